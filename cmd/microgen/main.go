@@ -8,7 +8,10 @@ import (
 	"path/filepath"
 	"strings"
 
+	"github.com/dave/jennifer/jen"
 	"github.com/devimteam/microgen/generator"
+	"github.com/devimteam/microgen/generator/openapi"
+	"github.com/devimteam/microgen/generator/proto"
 	mstrings "github.com/devimteam/microgen/generator/strings"
 	"github.com/devimteam/microgen/generator/template"
 	lg "github.com/devimteam/microgen/logger"
@@ -28,8 +31,16 @@ var (
 	flagDebug        = flag.Bool("debug", false, "Print all microgen messages. Equivalent to -v=100.")
 	flagGenProtofile = flag.String(".proto", "", "Package field in protobuf file. If not empty, service.proto file will be generated.")
 	flagGenMain      = flag.Bool(generator.MainTag, false, "Generate main.go file.")
+	flagFromProto    = flag.String("from-proto", "", "Path to .proto file. If not empty, input file with interface will be generated from it.")
+	flagFromOpenAPI  = flag.String("from-openapi", "", "Path to OpenAPI 3 document. If not empty, input file with interface will be generated from it.")
 )
 
+// Tags of interface, generated from .proto file.
+var fromProtoTags = []string{generator.MiddlewareTag, generator.LoggingMiddlewareTag, generator.GrpcTag}
+
+// Tags of interface, generated from OpenAPI document.
+var fromOpenAPITags = []string{generator.MiddlewareTag, generator.LoggingMiddlewareTag, generator.HttpTag}
+
 func init() {
 	flag.Parse()
 }
@@ -45,6 +56,25 @@ func main() {
 		os.Exit(0)
 	}
 
+	if *flagFromProto != "" && *flagFromOpenAPI != "" {
+		lg.Logger.Logln(0, "fatal: -from-proto and -from-openapi can not be used together")
+		os.Exit(1)
+	}
+	if *flagFromProto != "" {
+		lg.Logger.Logln(4, "Protobuf file:", *flagFromProto)
+		if err := generateFromProto(*flagFromProto, *flagFileName); err != nil {
+			lg.Logger.Logln(0, "fatal:", err)
+			os.Exit(1)
+		}
+	}
+	if *flagFromOpenAPI != "" {
+		lg.Logger.Logln(4, "OpenAPI document:", *flagFromOpenAPI)
+		if err := generateFromOpenAPI(*flagFromOpenAPI, *flagFileName); err != nil {
+			lg.Logger.Logln(0, "fatal:", err)
+			os.Exit(1)
+		}
+	}
+
 	lg.Logger.Logln(4, "Source file:", *flagFileName)
 	info, err := astra.ParseFile(*flagFileName)
 	if err != nil {
@@ -60,10 +90,22 @@ func main() {
 		os.Exit(1)
 	}
 
-	if err := generator.ValidateInterface(i); err != nil {
+	if err := generator.RegisterTypeMappings(i, info); err != nil {
+		lg.Logger.Logln(0, "fatal:", err)
+		os.Exit(1)
+	}
+	if err := generator.ValidateInterface(i, *flagFileName); err != nil {
 		lg.Logger.Logln(0, "validation:", err)
 		os.Exit(1)
 	}
+	if err := generator.ValidateProtobuf(i, *flagFileName); err != nil {
+		// Package is compiled from service.proto, so it is outdated, until regenerated service.proto is compiled.
+		if *flagGenProtofile == "" {
+			lg.Logger.Logln(0, "validation:", err)
+			os.Exit(1)
+		}
+		lg.Logger.Logln(1, "Warning: protobuf package does not match interface, compile it from regenerated service.proto:", err)
+	}
 
 	ctx, err := prepareContext(*flagFileName, i)
 	if err != nil {
@@ -116,6 +158,54 @@ func prepareContext(filename string, iface *types.Interface) (context.Context, e
 	return ctx, nil
 }
 
+// Writes go file with interface and entities, restored from .proto file.
+func generateFromProto(protoFile, goFile string) error {
+	file, err := proto.ParseFile(protoFile)
+	if err != nil {
+		return err
+	}
+	absGoFile, err := filepath.Abs(goFile)
+	if err != nil {
+		return err
+	}
+	pkgName := strings.Replace(filepath.Base(filepath.Dir(absGoFile)), "-", "_", -1)
+	f, err := proto.GoFile(file, pkgName, fromProtoTags)
+	if err != nil {
+		return fmt.Errorf("%s: %v", protoFile, err)
+	}
+	return writeGoFile(f, absGoFile)
+}
+
+// Writes go file with interface and entities, restored from OpenAPI document.
+func generateFromOpenAPI(docFile, goFile string) error {
+	doc, err := openapi.ParseFile(docFile)
+	if err != nil {
+		return err
+	}
+	absGoFile, err := filepath.Abs(goFile)
+	if err != nil {
+		return err
+	}
+	pkgName := strings.Replace(filepath.Base(filepath.Dir(absGoFile)), "-", "_", -1)
+	f, err := openapi.GoFile(doc, pkgName, fromOpenAPITags)
+	if err != nil {
+		return fmt.Errorf("%s: %v", docFile, err)
+	}
+	return writeGoFile(f, absGoFile)
+}
+
+func writeGoFile(f *jen.File, absGoFile string) error {
+	if err := os.MkdirAll(filepath.Dir(absGoFile), 0777); err != nil {
+		return err
+	}
+	out, err := os.Create(absGoFile)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+	return f.Render(out)
+}
+
 func findInterface(file *types.File) *types.Interface {
 	for i := range file.Interfaces {
 		if docsContainMicrogenTag(file.Interfaces[i].Docs) {
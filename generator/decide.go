@@ -18,6 +18,7 @@ const (
 	MicrogenMainTag = template.MicrogenMainTag
 	ProtobufTag     = "protobuf"
 	GRPCClientAddr  = "grpc-addr"
+	TypeMappingTag  = "type-mapping"
 
 	MiddlewareTag             = template.MiddlewareTag
 	LoggingMiddlewareTag      = template.LoggingMiddlewareTag
@@ -40,6 +41,9 @@ const (
 	TransportServer           = template.TransportServer
 	MetricsMiddlewareTag      = template.MetricsMiddlewareTag
 	ServiceDiscoveryTag       = template.ServiceDiscoveryTag
+	GrpcHealthTag             = template.GrpcHealthTag
+	GrpcReflectionTag         = template.GrpcReflectionTag
+	GrpcDirectTag             = template.GrpcDirectTag
 
 	HttpMethodTag  = template.HttpMethodTag
 	HttpMethodPath = template.HttpMethodPath
@@ -133,6 +137,9 @@ func tagToTemplate(tag string, info *template.GenerationInfo) (tmpls []template.
 			template.NewGRPCServerTemplate(info),
 			template.NewGRPCEndpointConverterTemplate(info),
 			template.NewStubGRPCTypeConverterTemplate(info),
+			template.NewGRPCMetadataTemplate(info),
+			template.NewGRPCGatewayTemplate(info),
+			template.NewGRPCMessageConvertersTemplate(info),
 		)
 	case GrpcClientTag:
 		return append(
@@ -140,6 +147,8 @@ func tagToTemplate(tag string, info *template.GenerationInfo) (tmpls []template.
 			template.NewGRPCClientTemplate(info),
 			template.NewGRPCEndpointConverterTemplate(info),
 			template.NewStubGRPCTypeConverterTemplate(info),
+			template.NewGRPCMetadataTemplate(info),
+			template.NewGRPCMessageConvertersTemplate(info),
 		)
 	case GrpcServerTag:
 		return append(
@@ -147,6 +156,9 @@ func tagToTemplate(tag string, info *template.GenerationInfo) (tmpls []template.
 			template.NewGRPCServerTemplate(info),
 			template.NewGRPCEndpointConverterTemplate(info),
 			template.NewStubGRPCTypeConverterTemplate(info),
+			template.NewGRPCMetadataTemplate(info),
+			template.NewGRPCGatewayTemplate(info),
+			template.NewGRPCMessageConvertersTemplate(info),
 		)
 	case HttpTag:
 		return append(
@@ -154,18 +166,33 @@ func tagToTemplate(tag string, info *template.GenerationInfo) (tmpls []template.
 			template.NewHttpServerTemplate(info),
 			template.NewHttpClientTemplate(info),
 			template.NewHttpConverterTemplate(info),
+			template.NewHttpValidationTemplate(info),
+			template.NewExchangeJSONTemplate(info),
+			template.NewHttpErrorsTemplate(info),
+			template.NewHttpCodecsTemplate(info),
+			template.NewGRPCMessageConvertersTemplate(info),
 		)
 	case HttpServerTag:
 		return append(
 			append(tmpls, tagToTemplate(TransportServer, info)...),
 			template.NewHttpServerTemplate(info),
 			template.NewHttpConverterTemplate(info),
+			template.NewHttpValidationTemplate(info),
+			template.NewExchangeJSONTemplate(info),
+			template.NewHttpErrorsTemplate(info),
+			template.NewHttpCodecsTemplate(info),
+			template.NewGRPCMessageConvertersTemplate(info),
 		)
 	case HttpClientTag:
 		return append(
 			append(tmpls, tagToTemplate(TransportClient, info)...),
 			template.NewHttpClientTemplate(info),
 			template.NewHttpConverterTemplate(info),
+			template.NewHttpValidationTemplate(info),
+			template.NewExchangeJSONTemplate(info),
+			template.NewHttpErrorsTemplate(info),
+			template.NewHttpCodecsTemplate(info),
+			template.NewGRPCMessageConvertersTemplate(info),
 		)
 	case RecoveringMiddlewareTag:
 		return append(
@@ -191,6 +218,8 @@ func tagToTemplate(tag string, info *template.GenerationInfo) (tmpls []template.
 		return append(tmpls, template.EmptyTemplate{})
 	case ServiceDiscoveryTag:
 		return append(tmpls, template.EmptyTemplate{})
+	case GrpcHealthTag, GrpcReflectionTag, GrpcDirectTag:
+		return append(tmpls, template.EmptyTemplate{})
 	case Transport:
 		return append(tmpls,
 			template.NewExchangeTemplate(info),
@@ -1,14 +1,21 @@
 package generator
 
 import (
+	"context"
+	"flag"
 	"fmt"
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	gotypes "go/types"
 	"io/ioutil"
+	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"testing"
 
-	"context"
-
 	strings2 "github.com/devimteam/microgen/generator/strings"
 	"github.com/devimteam/microgen/generator/template"
 	"github.com/stretchr/testify/assert"
@@ -16,6 +23,8 @@ import (
 	"github.com/vetcher/go-astra/types"
 )
 
+var update = flag.Bool("update", false, "Rewrite expected files in test_assets with generated ones.")
+
 func findInterface(file *types.File, ifaceName string) *types.Interface {
 	for i := range file.Interfaces {
 		if file.Interfaces[i].Name == ifaceName {
@@ -37,22 +46,254 @@ func loadInterface(sourceFile, ifaceName string) (*types.Interface, error) {
 	return i, nil
 }
 
+// Creates GOPATH in temporary directory with files, which paths are relative to GOPATH/src.
+// Registry of type mappings is restored, when test is finished.
+func setupGopath(t *testing.T, files map[string]string) string {
+	gopath := t.TempDir()
+	t.Setenv("GOPATH", gopath)
+	template.ResetTypeMappings()
+	t.Cleanup(template.ResetTypeMappings)
+	for name, content := range files {
+		path := filepath.Join(gopath, "src", name)
+		if err := os.MkdirAll(filepath.Dir(path), 0777); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(content), 0666); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return gopath
+}
+
+// Returns context, prepared the same way, as microgen command prepares it.
+func testContext(t *testing.T, sourceFile string, iface *types.Interface) context.Context {
+	p, err := astra.ResolvePackagePath(sourceFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := template.WithSourcePackageImport(context.Background(), p)
+	set := template.TagsSet{}
+	for _, tag := range strings2.FetchTags(iface.Docs, TagMark+MicrogenMainTag) {
+		set.Add(tag)
+	}
+	return template.WithTags(ctx, set)
+}
+
+// Generates files for interface of source file, as microgen command does, to the package of source file.
+// Path of source file is relative to GOPATH/src.
+// Returns content of generated files by paths, relative to package.
+func generate(t *testing.T, files map[string]string, source, ifaceName, genProto string) map[string]string {
+	gopath := setupGopath(t, files)
+	return generateSource(t, filepath.Join(gopath, "src", source), ifaceName, genProto, false)
+}
+
+// Generates files for interface of source file to the package of source file, files of package
+// are generated again, when it is called several times.
+func generateSource(t *testing.T, sourceFile, ifaceName, genProto string, genMain bool) map[string]string {
+	file, err := astra.ParseFile(sourceFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	iface := findInterface(file, ifaceName)
+	if iface == nil {
+		t.Fatalf("could not find %s interface", ifaceName)
+	}
+	if err := RegisterTypeMappings(iface, file); err != nil {
+		t.Fatal(err)
+	}
+	if err := ValidateInterface(iface, sourceFile); err != nil {
+		t.Fatal(err)
+	}
+	ctx := testContext(t, sourceFile, iface)
+	outDir := filepath.Dir(sourceFile)
+	units, err := ListTemplatesForGen(ctx, iface, outDir, sourceFile, genProto, genMain)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, unit := range units {
+		if err := unit.Generate(ctx); err != nil && err != EmptyStrategyError {
+			t.Fatal(err)
+		}
+	}
+	generated := make(map[string]string)
+	err = filepath.Walk(outDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(outDir, path)
+		if err != nil {
+			return err
+		}
+		// Go files of package are sources.
+		if filepath.Dir(rel) == "." && filepath.Ext(rel) == ".go" {
+			return nil
+		}
+		content, err := ioutil.ReadFile(path)
+		generated[filepath.ToSlash(rel)] = string(content)
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return generated
+}
+
+// Returns generated files with provided names.
+func pick(t *testing.T, generated map[string]string, names ...string) map[string]string {
+	picked := make(map[string]string, len(names))
+	for _, name := range names {
+		content, ok := generated[name]
+		if !ok {
+			t.Fatalf("%s is not generated", name)
+		}
+		picked[name] = content
+	}
+	return picked
+}
+
+// Compares generated files with expected files in test_assets/<dir>, file names are suffixed with .txt.
+// Expected files are rewritten, when test is run with -update flag.
+func assertGolden(t *testing.T, dir string, generated map[string]string) {
+	dir = filepath.Join("test_assets", dir)
+	if *update {
+		if err := os.RemoveAll(dir); err != nil {
+			t.Fatal(err)
+		}
+		for name, content := range generated {
+			path := filepath.Join(dir, name+".txt")
+			if err := os.MkdirAll(filepath.Dir(path), 0777); err != nil {
+				t.Fatal(err)
+			}
+			if err := ioutil.WriteFile(path, []byte(content), 0666); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return
+	}
+	var expectedNames []string
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		expectedNames = append(expectedNames, strings.TrimSuffix(filepath.ToSlash(rel), ".txt"))
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(expectedNames)
+	var generatedNames []string
+	for name := range generated {
+		generatedNames = append(generatedNames, name)
+	}
+	sort.Strings(generatedNames)
+	assert.Equal(t, expectedNames, generatedNames, "generated files")
+	for _, name := range generatedNames {
+		expected, err := ioutil.ReadFile(filepath.Join(dir, name+".txt"))
+		if err != nil {
+			continue
+		}
+		assert.Equal(t,
+			strings.Split(string(expected), "\n"),
+			strings.Split(generated[name], "\n"),
+			name,
+		)
+	}
+}
+
+// Type checks generated packages, which paths are relative to GOPATH/src, prepared by generate.
+// Packages of GOPATH, e.g. stubs of protobuf packages, are checked from sources. Other packages,
+// except standard library, e.g. go-kit or grpc, are not available in tests, so errors of expressions,
+// which use them, are not reported. Variables, passed to such expressions, look unused,
+// so soft errors about unused variables and imports are not reported too.
+func assertCompiles(t *testing.T, pkgs ...string) {
+	imp := &gopathImporter{
+		fset:   token.NewFileSet(),
+		gopath: os.Getenv("GOPATH"),
+		std:    importer.Default(),
+		pkgs:   make(map[string]*gotypes.Package),
+	}
+	for _, path := range pkgs {
+		if _, err := imp.Import(path); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, err := range imp.errs {
+		t.Error(err)
+	}
+}
+
+type gopathImporter struct {
+	fset   *token.FileSet
+	gopath string
+	std    gotypes.Importer
+	pkgs   map[string]*gotypes.Package
+	errs   []error
+}
+
+func (i *gopathImporter) Import(path string) (*gotypes.Package, error) {
+	if pkg, ok := i.pkgs[path]; ok {
+		return pkg, nil
+	}
+	dir := filepath.Join(i.gopath, "src", filepath.FromSlash(path))
+	if _, err := os.Stat(dir); err != nil {
+		if !strings.Contains(strings.Split(path, "/")[0], ".") {
+			return i.std.Import(path)
+		}
+		return nil, err
+	}
+	parsed, err := parser.ParseDir(i.fset, dir, func(info os.FileInfo) bool {
+		return !strings.HasSuffix(info.Name(), "_test.go")
+	}, 0)
+	if err != nil {
+		return nil, err
+	}
+	var files []*ast.File
+	for _, p := range parsed {
+		for _, file := range p.Files {
+			files = append(files, file)
+		}
+	}
+	conf := gotypes.Config{
+		Importer: i,
+		Error: func(err error) {
+			if e := err.(gotypes.Error); !e.Soft && !strings.Contains(e.Msg, "could not import") {
+				i.errs = append(i.errs, err)
+			}
+		},
+	}
+	pkg, _ := conf.Check(path, i.fset, files, nil)
+	i.pkgs[path] = pkg
+	return pkg, nil
+}
+
 func TestTemplates(t *testing.T) {
-	outPath := "./test_out/"
-	sourcePath := "./test_assets/service.go.txt"
-	absSourcePath, err := filepath.Abs(sourcePath)
-	importPackagePath, err := resolvePackagePath(outPath)
+	service, err := ioutil.ReadFile("./test_assets/service.go.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	gopath := setupGopath(t, map[string]string{"github.com/devimteam/microgen/example/svc/service.go": string(service)})
+	sourcePath := filepath.Join(gopath, "src", "github.com/devimteam/microgen/example/svc/service.go")
+	importPackagePath, err := resolvePackagePath(filepath.Dir(sourcePath))
+	if err != nil {
+		t.Fatal(err)
+	}
 	iface, err := loadInterface(sourcePath, "StringService")
 	if err != nil {
 		t.Fatal(err)
 	}
+	ctx := testContext(t, sourcePath, iface)
 
 	genInfo := &template.GenerationInfo{
 		SourcePackageImport:   importPackagePath,
 		Iface:                 iface,
-		OutputFilePath:        outPath,
-		SourceFilePath:        absSourcePath,
+		OutputPackageImport:   importPackagePath,
+		OutputFilePath:        filepath.Dir(sourcePath),
+		SourceFilePath:        sourcePath,
 		ProtobufPackageImport: strings2.FetchMetaInfo(TagMark+ProtobufTag, iface.Docs),
+		FileHeader:            defaultFileHeader,
+		AllowedMethods:        map[string]bool{"Uppercase": true, "Count": true, "TestCase": true},
 	}
 	t.Log("protobuf pkg", genInfo.ProtobufPackageImport)
 
@@ -64,12 +305,12 @@ func TestTemplates(t *testing.T) {
 		{
 			TestName:    "Endpoints",
 			Template:    template.NewEndpointsTemplate(genInfo),
-			OutFilePath: "transport_endpoints.go.txt",
+			OutFilePath: "endpoints.go.txt",
 		},
 		{
 			TestName:    "Exchange",
 			Template:    template.NewExchangeTemplate(genInfo),
-			OutFilePath: "transport_exchanges.go.txt",
+			OutFilePath: "exchanges.go.txt",
 		},
 		{
 			TestName:    "Middleware",
@@ -104,24 +345,27 @@ func TestTemplates(t *testing.T) {
 	}
 	for _, test := range allTemplateTests {
 		t.Run(test.TestName, func(t *testing.T) {
-			expected, err := ioutil.ReadFile("test_assets/" + test.OutFilePath)
-			if err != nil {
-				t.Fatalf("read expected file error: %v", err)
-			}
-
-			absOutPath := "./test_out/"
-			gen, err := NewGenUnit(context.Background(), test.Template, absOutPath)
+			gen, err := NewGenUnit(ctx, test.Template, genInfo.OutputFilePath)
 			if err != nil {
 				t.Fatalf("NewGenUnit: %v", err)
 			}
-			err = gen.Generate(context.Background())
+			err = gen.Generate(ctx)
 			if err != nil {
 				t.Fatalf("unable to generate: %v", err)
 			}
-			actual, err := ioutil.ReadFile("./test_out/" + test.Template.DefaultPath())
+			actual, err := ioutil.ReadFile(filepath.Join(genInfo.OutputFilePath, test.Template.DefaultPath()))
 			if err != nil {
 				t.Fatalf("read actual file error: %v", err)
 			}
+			if *update {
+				if err := ioutil.WriteFile("test_assets/"+test.OutFilePath, actual, 0666); err != nil {
+					t.Fatal(err)
+				}
+			}
+			expected, err := ioutil.ReadFile("test_assets/" + test.OutFilePath)
+			if err != nil {
+				t.Fatalf("read expected file error: %v", err)
+			}
 			assert.Equal(t,
 				strings.Split(string(expected[:]), "\n"),
 				strings.Split(string(actual[:]), "\n"),
@@ -129,3 +373,953 @@ func TestTemplates(t *testing.T) {
 		})
 	}
 }
+
+func TestAdaptedMethods(t *testing.T) {
+	generated := generate(t, map[string]string{
+		"example.com/svc/service.go": `package svc
+
+import "context"
+
+// @microgen transport
+type Service interface {
+	Count(text string) (count int)
+	Ping(ctx context.Context) (err error)
+	Upper(ctx context.Context, text string) (upper string)
+}
+`,
+	}, "example.com/svc/service.go", "Service", "")
+	assertGolden(t, "adapted_methods", generated)
+}
+
+func TestProtoScalarTypes(t *testing.T) {
+	generated := generate(t, map[string]string{
+		"example.com/svc/service.go": `package svc
+
+import "context"
+
+// @microgen grpc
+// @protobuf example.com/svc/pb
+type Service interface {
+	Scalars(ctx context.Context, a float64, b float32, c int8, d int16, e rune, f uint8, g uint16, h byte, raw []byte) (err error)
+}
+`,
+	}, "example.com/svc/service.go", "Service", "svc")
+	assertGolden(t, "proto_scalar_types", pick(t, generated, "service.proto"))
+}
+
+func TestProtoLock(t *testing.T) {
+	const source = `package svc
+
+import "context"
+
+// @microgen grpc
+// @protobuf example.com/svc/pb
+type Service interface {
+	Count(ctx context.Context, %s) (err error)
+}
+`
+	gopath := setupGopath(t, nil)
+	sourceFile := filepath.Join(gopath, "src", "example.com/svc/service.go")
+	steps := []struct {
+		name string
+		args string
+	}{
+		{name: "initial", args: "text string, symbol string"},
+		// New field gets the next number, removed one is reserved.
+		{name: "add_remove", args: "limit int, text string"},
+		// Renamed field is a new field, old name is reserved.
+		{name: "rename", args: "limit int, content string"},
+		// Removed field, which is restored, keeps its number.
+		{name: "restore", args: "symbol string, limit int, content string"},
+	}
+	for _, step := range steps {
+		if err := os.MkdirAll(filepath.Dir(sourceFile), 0777); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(sourceFile, []byte(fmt.Sprintf(source, step.args)), 0666); err != nil {
+			t.Fatal(err)
+		}
+		generated := generateSource(t, sourceFile, "Service", "svc", false)
+		assertGolden(t, filepath.Join("proto_lock", step.name), pick(t, generated, "service.proto", "service.proto.lock"))
+	}
+}
+
+func TestProtobufTypeConverters(t *testing.T) {
+	generated := generate(t, map[string]string{
+		"example.com/svc/service.go": `package svc
+
+import (
+	"context"
+	"time"
+
+	"example.com/svc/entity"
+)
+
+// @microgen grpc
+// @protobuf example.com/svc/pb
+type Service interface {
+	Small(ctx context.Context, a int8, b int16, c uint8, d uint16, e float32, f rune) (g int8, h float64, err error)
+	Lists(ctx context.Context, ids []int8, names []string, values map[string]int16, items map[string]*entity.Item) (items2 []*entity.Item, err error)
+	Times(ctx context.Context, at time.Time, until *time.Time, timeout time.Duration) (item *entity.Item, err error)
+}
+`,
+		"example.com/svc/entity/entity.go": `package entity
+
+import "time"
+
+type Item struct {
+	ID      int8
+	Weight  float32
+	Ratio   float64
+	Tags    []string
+	Counts  map[string]uint16
+	Created time.Time
+	Parent  *Item
+	Limit   *int16
+}
+`,
+	}, "example.com/svc/service.go", "Service", "svc")
+	assertGolden(t, "protobuf_type_converters", pick(t, generated,
+		"service.proto",
+		"transport/grpc/protobuf_type_converters.microgen.go",
+		"transport/grpc/protobuf_endpoint_converters.microgen.go",
+	))
+}
+
+func TestTypeMappingConverters(t *testing.T) {
+	generated := generate(t, map[string]string{
+		"example.com/svc/service.go": `package svc
+
+import (
+	"context"
+
+	"example.com/conv"
+	"example.com/uuid"
+)
+
+// @microgen grpc
+// @protobuf example.com/svc/pb
+// @type-mapping uuid.UUID proto=string to-proto=conv.UUIDToString from-proto=conv.StringToUUID to-string=.String from-string=uuid.Parse
+// @type-mapping conv.Money proto=int64
+type Service interface {
+	Get(ctx context.Context, id uuid.UUID, ids []uuid.UUID, owner *uuid.UUID) (price conv.Money, prices map[string]conv.Money, err error)
+}
+`,
+		"example.com/uuid/uuid.go": `package uuid
+
+type UUID [16]byte
+
+func (u UUID) String() string { return "" }
+
+func Parse(s string) (UUID, error) { return UUID{}, nil }
+`,
+		"example.com/conv/conv.go": `package conv
+
+import "example.com/uuid"
+
+type Money int64
+
+func UUIDToString(u uuid.UUID) (string, error) { return u.String(), nil }
+
+func StringToUUID(s string) (uuid.UUID, error) { return uuid.Parse(s) }
+`,
+	}, "example.com/svc/service.go", "Service", "svc")
+	assertGolden(t, "type_mapping_converters", pick(t, generated,
+		"service.proto",
+		"transport/grpc/protobuf_type_converters.microgen.go",
+		"transport/grpc/protobuf_endpoint_converters.microgen.go",
+	))
+}
+
+func TestGRPCStreams(t *testing.T) {
+	generated := generate(t, map[string]string{
+		"example.com/svc/service.go": `package svc
+
+import "context"
+
+type Event struct {
+	Name string
+}
+
+// @microgen grpc
+// @protobuf example.com/svc/pb
+type Service interface {
+	Watch(ctx context.Context, filter string) (events <-chan *Event, err error)
+	Upload(ctx context.Context, name string, chunks <-chan []byte) (size int, err error)
+	Chat(ctx context.Context, in <-chan string) (out <-chan string, err error)
+}
+`,
+		"example.com/svc/pb/service.pb.go": `package pb
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+)
+
+type WatchRequest struct{ Filter string }
+type WatchResponse struct{ Events *Event }
+type UploadRequest struct {
+	Name   string
+	Chunks []byte
+}
+type UploadResponse struct{ Size int64 }
+type ChatRequest struct{ In string }
+type ChatResponse struct{ Out string }
+type Event struct{ Name string }
+
+type ServiceClient interface {
+	Watch(ctx context.Context, in *WatchRequest, opts ...grpc.CallOption) (Service_WatchClient, error)
+	Upload(ctx context.Context, opts ...grpc.CallOption) (Service_UploadClient, error)
+	Chat(ctx context.Context, opts ...grpc.CallOption) (Service_ChatClient, error)
+}
+
+type Service_WatchClient interface {
+	Recv() (*WatchResponse, error)
+}
+
+type Service_UploadClient interface {
+	Send(*UploadRequest) error
+	CloseAndRecv() (*UploadResponse, error)
+}
+
+type Service_ChatClient interface {
+	Send(*ChatRequest) error
+	Recv() (*ChatResponse, error)
+	CloseSend() error
+}
+
+func NewServiceClient(cc *grpc.ClientConn) ServiceClient { return nil }
+
+type ServiceServer interface {
+	Watch(*WatchRequest, Service_WatchServer) error
+	Upload(Service_UploadServer) error
+	Chat(Service_ChatServer) error
+}
+
+type Service_WatchServer interface {
+	Send(*WatchResponse) error
+	Context() context.Context
+}
+
+type Service_UploadServer interface {
+	SendAndClose(*UploadResponse) error
+	Recv() (*UploadRequest, error)
+	Context() context.Context
+}
+
+type Service_ChatServer interface {
+	Send(*ChatResponse) error
+	Recv() (*ChatRequest, error)
+	Context() context.Context
+}
+`,
+	}, "example.com/svc/service.go", "Service", "svc")
+	assertGolden(t, "grpc_streams", pick(t, generated,
+		"service.proto",
+		"transport/grpc/server.microgen.go",
+		"transport/grpc/client.microgen.go",
+		"transport/grpc/protobuf_endpoint_converters.microgen.go",
+		"transport/grpc/protobuf_type_converters.microgen.go",
+	))
+	assertCompiles(t, "example.com/svc/transport/grpc")
+}
+
+func TestGRPCErrors(t *testing.T) {
+	generated := generate(t, map[string]string{
+		"example.com/svc/service.go": `package svc
+
+import (
+	"context"
+	"errors"
+
+	"example.com/svc/entity"
+)
+
+var (
+	ErrNotFound  = errors.New("not found")
+	ErrForbidden = errors.New("forbidden")
+)
+
+// @microgen grpc
+// @protobuf example.com/svc/pb
+// @grpc-error ErrNotFound=NotFound ErrForbidden=PermissionDenied
+type Service interface {
+	Get(ctx context.Context, id string) (text string, err error)
+	// @grpc-error entity.ErrExists=AlreadyExists ErrForbidden=Unauthenticated
+	Create(ctx context.Context, text string) (err error)
+}
+`,
+		"example.com/svc/entity/entity.go": `package entity
+
+import "errors"
+
+var ErrExists = errors.New("exists")
+`,
+	}, "example.com/svc/service.go", "Service", "")
+	assertGolden(t, "grpc_errors", pick(t, generated,
+		"transport/grpc/server.microgen.go",
+		"transport/grpc/client.microgen.go",
+	))
+}
+
+func TestMainGRPCHealth(t *testing.T) {
+	gopath := setupGopath(t, map[string]string{
+		"example.com/svc/service.go": `package svc
+
+import "context"
+
+// @microgen grpc-server, grpc-health, grpc-reflection
+// @protobuf example.com/svc/pb
+type Service interface {
+	Ping(ctx context.Context) (err error)
+}
+`,
+	})
+	generated := generateSource(t, filepath.Join(gopath, "src", "example.com/svc/service.go"), "Service", "", true)
+	assertGolden(t, "main_grpc_health", pick(t, generated, "cmd/service/main.go"))
+}
+
+func TestGRPCMetadata(t *testing.T) {
+	generated := generate(t, map[string]string{
+		"example.com/svc/service.go": `package svc
+
+import (
+	"context"
+
+	"example.com/svc/ctxkeys"
+)
+
+type contextKey string
+
+const TenantKey contextKey = "tenant"
+
+// @microgen grpc
+// @protobuf example.com/svc/pb
+// @grpc-metadata x-tenant-id=TenantKey
+type Service interface {
+	// @grpc-metadata x-request-id=ctxkeys.RequestID
+	Count(ctx context.Context, text string) (count int, err error)
+	Ping(ctx context.Context) (err error)
+}
+`,
+		"example.com/svc/ctxkeys/ctxkeys.go": `package ctxkeys
+
+type key int
+
+const RequestID key = 0
+`,
+	}, "example.com/svc/service.go", "Service", "")
+	assertGolden(t, "grpc_metadata", pick(t, generated,
+		"transport/grpc/metadata.microgen.go",
+		"transport/grpc/server.microgen.go",
+		"transport/grpc/client.microgen.go",
+	))
+}
+
+func TestGRPCClientSD(t *testing.T) {
+	generated := generate(t, map[string]string{
+		"example.com/svc/service.go": `package svc
+
+import "context"
+
+// @microgen grpc, service-discovery
+// @protobuf example.com/svc/pb
+// @grpc-addr svc.Service
+type Service interface {
+	Count(ctx context.Context, text string) (count int, err error)
+	Ping(ctx context.Context) (err error)
+}
+`,
+	}, "example.com/svc/service.go", "Service", "")
+	assertGolden(t, "grpc_client_sd", pick(t, generated, "transport/grpc/client.microgen.go"))
+}
+
+func TestGRPCGateway(t *testing.T) {
+	generated := generate(t, map[string]string{
+		"example.com/svc/service.go": `package svc
+
+import "context"
+
+// @microgen grpc
+// @protobuf example.com/svc/pb
+type CommentService interface {
+	// @http-method GET
+	// @http-path /comments/{id}
+	GetComment(ctx context.Context, id string) (text string, err error)
+	// @http-method POST
+	// @http-path /posts/{postID}/comments
+	AddComment(ctx context.Context, postID string, text string) (id string, err error)
+	// @http-method DELETE
+	DeleteComment(ctx context.Context, id string, force bool) (err error)
+	Ping(ctx context.Context) (err error)
+}
+`,
+	}, "example.com/svc/service.go", "CommentService", "svc")
+	assertGolden(t, "grpc_gateway", pick(t, generated, "service.proto", "transport/grpc/gateway.microgen.go"))
+}
+
+func TestProtoEnums(t *testing.T) {
+	generated := generate(t, map[string]string{
+		"example.com/svc/service.go": `package svc
+
+import (
+	"context"
+
+	"example.com/svc/entity"
+)
+
+// @microgen grpc
+// @protobuf example.com/svc/pb
+type Service interface {
+	Set(ctx context.Context, status entity.Status, kind entity.Kind) (err error)
+	Get(ctx context.Context, id string) (item *entity.Item, err error)
+}
+`,
+		"example.com/svc/entity/entity.go": `package entity
+
+type Status string
+
+const (
+	StatusDraft     Status = "draft"
+	StatusPublished Status = "published"
+)
+
+type Kind int
+
+const (
+	KindUnknown Kind = iota
+	KindArticle
+	KindNews
+)
+
+type Item struct {
+	Status Status
+	Kinds  []Kind
+}
+`,
+	}, "example.com/svc/service.go", "Service", "svc")
+	assertGolden(t, "proto_enums", pick(t, generated,
+		"service.proto",
+		"transport/grpc/protobuf_type_converters.microgen.go",
+		"transport/grpc/protobuf_endpoint_converters.microgen.go",
+	))
+}
+
+func TestProtoDynamicTypes(t *testing.T) {
+	generated := generate(t, map[string]string{
+		"example.com/svc/service.go": `package svc
+
+import (
+	"context"
+
+	"github.com/golang/protobuf/proto"
+)
+
+// @microgen grpc
+// @protobuf example.com/svc/pb
+type Service interface {
+	Put(ctx context.Context, value interface{}, attrs map[string]interface{}, payload proto.Message) (err error)
+	List(ctx context.Context) (values []interface{}, payloads []proto.Message, err error)
+}
+`,
+	}, "example.com/svc/service.go", "Service", "svc")
+	assertGolden(t, "proto_dynamic_types", pick(t, generated,
+		"service.proto",
+		"transport/grpc/protobuf_type_converters.microgen.go",
+		"transport/grpc/protobuf_endpoint_converters.microgen.go",
+	))
+}
+
+func TestGRPCDirect(t *testing.T) {
+	generated := generate(t, map[string]string{
+		"example.com/svc/service.go": `package svc
+
+import "context"
+
+// @microgen grpc, grpc-direct
+// @protobuf example.com/svc/pb
+type Service interface {
+	Count(ctx context.Context, text string) (count int, err error)
+	Ping(ctx context.Context) (err error)
+}
+`,
+	}, "example.com/svc/service.go", "Service", "")
+	// Endpoints pass protobuf messages, so exchanges are neither generated nor used.
+	if _, ok := generated["transport/exchanges.microgen.go"]; ok {
+		t.Error("exchanges are generated for direct grpc transport")
+	}
+	for name, content := range generated {
+		for _, exchange := range []string{"transport.CountRequest", "transport.CountResponse", "transport.PingRequest", "transport.PingResponse"} {
+			if strings.Contains(content, exchange) {
+				t.Errorf("%s uses exchange %s", name, exchange)
+			}
+		}
+	}
+	assertGolden(t, "grpc_direct", pick(t, generated,
+		"transport/server.microgen.go",
+		"transport/client.microgen.go",
+		"transport/grpc/protobuf_endpoint_converters.microgen.go",
+	))
+}
+
+func TestExchangesJSON(t *testing.T) {
+	generated := generate(t, map[string]string{
+		"example.com/svc/service.go": `package svc
+
+import (
+	"context"
+	"time"
+)
+
+// @microgen http
+type Service interface {
+	Put(ctx context.Context, name string, count int, ratio float64, enabled bool, limit *uint8, tags []string, at time.Time) (id int64, err error)
+	Ping(ctx context.Context) (err error)
+}
+`,
+	}, "example.com/svc/service.go", "Service", "")
+	assertGolden(t, "exchanges_json", pick(t, generated,
+		"transport/exchanges.microgen.go",
+		"transport/exchanges_json.microgen.go",
+	))
+}
+
+func TestHTTPErrors(t *testing.T) {
+	generated := generate(t, map[string]string{
+		"example.com/svc/service.go": `package svc
+
+import (
+	"context"
+	"errors"
+
+	"example.com/svc/entity"
+)
+
+var (
+	ErrNotFound  = errors.New("not found")
+	ErrForbidden = errors.New("forbidden")
+)
+
+// @microgen http
+// @http-error ErrNotFound=404 ErrForbidden=403
+type Service interface {
+	Get(ctx context.Context, id string) (text string, err error)
+	// @http-error entity.ErrExists=409 entity.ErrGone=404
+	Create(ctx context.Context, text string) (err error)
+}
+`,
+		"example.com/svc/entity/entity.go": `package entity
+
+import "errors"
+
+var (
+	ErrExists = errors.New("exists")
+	ErrGone   = errors.New("gone")
+)
+`,
+	}, "example.com/svc/service.go", "Service", "")
+	assertGolden(t, "http_errors", pick(t, generated,
+		"transport/http/errors.microgen.go",
+		"transport/http/client.microgen.go",
+	))
+}
+
+func TestHTTPContentNegotiation(t *testing.T) {
+	generated := generate(t, map[string]string{
+		"example.com/svc/service.go": `package svc
+
+import "context"
+
+// @microgen http, grpc
+// @protobuf example.com/svc/pb
+type Service interface {
+	// @http-method GET
+	Count(ctx context.Context, text string, limit int) (count int, err error)
+	// @http-method POST
+	Add(ctx context.Context, tags []string, weight float64) (id string, err error)
+}
+`,
+	}, "example.com/svc/service.go", "Service", "")
+	assertGolden(t, "http_content_negotiation", pick(t, generated,
+		"transport/http/codecs.microgen.go",
+		"transport/http/converters.microgen.go",
+		"transport/http/client.microgen.go",
+		"transport/grpc/message_converters.microgen.go",
+	))
+}
+
+func TestHTTPFiles(t *testing.T) {
+	generated := generate(t, map[string]string{
+		"example.com/svc/service.go": `package svc
+
+import (
+	"context"
+	"io"
+)
+
+// @microgen http
+type FileService interface {
+	// @http-file data
+	// @http-path /files/{name}
+	Upload(ctx context.Context, name string, data io.Reader) (size int64, err error)
+	// @http-file avatar=image
+	UpdateProfile(ctx context.Context, name string, tags []string, image []byte) (err error)
+	// @http-method GET
+	// @http-path /files/{name}
+	Download(ctx context.Context, name string) (body io.ReadCloser, contentType string, err error)
+}
+`,
+	}, "example.com/svc/service.go", "FileService", "")
+	assertGolden(t, "http_files", pick(t, generated,
+		"transport/http/converters.microgen.go",
+		"transport/http/client.microgen.go",
+		"transport/http/server.microgen.go",
+		"transport/http/codecs.microgen.go",
+	))
+}
+
+func TestProtoBytes(t *testing.T) {
+	generated := generate(t, map[string]string{
+		"example.com/svc/service.go": `package svc
+
+import (
+	"context"
+
+	"example.com/svc/entity"
+)
+
+// @microgen grpc
+// @protobuf example.com/svc/pb
+type Service interface {
+	Put(ctx context.Context, data []byte, sizes []uint8, chunks [][]byte) (raw []byte, file *entity.File, err error)
+}
+`,
+		"example.com/svc/entity/entity.go": `package entity
+
+type File struct {
+	Name    string
+	Content []byte
+}
+`,
+	}, "example.com/svc/service.go", "Service", "svc")
+	assertGolden(t, "proto_bytes", pick(t, generated,
+		"service.proto",
+		"transport/grpc/protobuf_type_converters.microgen.go",
+		"transport/grpc/protobuf_endpoint_converters.microgen.go",
+	))
+}
+
+func TestInlineParams(t *testing.T) {
+	const source = `package svc
+
+import "context"
+
+// @microgen grpc
+// @protobuf example.com/svc/pb
+type Service interface {
+	// @type-name opts=SearchFilter
+	Search(ctx context.Context, opts struct {
+		Query string ` + "`json:\"query\"`" + `
+		Limit int    ` + "`json:\"limit\"`" + `
+	}) (found []string, err error)
+	Update(ctx context.Context, id string, patch struct {
+		%s
+	}) (stats struct{ Changed int }, err error)
+}
+`
+	gopath := setupGopath(t, map[string]string{
+		"example.com/svc/pb/service.pb.go": `package pb
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+)
+
+type SearchRequest struct{ Opts *SearchFilter }
+type SearchResponse struct{ Found []string }
+type UpdateRequest struct {
+	Id    string
+	Patch *UpdatePatch
+}
+type UpdateResponse struct{ Stats *UpdateStats }
+type SearchFilter struct {
+	Query string
+	Limit int64
+}
+type UpdatePatch struct {
+	Name string
+	Tags []string
+}
+type UpdateStats struct{ Changed int64 }
+
+type ServiceServer interface {
+	Search(context.Context, *SearchRequest) (*SearchResponse, error)
+	Update(context.Context, *UpdateRequest) (*UpdateResponse, error)
+}
+
+type ServiceClient interface {
+	Search(ctx context.Context, in *SearchRequest, opts ...grpc.CallOption) (*SearchResponse, error)
+	Update(ctx context.Context, in *UpdateRequest, opts ...grpc.CallOption) (*UpdateResponse, error)
+}
+`,
+	})
+	sourceFile := filepath.Join(gopath, "src", "example.com/svc/service.go")
+	steps := []struct {
+		name   string
+		fields string
+	}{
+		{name: "initial", fields: "Name string; Tags []string"},
+		// Fields of inline structures are numbered by lock file the same as fields of other messages.
+		{name: "add_remove", fields: "Tags []string; Note string"},
+	}
+	for _, step := range steps {
+		if err := ioutil.WriteFile(sourceFile, []byte(fmt.Sprintf(source, step.fields)), 0666); err != nil {
+			t.Fatal(err)
+		}
+		generated := generateSource(t, sourceFile, "Service", "svc", false)
+		assertGolden(t, filepath.Join("inline_params", step.name), pick(t, generated,
+			"service.proto",
+			"service.proto.lock",
+			"transport/exchanges.microgen.go",
+			"transport/grpc/protobuf_endpoint_converters.microgen.go",
+			"transport/grpc/protobuf_type_converters.microgen.go",
+		))
+		if step.name == "initial" {
+			assertCompiles(t, "example.com/svc/transport/grpc")
+		}
+	}
+}
+
+func TestInlineFunctionParams(t *testing.T) {
+	gopath := setupGopath(t, map[string]string{
+		"example.com/svc/service.go": `package svc
+
+import "context"
+
+// @microgen grpc, http
+type Service interface {
+	Each(ctx context.Context, cb func(s string) error) (err error)
+}
+`,
+	})
+	sourceFile := filepath.Join(gopath, "src", "example.com/svc/service.go")
+	iface, err := loadInterface(sourceFile, "Service")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = ValidateInterface(iface, sourceFile)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "Each: function cb can not be transferred by grpc transport")
+	}
+}
+
+func TestProtoPointers(t *testing.T) {
+	const source = `package svc
+
+import (
+	"context"
+
+	"example.com/svc/entity"
+)
+
+// @microgen grpc
+// @protobuf example.com/svc/pb%s
+type Service interface {
+	Find(ctx context.Context, name *string, limit *int, status *entity.Status) (total *int64, profile *entity.Profile, err error)
+}
+`
+	const entity = `package entity
+
+type Status int
+
+const (
+	StatusActive Status = iota
+	StatusBlocked
+)
+
+type Profile struct {
+	Nick    *string
+	Age     *uint32
+	Score   *float64
+	Address *Address
+}
+
+type Address struct {
+	City string
+	Zip  *string
+}
+`
+	modes := []struct {
+		name string
+		tag  string
+		pb   string
+	}{
+		{name: "wrappers"},
+		{name: "optional", tag: "\n// @protobuf-pointers optional", pb: `package pb
+
+import "context"
+
+type Status int32
+
+type FindRequest struct {
+	Name   *string
+	Limit  *int64
+	Status *Status
+}
+
+type FindResponse struct {
+	Total   *int64
+	Profile *Profile
+}
+
+type Profile struct {
+	Nick    *string
+	Age     *uint32
+	Score   *float64
+	Address *Address
+}
+
+type Address struct {
+	City string
+	Zip  *string
+}
+
+type ServiceServer interface {
+	Find(context.Context, *FindRequest) (*FindResponse, error)
+}
+`},
+	}
+	for _, mode := range modes {
+		t.Run(mode.name, func(t *testing.T) {
+			files := map[string]string{
+				"example.com/svc/service.go":       fmt.Sprintf(source, mode.tag),
+				"example.com/svc/entity/entity.go": entity,
+			}
+			if mode.pb != "" {
+				files["example.com/svc/pb/service.pb.go"] = mode.pb
+			}
+			generated := generate(t, files, "example.com/svc/service.go", "Service", "svc")
+			assertGolden(t, filepath.Join("proto_pointers", mode.name), pick(t, generated,
+				"service.proto",
+				"transport/grpc/protobuf_endpoint_converters.microgen.go",
+				"transport/grpc/protobuf_type_converters.microgen.go",
+			))
+			if mode.pb != "" {
+				assertCompiles(t, "example.com/svc/transport/grpc")
+			}
+		})
+	}
+}
+
+func TestHTTPParams(t *testing.T) {
+	generated := generate(t, map[string]string{
+		"example.com/svc/service.go": `package svc
+
+import "context"
+
+// @microgen http
+type Service interface {
+	// @http-method PUT
+	// @http-path /comments/{id}
+	// @http-query notify page-size=pageSize
+	// @http-header X-Request-Id=requestID
+	Update(ctx context.Context, id int64, notify *bool, pageSize int, requestID string, text string) (err error)
+	// @http-method DELETE
+	// @http-path-param id
+	// @http-header X-Tags=tags
+	Delete(ctx context.Context, id string, tags []string) (err error)
+}
+`,
+	}, "example.com/svc/service.go", "Service", "")
+	assertGolden(t, "http_params", pick(t, generated,
+		"transport/http/converters.microgen.go",
+		"transport/http/server.microgen.go",
+	))
+	assertCompiles(t, "example.com/svc/transport/http")
+}
+
+func TestHTTPFormParams(t *testing.T) {
+	generated := generate(t, map[string]string{
+		"example.com/svc/service.go": `package svc
+
+import "context"
+
+// @microgen http
+type Service interface {
+	// @http-method POST
+	// @http-path-param id
+	UpdateComment(ctx context.Context, id string, text string) (err error)
+	// @http-method DELETE
+	// @http-path-param id
+	DeleteComment(ctx context.Context, id string, force *bool) (err error)
+}
+`,
+	}, "example.com/svc/service.go", "Service", "")
+	assertGolden(t, "http_form_params", pick(t, generated,
+		"transport/http/converters.microgen.go",
+	))
+	assertCompiles(t, "example.com/svc/transport/http")
+}
+
+func TestHTTPQueryParams(t *testing.T) {
+	generated := generate(t, map[string]string{
+		"example.com/svc/service.go": `package svc
+
+import (
+	"context"
+	"time"
+
+	"example.com/svc/entity"
+)
+
+type Status string
+
+type Archived bool
+
+// @microgen http
+type Service interface {
+	// @http-method GET
+	// @http-query status level since
+	// @http-header X-Archived=archived
+	List(ctx context.Context, status Status, statuses []Status, kind *Status, archived Archived, level entity.Level, levels []entity.Level, since time.Time, until *time.Time, limit *int, ids []int64, tags []string) (total int, err error)
+}
+`,
+		"example.com/svc/entity/entity.go": `package entity
+
+type Level int32
+`,
+	}, "example.com/svc/service.go", "Service", "")
+	assertGolden(t, "http_query_params", pick(t, generated,
+		"transport/http/converters.microgen.go",
+	))
+	assertCompiles(t, "example.com/svc/transport/http")
+}
+
+func TestHTTPUnsupportedParams(t *testing.T) {
+	gopath := setupGopath(t, map[string]string{
+		"example.com/svc/service.go": `package svc
+
+import "context"
+
+type Tags []string
+
+// @microgen http
+type Service interface {
+	// @http-method GET
+	// @http-query tags
+	List(ctx context.Context, tags Tags) (total int, err error)
+}
+`,
+	})
+	sourceFile := filepath.Join(gopath, "src", "example.com/svc/service.go")
+	iface, err := loadInterface(sourceFile, "Service")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = ValidateInterface(iface, sourceFile)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "List: argument tags of type Tags can't be placed to query of http request")
+	}
+}
@@ -0,0 +1,440 @@
+package openapi
+
+import (
+	"fmt"
+	gotoken "go/token"
+	"strings"
+	"unicode"
+
+	"github.com/dave/jennifer/jen"
+	mstrings "github.com/devimteam/microgen/generator/strings"
+)
+
+const (
+	packagePathContext = "context"
+	packagePathTime    = "time"
+)
+
+// GoFile renders go file with service interface and structures for schemas.
+// Each operation becomes method with @http-method and @http-path tags.
+// Path, query and header parameters become arguments of method, placed by @http-query and @http-header tags,
+// and so do properties of inline object of request body,
+// referenced or non-object body becomes single argument. Results are restored from successful response the same way.
+//
+//		// @microgen middleware, logging, http
+//		type PetStoreService interface {
+//			// @http-method GET
+//			// @http-path /pets/{petId}
+//			ShowPetById(ctx context.Context, petId string) (pet *Pet, err error)
+//		}
+//
+func GoFile(doc *Document, pkgName string, tags []string) (*jen.File, error) {
+	if len(doc.Operations) == 0 {
+		return nil, fmt.Errorf("document does not have any operations")
+	}
+	g := &goGenerator{doc: doc, declared: make(map[string]bool)}
+	for _, s := range doc.Schemas {
+		g.declared[goName(s.Name)] = true
+	}
+
+	f := jen.NewFile(pkgName)
+	f.HeaderComment("Code generated by microgen from OpenAPI document. DO NOT EDIT.")
+
+	var methods []jen.Code
+	names := make(map[string]bool)
+	for _, op := range doc.Operations {
+		name := methodName(op)
+		if names[name] {
+			return nil, fmt.Errorf("%s %s: method %s is declared twice", op.Method, op.Path, name)
+		}
+		names[name] = true
+		m, err := g.method(name, op)
+		if err != nil {
+			return nil, fmt.Errorf("%s %s: %v", op.Method, op.Path, err)
+		}
+		methods = append(methods, m)
+	}
+	for _, line := range docLines(doc.Description) {
+		f.Comment(line)
+	}
+	f.Comment("@microgen " + strings.Join(tags, ", "))
+	f.Type().Id(serviceName(doc.Title)).Interface(methods...)
+
+	for _, s := range doc.Schemas {
+		// References to aliases are resolved to original schema.
+		if s.Ref != "" {
+			continue
+		}
+		decl, err := g.declaration(goName(s.Name), s)
+		if err != nil {
+			return nil, fmt.Errorf("schema %s: %v", s.Name, err)
+		}
+		f.Line().Add(decl)
+	}
+	// Inline objects are declared after schemas, and they may declare other inline objects.
+	for i := 0; i < len(g.inline); i++ {
+		decl, err := g.declaration(g.inline[i].name, g.inline[i].schema)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", g.inline[i].name, err)
+		}
+		f.Line().Add(decl)
+	}
+	return f, nil
+}
+
+type inlineObject struct {
+	name   string
+	schema *Schema
+}
+
+type goGenerator struct {
+	doc      *Document
+	declared map[string]bool
+	inline   []inlineObject
+}
+
+// Returns interface name from title of document.
+//
+//		Swagger Petstore -> SwaggerPetstoreService
+//
+func serviceName(title string) string {
+	name := goName(title)
+	if !strings.HasSuffix(name, "Service") {
+		name += "Service"
+	}
+	return name
+}
+
+// Returns method name from operationId or from http method and path, when operationId is empty.
+//
+//		GET /pets/{petId} -> GetPetsPetId
+//
+func methodName(op *Operation) string {
+	if op.ID != "" {
+		return goName(op.ID)
+	}
+	return goName(strings.ToLower(op.Method) + " " + op.Path)
+}
+
+// Returns exported go identifier for name, which may contain any separators.
+//
+//		pet_id -> PetId
+//		X-Request-ID -> XRequestID
+//
+func goName(s string) string {
+	parts := strings.FieldsFunc(s, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for i := range parts {
+		parts[i] = mstrings.ToUpperFirst(parts[i])
+	}
+	name := strings.Join(parts, "")
+	if name != "" && unicode.IsDigit(rune(name[0])) {
+		name = "X" + name
+	}
+	return name
+}
+
+// Returns name of method argument or result.
+func paramName(s string) (string, error) {
+	name := mstrings.ToLowerFirst(goName(s))
+	if name == "" || gotoken.Lookup(name).IsKeyword() || name == "ctx" || name == "err" {
+		return "", fmt.Errorf("%s can not be used as name of argument", s)
+	}
+	return name, nil
+}
+
+func docLines(s string) []string {
+	if s = strings.TrimSpace(s); s == "" {
+		return nil
+	}
+	return strings.Split(s, "\n")
+}
+
+// Path variables in order of appearance.
+//
+//		/pets/{petId}/toys/{toyId} -> [petId toyId]
+//
+func pathVars(path string) (vars []string) {
+	for {
+		start := strings.IndexByte(path, '{')
+		end := strings.IndexByte(path, '}')
+		if start == -1 || end < start {
+			return
+		}
+		vars = append(vars, path[start+1:end])
+		path = path[end+1:]
+	}
+}
+
+type params struct {
+	list  []jen.Code
+	names map[string]bool
+}
+
+func (p *params) add(name string, t jen.Code) error {
+	if p.names[name] {
+		return fmt.Errorf("argument %s is declared twice", name)
+	}
+	p.names[name] = true
+	p.list = append(p.list, jen.Id(name).Add(t))
+	return nil
+}
+
+func (g *goGenerator) method(name string, op *Operation) (jen.Code, error) {
+	args := &params{names: map[string]bool{"ctx": true}}
+	args.list = append(args.list, jen.Id("ctx").Qual(packagePathContext, "Context"))
+	for _, v := range pathVars(op.Path) {
+		p := findParameter(op.Parameters, "path", v)
+		if p == nil {
+			return nil, fmt.Errorf("path variable {%s} is not described in parameters", v)
+		}
+		if err := g.addParameter(args, p); err != nil {
+			return nil, err
+		}
+	}
+	var query, header []string
+	for _, p := range op.Parameters {
+		if p.In != "query" && p.In != "header" {
+			continue
+		}
+		if err := g.addParameter(args, p); err != nil {
+			return nil, err
+		}
+		name, _ := paramName(p.Name)
+		switch {
+		case p.In == "header":
+			header = append(header, p.Name+"="+name)
+		case mstrings.ToSnakeCase(name) == p.Name:
+			query = append(query, name)
+		default:
+			query = append(query, p.Name+"="+name)
+		}
+	}
+	if err := g.addContent(args, name, op.Body, "body"); err != nil {
+		return nil, fmt.Errorf("request body: %v", err)
+	}
+	// Names of arguments and results should be different.
+	results := &params{names: args.names}
+	results.names["err"] = true
+	if err := g.addContent(results, name, op.Response, "result"); err != nil {
+		return nil, fmt.Errorf("response: %v", err)
+	}
+	results.list = append(results.list, jen.Id("err").Error())
+
+	s := &jen.Statement{}
+	for _, line := range append(docLines(op.Summary), docLines(op.Description)...) {
+		s.Comment(line).Line()
+	}
+	s.Comment("@http-method " + op.Method).Line()
+	s.Comment("@http-path " + op.Path).Line()
+	if len(query) > 0 {
+		s.Comment("@http-query " + strings.Join(query, ", ")).Line()
+	}
+	if len(header) > 0 {
+		s.Comment("@http-header " + strings.Join(header, ", ")).Line()
+	}
+	return s.Id(name).Params(args.list...).Params(results.list...), nil
+}
+
+func findParameter(params []*Parameter, in, name string) *Parameter {
+	for _, p := range params {
+		if p.In == in && p.Name == name {
+			return p
+		}
+	}
+	return nil
+}
+
+// Path, query and header parameters should be strings, numbers or booleans, because they are inserted to url and headers.
+// Query and header parameters may also be arrays of them, which are passed as repeated parameters.
+func (g *goGenerator) addParameter(args *params, p *Parameter) error {
+	name, err := paramName(p.Name)
+	if err != nil {
+		return fmt.Errorf("parameter %s: %v", p.Name, err)
+	}
+	s := g.resolve(p.Schema)
+	array := s.Type == "array" && s.Items != nil && p.In != "path"
+	if array {
+		s = g.resolve(s.Items)
+	}
+	switch s.Type {
+	case "string", "integer", "number", "boolean":
+	default:
+		return fmt.Errorf("parameter %s: type %s is not supported, only strings, numbers, booleans and arrays of them may be placed to url", p.Name, s.Type)
+	}
+	t, err := g.goType(s, "")
+	if err != nil {
+		return fmt.Errorf("parameter %s: %v", p.Name, err)
+	}
+	// Formats of strings, except date-time, are not parsed from url.
+	if s.Type == "string" && s.Format != "date-time" {
+		t = jen.String()
+	}
+	if array {
+		t = jen.Index().Add(t)
+	}
+	return args.add(name, t)
+}
+
+// Adds properties of inline object as params or single param for other schemas.
+func (g *goGenerator) addContent(list *params, method string, s *Schema, defaultName string) error {
+	if s == nil {
+		return nil
+	}
+	if s.IsObject() {
+		for _, prop := range s.Properties {
+			name, err := paramName(prop.Name)
+			if err != nil {
+				return err
+			}
+			t, err := g.goType(prop.Schema, method+goName(prop.Name))
+			if err != nil {
+				return fmt.Errorf("%s: %v", prop.Name, err)
+			}
+			if err := list.add(name, t); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	name := defaultName
+	if s.Ref != "" {
+		name = mstrings.ToLowerFirst(goName(s.Ref))
+	} else if s.Type == "array" {
+		name = "items"
+	}
+	t, err := g.goType(s, method+mstrings.ToUpperFirst(name))
+	if err != nil {
+		return err
+	}
+	// Method often returns the same entity, that it accepts.
+	if list.names[name] {
+		name += "Result"
+	}
+	return list.add(name, t)
+}
+
+// Returns schema, which is referenced through aliases, or schema itself.
+func (g *goGenerator) resolve(s *Schema) *Schema {
+	for i := 0; s.Ref != "" && i < len(g.doc.Schemas); i++ {
+		s = g.doc.Schema(s.Ref)
+	}
+	return s
+}
+
+// Renders go type of schema. Inline objects are declared as structures with provided name.
+//
+//		{type: array, items: {$ref: '#/components/schemas/Pet'}} -> []*Pet
+//
+func (g *goGenerator) goType(s *Schema, inlineName string) (*jen.Statement, error) {
+	if s.Ref != "" {
+		target := g.resolve(s)
+		if target.Ref != "" {
+			return nil, fmt.Errorf("schema %s references itself", s.Ref)
+		}
+		if target.IsObject() && len(target.Properties) > 0 {
+			return jen.Op("*").Id(goName(target.Name)), nil
+		}
+		return jen.Id(goName(target.Name)), nil
+	}
+	switch {
+	case s.AdditionalProperties != nil:
+		t, err := g.goType(s.AdditionalProperties, inlineName+"Value")
+		if err != nil {
+			return nil, err
+		}
+		return jen.Map(jen.String()).Add(t), nil
+	case s.IsObject() && len(s.Properties) == 0:
+		return jen.Map(jen.String()).Interface(), nil
+	case s.IsObject():
+		if g.declared[inlineName] {
+			return nil, fmt.Errorf("name %s of inline object is already used", inlineName)
+		}
+		g.declared[inlineName] = true
+		g.inline = append(g.inline, inlineObject{name: inlineName, schema: s})
+		return jen.Op("*").Id(inlineName), nil
+	}
+	switch s.Type {
+	case "array":
+		t, err := g.goType(s.Items, inlineName+"Item")
+		if err != nil {
+			return nil, err
+		}
+		return jen.Index().Add(t), nil
+	case "string":
+		switch s.Format {
+		case "date-time":
+			return jen.Qual(packagePathTime, "Time"), nil
+		case "byte", "binary":
+			return jen.Index().Byte(), nil
+		}
+		return jen.String(), nil
+	case "integer":
+		switch s.Format {
+		case "int32":
+			return jen.Int32(), nil
+		case "int64":
+			return jen.Int64(), nil
+		}
+		return jen.Int(), nil
+	case "number":
+		if s.Format == "float" {
+			return jen.Float32(), nil
+		}
+		return jen.Float64(), nil
+	case "boolean":
+		return jen.Bool(), nil
+	case "":
+		return jen.Interface(), nil
+	}
+	return nil, fmt.Errorf("unsupported type %s", s.Type)
+}
+
+// Renders structure for object schema, named string type with constants for string enum and named type for others.
+//
+//		// Pet docs.
+//		type Pet struct {
+//			Id   int64  `json:"id"`
+//			Name string `json:"name"`
+//			Tag  string `json:"tag,omitempty"`
+//		}
+//
+func (g *goGenerator) declaration(name string, s *Schema) (*jen.Statement, error) {
+	decl := &jen.Statement{}
+	for _, line := range docLines(s.Description) {
+		decl.Comment(line).Line()
+	}
+	if !s.IsObject() || len(s.Properties) == 0 {
+		t, err := g.goType(s, name+"Value")
+		if err != nil {
+			return nil, err
+		}
+		decl.Type().Id(name).Add(t)
+		if s.Type == "string" && len(s.Enum) > 0 {
+			decl.Line().Line().Const().DefsFunc(func(group *jen.Group) {
+				for _, v := range s.Enum {
+					group.Id(name + goName(v)).Id(name).Op("=").Lit(v)
+				}
+			})
+		}
+		return decl, nil
+	}
+	var fields []jen.Code
+	for _, prop := range s.Properties {
+		t, err := g.goType(prop.Schema, name+goName(prop.Name))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", prop.Name, err)
+		}
+		tag := prop.Name
+		if !s.IsRequired(prop.Name) {
+			tag += ",omitempty"
+		}
+		field := &jen.Statement{}
+		for _, line := range docLines(prop.Schema.Description) {
+			field.Comment(line).Line()
+		}
+		fields = append(fields, field.Id(goName(prop.Name)).Add(t).Tag(map[string]string{"json": tag}))
+	}
+	return decl.Type().Id(name).Struct(fields...), nil
+}
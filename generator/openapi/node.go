@@ -0,0 +1,479 @@
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+type nodeKind int
+
+const (
+	scalarNode nodeKind = iota
+	mappingNode
+	sequenceNode
+)
+
+// Node of decoded JSON or YAML document.
+// Mapping keeps order of keys, because it defines order of methods and structure fields.
+type node struct {
+	kind  nodeKind
+	value string
+	keys  []string
+	// Values of mapping keys or items of sequence.
+	values []*node
+}
+
+// Returns value of mapping key or nil.
+func (n *node) get(key string) *node {
+	if n == nil || n.kind != mappingNode {
+		return nil
+	}
+	for i := range n.keys {
+		if n.keys[i] == key {
+			return n.values[i]
+		}
+	}
+	return nil
+}
+
+// Returns scalar value of mapping key or empty string.
+func (n *node) str(key string) string {
+	if v := n.get(key); v != nil && v.kind == scalarNode {
+		return v.value
+	}
+	return ""
+}
+
+// Returns items of sequence or nil.
+func (n *node) items() []*node {
+	if n == nil || n.kind != sequenceNode {
+		return nil
+	}
+	return n.values
+}
+
+func (n *node) bool(key string) bool {
+	return n.str(key) == "true"
+}
+
+// Decodes JSON document or YAML document, which uses subset of YAML, that is enough for API specifications:
+// block mappings and sequences, plain and quoted scalars, literal and folded block scalars and one-line flow collections.
+// Anchors, aliases, tags and multi-line flow collections are not supported.
+func decode(src []byte) (*node, error) {
+	if trimmed := bytes.TrimSpace(src); len(trimmed) > 0 && trimmed[0] == '{' {
+		return decodeJSON(src)
+	}
+	return decodeYAML(string(src))
+}
+
+func decodeJSON(src []byte) (*node, error) {
+	dec := json.NewDecoder(bytes.NewReader(src))
+	dec.UseNumber()
+	n, err := decodeJSONValue(dec)
+	if err != nil {
+		return nil, fmt.Errorf("json: %v", err)
+	}
+	return n, nil
+}
+
+func decodeJSONValue(dec *json.Decoder) (*node, error) {
+	tok, err := dec.Token()
+	if err == io.EOF {
+		return nil, io.ErrUnexpectedEOF
+	}
+	if err != nil {
+		return nil, err
+	}
+	switch t := tok.(type) {
+	case json.Delim:
+		if t == '{' {
+			n := &node{kind: mappingNode}
+			for dec.More() {
+				key, err := dec.Token()
+				if err != nil {
+					return nil, err
+				}
+				value, err := decodeJSONValue(dec)
+				if err != nil {
+					return nil, err
+				}
+				n.keys = append(n.keys, key.(string))
+				n.values = append(n.values, value)
+			}
+			_, err = dec.Token()
+			return n, err
+		}
+		n := &node{kind: sequenceNode}
+		for dec.More() {
+			value, err := decodeJSONValue(dec)
+			if err != nil {
+				return nil, err
+			}
+			n.values = append(n.values, value)
+		}
+		_, err = dec.Token()
+		return n, err
+	case nil:
+		return &node{kind: scalarNode, value: "null"}, nil
+	default:
+		return &node{kind: scalarNode, value: fmt.Sprint(t)}, nil
+	}
+}
+
+type yamlLine struct {
+	number int
+	indent int
+	// Text without indent and comment.
+	text string
+	raw  string
+}
+
+type yamlParser struct {
+	lines []*yamlLine
+	pos   int
+}
+
+func decodeYAML(src string) (*node, error) {
+	p := &yamlParser{}
+	for i, raw := range strings.Split(strings.Replace(src, "\r\n", "\n", -1), "\n") {
+		text := strings.TrimLeft(raw, " ")
+		if strings.HasPrefix(text, "\t") {
+			return nil, fmt.Errorf("yaml: line %d: tabs are not allowed as indentation", i+1)
+		}
+		if strings.HasPrefix(text, "%") || text == "---" {
+			continue
+		}
+		if text == "..." {
+			break
+		}
+		p.lines = append(p.lines, &yamlLine{
+			number: i + 1,
+			indent: len(raw) - len(text),
+			text:   strings.TrimSpace(stripComment(text)),
+			raw:    raw,
+		})
+	}
+	line := p.next()
+	if line == nil {
+		return nil, fmt.Errorf("yaml: document is empty")
+	}
+	n, err := p.parseBlock(line.indent)
+	if err != nil {
+		return nil, fmt.Errorf("yaml: %v", err)
+	}
+	if line := p.next(); line != nil {
+		return nil, fmt.Errorf("yaml: line %d: unexpected indentation", line.number)
+	}
+	return n, nil
+}
+
+// Returns next not empty line without moving forward.
+func (p *yamlParser) next() *yamlLine {
+	for ; p.pos < len(p.lines); p.pos++ {
+		if p.lines[p.pos].text != "" {
+			return p.lines[p.pos]
+		}
+	}
+	return nil
+}
+
+func (p *yamlParser) parseBlock(indent int) (*node, error) {
+	line := p.next()
+	if isSequenceItem(line.text) {
+		return p.parseSequence(indent)
+	}
+	if _, _, ok := splitMappingKey(line.text); ok {
+		return p.parseMapping(indent)
+	}
+	p.pos++
+	return parseFlowScalar(line)
+}
+
+func (p *yamlParser) parseSequence(indent int) (*node, error) {
+	n := &node{kind: sequenceNode}
+	for line := p.next(); line != nil && line.indent == indent && isSequenceItem(line.text); line = p.next() {
+		content := strings.TrimLeft(line.text[1:], " ")
+		if content == "" {
+			p.pos++
+			child := p.next()
+			if child == nil || child.indent <= indent {
+				n.values = append(n.values, &node{kind: scalarNode, value: "null"})
+				continue
+			}
+			value, err := p.parseBlock(child.indent)
+			if err != nil {
+				return nil, err
+			}
+			n.values = append(n.values, value)
+			continue
+		}
+		// Content of item is parsed as block, which starts after dash.
+		line.indent += len(line.text) - len(content)
+		line.text = content
+		value, err := p.parseBlock(line.indent)
+		if err != nil {
+			return nil, err
+		}
+		n.values = append(n.values, value)
+	}
+	return n, nil
+}
+
+func (p *yamlParser) parseMapping(indent int) (*node, error) {
+	n := &node{kind: mappingNode}
+	for line := p.next(); line != nil && line.indent == indent && !isSequenceItem(line.text); line = p.next() {
+		key, value, ok := splitMappingKey(line.text)
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected mapping key", line.number)
+		}
+		for i := range n.keys {
+			if n.keys[i] == key {
+				return nil, fmt.Errorf("line %d: duplicated key %s", line.number, key)
+			}
+		}
+		p.pos++
+		var v *node
+		var err error
+		switch {
+		case value == "":
+			child := p.next()
+			switch {
+			case child != nil && child.indent > indent:
+				v, err = p.parseBlock(child.indent)
+			// Sequence may have the same indent as its key.
+			case child != nil && child.indent == indent && isSequenceItem(child.text):
+				v, err = p.parseSequence(indent)
+			default:
+				v = &node{kind: scalarNode, value: "null"}
+			}
+		case value[0] == '|' || value[0] == '>':
+			v = p.parseBlockScalar(indent, value[0] == '>')
+		case value[0] == '"' || value[0] == '\'' || value[0] == '[' || value[0] == '{':
+			v, err = parseFlowScalar(&yamlLine{number: line.number, text: value})
+		default:
+			v = &node{kind: scalarNode, value: p.parsePlainScalar(indent, value)}
+		}
+		if err != nil {
+			return nil, err
+		}
+		n.keys = append(n.keys, key)
+		n.values = append(n.values, v)
+	}
+	return n, nil
+}
+
+// Joins plain scalar with its continuation lines, which are indented deeper than key.
+func (p *yamlParser) parsePlainScalar(indent int, value string) string {
+	for line := p.next(); line != nil && line.indent > indent; line = p.next() {
+		value += " " + line.text
+		p.pos++
+	}
+	return value
+}
+
+// Collects lines of literal (|) or folded (>) block scalar, which are indented deeper than key.
+func (p *yamlParser) parseBlockScalar(indent int, folded bool) *node {
+	var lines []string
+	blockIndent := -1
+	for ; p.pos < len(p.lines); p.pos++ {
+		line := p.lines[p.pos]
+		if strings.TrimSpace(line.raw) == "" {
+			lines = append(lines, "")
+			continue
+		}
+		if line.indent <= indent {
+			break
+		}
+		if blockIndent == -1 {
+			blockIndent = line.indent
+		}
+		lines = append(lines, line.raw[blockIndent:])
+	}
+	for len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	sep := "\n"
+	if folded {
+		sep = " "
+	}
+	return &node{kind: scalarNode, value: strings.Join(lines, sep)}
+}
+
+func isSequenceItem(text string) bool {
+	return text == "-" || strings.HasPrefix(text, "- ")
+}
+
+// Splits `key: value` line. Colon should be followed by space or end of line and be outside of quotes and brackets.
+func splitMappingKey(text string) (key, value string, ok bool) {
+	var quote byte
+	depth := 0
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case (c == '"' || c == '\'') && i == 0:
+			quote = c
+		case c == '[' || c == '{':
+			depth++
+		case c == ']' || c == '}':
+			depth--
+		case c == ':' && depth == 0 && (i+1 == len(text) || text[i+1] == ' '):
+			key, err := unquote(strings.TrimSpace(text[:i]))
+			if err != nil {
+				return "", "", false
+			}
+			return key, strings.TrimSpace(text[i+1:]), true
+		}
+	}
+	return "", "", false
+}
+
+// Removes comment, which starts with # after space, outside of quotes.
+func stripComment(text string) string {
+	var quote byte
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			if i == 0 || text[i-1] == ' ' || text[i-1] == '[' || text[i-1] == '{' || text[i-1] == ',' {
+				quote = c
+			}
+		case c == '#' && (i == 0 || text[i-1] == ' '):
+			return text[:i]
+		}
+	}
+	return text
+}
+
+func parseFlowScalar(line *yamlLine) (*node, error) {
+	f := &flowParser{text: line.text}
+	n, err := f.parseValue()
+	if err == nil && f.skipSpaces() < len(f.text) {
+		err = fmt.Errorf("unexpected %q", f.text[f.pos:])
+	}
+	if err != nil {
+		return nil, fmt.Errorf("line %d: %v", line.number, err)
+	}
+	return n, nil
+}
+
+// Parser of one-line flow values: scalars, [a, b] and {a: b}.
+type flowParser struct {
+	text string
+	pos  int
+}
+
+func (f *flowParser) skipSpaces() int {
+	for f.pos < len(f.text) && f.text[f.pos] == ' ' {
+		f.pos++
+	}
+	return f.pos
+}
+
+func (f *flowParser) parseValue() (*node, error) {
+	if f.skipSpaces() == len(f.text) {
+		return &node{kind: scalarNode, value: "null"}, nil
+	}
+	switch c := f.text[f.pos]; c {
+	case '[', '{':
+		f.pos++
+		n := &node{kind: sequenceNode}
+		end := byte(']')
+		if c == '{' {
+			n.kind = mappingNode
+			end = '}'
+		}
+		for {
+			if f.skipSpaces() == len(f.text) {
+				return nil, fmt.Errorf("multi-line flow collections are not supported")
+			}
+			if f.text[f.pos] == end {
+				f.pos++
+				return n, nil
+			}
+			if n.kind == mappingNode {
+				key, err := f.parseScalar(":")
+				if err != nil {
+					return nil, err
+				}
+				if f.skipSpaces() == len(f.text) || f.text[f.pos] != ':' {
+					return nil, fmt.Errorf("expected ':' after key %s", key)
+				}
+				f.pos++
+				n.keys = append(n.keys, key)
+			}
+			value, err := f.parseValue()
+			if err != nil {
+				return nil, err
+			}
+			n.values = append(n.values, value)
+			if f.skipSpaces() < len(f.text) && f.text[f.pos] == ',' {
+				f.pos++
+			}
+		}
+	case '&', '*', '!':
+		return nil, fmt.Errorf("anchors, aliases and tags are not supported")
+	}
+	value, err := f.parseScalar(",]}")
+	if err != nil {
+		return nil, err
+	}
+	return &node{kind: scalarNode, value: value}, nil
+}
+
+// Parses quoted scalar or plain scalar, which ends with one of stop characters.
+func (f *flowParser) parseScalar(stop string) (string, error) {
+	f.skipSpaces()
+	start := f.pos
+	if f.pos < len(f.text) && (f.text[f.pos] == '"' || f.text[f.pos] == '\'') {
+		quote := f.text[f.pos]
+		for f.pos++; f.pos < len(f.text); f.pos++ {
+			if f.text[f.pos] == '\\' && quote == '"' {
+				f.pos++
+				continue
+			}
+			if f.text[f.pos] == quote {
+				if quote == '\'' && f.pos+1 < len(f.text) && f.text[f.pos+1] == '\'' {
+					f.pos++
+					continue
+				}
+				f.pos++
+				return unquote(f.text[start:f.pos])
+			}
+		}
+		return "", fmt.Errorf("unterminated string %s", f.text[start:])
+	}
+	for f.pos < len(f.text) && (strings.IndexByte(stop, f.text[f.pos]) == -1 ||
+		// Plain scalars of block context, like urls, may contain colons.
+		stop == ",]}" && start == 0) {
+		f.pos++
+	}
+	return strings.TrimSpace(f.text[start:f.pos]), nil
+}
+
+func unquote(s string) (string, error) {
+	if len(s) < 2 {
+		return s, nil
+	}
+	switch s[0] {
+	case '"':
+		return strconv.Unquote(s)
+	case '\'':
+		if s[len(s)-1] != '\'' {
+			return "", fmt.Errorf("unterminated string %s", s)
+		}
+		return strings.Replace(s[1:len(s)-1], "''", "'", -1), nil
+	}
+	return s, nil
+}
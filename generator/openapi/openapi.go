@@ -0,0 +1,397 @@
+// Package openapi contains minimal reader of OpenAPI 3 documents, which is enough to restore go service interface from it.
+package openapi
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+)
+
+const schemasRef = "#/components/schemas/"
+
+// Document is parsed OpenAPI document.
+type Document struct {
+	Title       string
+	Description string
+	Operations  []*Operation
+	// Schemas from components section.
+	Schemas []*Schema
+}
+
+// Operation is a pair of http method and path.
+type Operation struct {
+	ID          string
+	Method      string
+	Path        string
+	Summary     string
+	Description string
+	Parameters  []*Parameter
+	// Schema of application/json request body, nil if operation has no body.
+	Body *Schema
+	// Schema of application/json successful response, nil if response has no content.
+	Response *Schema
+}
+
+type Parameter struct {
+	Name        string
+	In          string
+	Description string
+	Required    bool
+	Schema      *Schema
+}
+
+type Schema struct {
+	// Name of schema in components section, empty for inline schemas.
+	Name string
+	// Name of referenced schema from components section.
+	Ref         string
+	Type        string
+	Format      string
+	Description string
+	Items       *Schema
+	Properties  []*Property
+	// Schema of values of object with arbitrary keys.
+	AdditionalProperties *Schema
+	Enum                 []string
+	Required             []string
+}
+
+type Property struct {
+	Name   string
+	Schema *Schema
+}
+
+// IsObject returns true when schema describes structure with fixed properties.
+func (s *Schema) IsObject() bool {
+	return s.Ref == "" && (len(s.Properties) > 0 || s.Type == "object" && s.AdditionalProperties == nil)
+}
+
+func (s *Schema) IsRequired(property string) bool {
+	for _, r := range s.Required {
+		if r == property {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns schema from components section by its name, or nil if there is no such schema.
+func (d *Document) Schema(name string) *Schema {
+	for _, s := range d.Schemas {
+		if s.Name == name {
+			return s
+		}
+	}
+	return nil
+}
+
+// ParseFile parses OpenAPI document from provided path.
+func ParseFile(filename string) (*Document, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	doc, err := Parse(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", filename, err)
+	}
+	return doc, nil
+}
+
+// Parse parses OpenAPI 3 document in JSON or YAML format.
+// Only paths with operations and schemas of components are read, external references are not supported.
+func Parse(r io.Reader) (*Document, error) {
+	src, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	root, err := decode(src)
+	if err != nil {
+		return nil, err
+	}
+	if root.kind != mappingNode {
+		return nil, fmt.Errorf("document should be an object")
+	}
+	if v := root.str("openapi"); !strings.HasPrefix(v, "3.") {
+		return nil, fmt.Errorf("only OpenAPI 3 is supported, got version %q", v)
+	}
+	p := &loader{root: root}
+	return p.load()
+}
+
+type loader struct {
+	root *node
+}
+
+var operationMethods = map[string]string{
+	"get":    "GET",
+	"put":    "PUT",
+	"post":   "POST",
+	"delete": "DELETE",
+	"patch":  "PATCH",
+}
+
+func (l *loader) load() (*Document, error) {
+	info := l.root.get("info")
+	doc := &Document{
+		Title:       info.str("title"),
+		Description: info.str("description"),
+	}
+	schemas := l.root.get("components").get("schemas")
+	if schemas != nil {
+		for i, name := range schemas.keys {
+			s, err := l.schema(schemas.values[i])
+			if err != nil {
+				return nil, fmt.Errorf("schema %s: %v", name, err)
+			}
+			if s.Ref != "" {
+				// Alias of other schema is copied to keep generated types simple.
+				s = &Schema{Ref: s.Ref}
+			}
+			s.Name = name
+			doc.Schemas = append(doc.Schemas, s)
+		}
+	}
+	paths := l.root.get("paths")
+	for i := 0; paths != nil && i < len(paths.keys); i++ {
+		path := paths.keys[i]
+		item, err := l.resolve(paths.values[i])
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", path, err)
+		}
+		common, err := l.parameters(item.get("parameters"))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", path, err)
+		}
+		for j, key := range item.keys {
+			method, ok := operationMethods[key]
+			if !ok {
+				if key == "head" || key == "options" || key == "trace" {
+					return nil, fmt.Errorf("%s %s: method is not supported", strings.ToUpper(key), path)
+				}
+				continue
+			}
+			op, err := l.operation(method, path, item.values[j], common)
+			if err != nil {
+				return nil, fmt.Errorf("%s %s: %v", method, path, err)
+			}
+			doc.Operations = append(doc.Operations, op)
+		}
+	}
+	for _, s := range doc.Schemas {
+		if err := checkRefs(doc, s); err != nil {
+			return nil, fmt.Errorf("schema %s: %v", s.Name, err)
+		}
+	}
+	for _, op := range doc.Operations {
+		if err := checkRefs(doc, op.Body); err != nil {
+			return nil, fmt.Errorf("%s %s: request body: %v", op.Method, op.Path, err)
+		}
+		if err := checkRefs(doc, op.Response); err != nil {
+			return nil, fmt.Errorf("%s %s: response: %v", op.Method, op.Path, err)
+		}
+		for _, param := range op.Parameters {
+			if err := checkRefs(doc, param.Schema); err != nil {
+				return nil, fmt.Errorf("%s %s: parameter %s: %v", op.Method, op.Path, param.Name, err)
+			}
+		}
+	}
+	return doc, nil
+}
+
+func (l *loader) operation(method, path string, n *node, common []*Parameter) (*Operation, error) {
+	op := &Operation{
+		ID:          n.str("operationId"),
+		Method:      method,
+		Path:        path,
+		Summary:     n.str("summary"),
+		Description: n.str("description"),
+	}
+	params, err := l.parameters(n.get("parameters"))
+	if err != nil {
+		return nil, err
+	}
+	// Operation parameters override parameters of path.
+	for _, c := range common {
+		overridden := false
+		for _, p := range params {
+			overridden = overridden || p.Name == c.Name && p.In == c.In
+		}
+		if !overridden {
+			op.Parameters = append(op.Parameters, c)
+		}
+	}
+	op.Parameters = append(op.Parameters, params...)
+	if body := n.get("requestBody"); body != nil {
+		body, err := l.resolve(body)
+		if err != nil {
+			return nil, fmt.Errorf("request body: %v", err)
+		}
+		op.Body, err = l.content(body.get("content"))
+		if err != nil {
+			return nil, fmt.Errorf("request body: %v", err)
+		}
+	}
+	if responses := n.get("responses"); responses != nil {
+		for _, code := range []string{"200", "201", "202", "2XX"} {
+			resp := responses.get(code)
+			if resp == nil {
+				continue
+			}
+			resp, err := l.resolve(resp)
+			if err != nil {
+				return nil, fmt.Errorf("response %s: %v", code, err)
+			}
+			op.Response, err = l.content(resp.get("content"))
+			if err != nil {
+				return nil, fmt.Errorf("response %s: %v", code, err)
+			}
+			break
+		}
+	}
+	return op, nil
+}
+
+// Returns schema of json content.
+func (l *loader) content(n *node) (*Schema, error) {
+	if n == nil || len(n.keys) == 0 {
+		return nil, nil
+	}
+	for i, mime := range n.keys {
+		if mime == "application/json" || strings.HasSuffix(mime, "+json") {
+			schema := n.values[i].get("schema")
+			if schema == nil {
+				return nil, fmt.Errorf("%s: schema is required", mime)
+			}
+			return l.schema(schema)
+		}
+	}
+	return nil, fmt.Errorf("only application/json content is supported, got %s", strings.Join(n.keys, ", "))
+}
+
+func (l *loader) parameters(n *node) ([]*Parameter, error) {
+	if n == nil {
+		return nil, nil
+	}
+	var params []*Parameter
+	for _, v := range n.items() {
+		v, err := l.resolve(v)
+		if err != nil {
+			return nil, err
+		}
+		p := &Parameter{
+			Name:        v.str("name"),
+			In:          v.str("in"),
+			Description: v.str("description"),
+			Required:    v.bool("required"),
+		}
+		if p.Name == "" {
+			return nil, fmt.Errorf("parameter without name")
+		}
+		schema := v.get("schema")
+		if schema == nil {
+			return nil, fmt.Errorf("parameter %s: schema is required", p.Name)
+		}
+		if p.Schema, err = l.schema(schema); err != nil {
+			return nil, fmt.Errorf("parameter %s: %v", p.Name, err)
+		}
+		params = append(params, p)
+	}
+	return params, nil
+}
+
+func (l *loader) schema(n *node) (*Schema, error) {
+	if ref := n.str("$ref"); ref != "" {
+		if !strings.HasPrefix(ref, schemasRef) {
+			return nil, fmt.Errorf("unsupported reference %s", ref)
+		}
+		return &Schema{Ref: strings.TrimPrefix(ref, schemasRef)}, nil
+	}
+	for _, key := range []string{"allOf", "oneOf", "anyOf", "not"} {
+		if n.get(key) != nil {
+			return nil, fmt.Errorf("%s is not supported", key)
+		}
+	}
+	s := &Schema{
+		Type:        n.str("type"),
+		Format:      n.str("format"),
+		Description: n.str("description"),
+	}
+	if items := n.get("items"); items != nil {
+		var err error
+		if s.Items, err = l.schema(items); err != nil {
+			return nil, fmt.Errorf("items: %v", err)
+		}
+	} else if s.Type == "array" {
+		return nil, fmt.Errorf("items of array are required")
+	}
+	if props := n.get("properties"); props != nil {
+		for i, name := range props.keys {
+			p, err := l.schema(props.values[i])
+			if err != nil {
+				return nil, fmt.Errorf("%s: %v", name, err)
+			}
+			s.Properties = append(s.Properties, &Property{Name: name, Schema: p})
+		}
+	}
+	if add := n.get("additionalProperties"); add != nil && add.kind == mappingNode {
+		var err error
+		if s.AdditionalProperties, err = l.schema(add); err != nil {
+			return nil, fmt.Errorf("additionalProperties: %v", err)
+		}
+	} else if add != nil && add.value == "true" {
+		s.AdditionalProperties = &Schema{}
+	}
+	if s.AdditionalProperties != nil && len(s.Properties) > 0 {
+		return nil, fmt.Errorf("properties together with additionalProperties are not supported")
+	}
+	for _, v := range n.get("enum").items() {
+		s.Enum = append(s.Enum, v.value)
+	}
+	for _, v := range n.get("required").items() {
+		s.Required = append(s.Required, v.value)
+	}
+	return s, nil
+}
+
+// Returns node, which is referenced by $ref, or node itself.
+func (l *loader) resolve(n *node) (*node, error) {
+	for i := 0; n != nil && n.str("$ref") != ""; i++ {
+		ref := n.str("$ref")
+		if !strings.HasPrefix(ref, "#/") || i > 10 {
+			return nil, fmt.Errorf("unsupported reference %s", ref)
+		}
+		n = l.root
+		for _, key := range strings.Split(ref[2:], "/") {
+			n = n.get(strings.Replace(strings.Replace(key, "~1", "/", -1), "~0", "~", -1))
+		}
+		if n == nil {
+			return nil, fmt.Errorf("unknown reference %s", ref)
+		}
+	}
+	return n, nil
+}
+
+func checkRefs(doc *Document, s *Schema) error {
+	switch {
+	case s == nil:
+		return nil
+	case s.Ref != "":
+		if doc.Schema(s.Ref) == nil {
+			return fmt.Errorf("unknown schema %s", s.Ref)
+		}
+		return nil
+	}
+	for _, p := range s.Properties {
+		if err := checkRefs(doc, p.Schema); err != nil {
+			return fmt.Errorf("%s: %v", p.Name, err)
+		}
+	}
+	if err := checkRefs(doc, s.Items); err != nil {
+		return err
+	}
+	return checkRefs(doc, s.AdditionalProperties)
+}
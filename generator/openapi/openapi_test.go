@@ -0,0 +1,227 @@
+package openapi
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+const testYAML = `
+openapi: "3.0.0"
+info:
+  title: Pet Store
+  description: |
+    Pets API.
+    # not a comment
+paths:
+  /pets:
+    get:
+      summary: List pets
+        of the store # comment
+      operationId: listPets
+      parameters:
+        - name: limit
+          in: query
+          schema: {type: integer, format: int32}
+      responses:
+        '200':
+          content:
+            application/json:
+              schema:
+                type: array
+                items:
+                  $ref: '#/components/schemas/Pet'
+    post:
+      operationId: create-pet
+      requestBody:
+        content:
+          application/json:
+            schema:
+              type: object
+              required: [name]
+              properties:
+                name:
+                  type: string
+                born:
+                  type: string
+                  format: date-time
+      responses:
+        "201":
+          $ref: '#/components/responses/PetResponse'
+  /pets/{pet_id}:
+    parameters:
+    - $ref: '#/components/parameters/PetId'
+    delete:
+      responses:
+        '204':
+          description: Deleted
+components:
+  parameters:
+    PetId:
+      name: pet_id
+      in: path
+      required: true
+      schema:
+        type: integer
+        format: int64
+  responses:
+    PetResponse:
+      content:
+        application/json:
+          schema:
+            $ref: "#/components/schemas/Pet"
+  schemas:
+    Pet:
+      description: Pet of the store.
+      required:
+        - id
+      properties:
+        id: {type: integer, format: int64}
+        kind:
+          $ref: '#/components/schemas/Kind'
+        owner:
+          properties:
+            name: {type: string}
+    Kind:
+      type: string
+      enum: [cat, dog]
+`
+
+func TestParseYAML(t *testing.T) {
+	doc, err := Parse(strings.NewReader(testYAML))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if doc.Title != "Pet Store" || doc.Description != "Pets API.\n# not a comment" {
+		t.Errorf("info: %q %q", doc.Title, doc.Description)
+	}
+	if len(doc.Operations) != 3 {
+		t.Fatal("operations:", doc.Operations)
+	}
+	list := doc.Operations[0]
+	if list.ID != "listPets" || list.Method != "GET" || list.Summary != "List pets of the store" {
+		t.Error("operation:", *list)
+	}
+	if len(list.Parameters) != 1 || list.Parameters[0].Schema.Format != "int32" {
+		t.Error("parameters:", list.Parameters)
+	}
+	if list.Response == nil || list.Response.Items == nil || list.Response.Items.Ref != "Pet" {
+		t.Error("response:", list.Response)
+	}
+	create := doc.Operations[1]
+	if create.Body == nil || len(create.Body.Properties) != 2 || !create.Body.IsRequired("name") {
+		t.Error("request body:", create.Body)
+	}
+	if create.Response == nil || create.Response.Ref != "Pet" {
+		t.Error("referenced response:", create.Response)
+	}
+	del := doc.Operations[2]
+	if len(del.Parameters) != 1 || del.Parameters[0].In != "path" || del.Response != nil {
+		t.Error("path parameters:", *del)
+	}
+	if pet := doc.Schema("Pet"); pet == nil || len(pet.Properties) != 3 || pet.Properties[1].Schema.Ref != "Kind" {
+		t.Error("schema:", pet)
+	}
+	if kind := doc.Schema("Kind"); kind == nil || len(kind.Enum) != 2 {
+		t.Error("enum:", kind)
+	}
+}
+
+func TestParseJSON(t *testing.T) {
+	doc, err := Parse(strings.NewReader(`{"openapi": "3.0.1", "info": {"title": "Notes"}, "paths": {
+		"/notes/{id}": {"get": {"operationId": "getNote", "parameters": [{"name": "id", "in": "path", "schema": {"type": "string"}}]}}
+	}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(doc.Operations) != 1 || doc.Operations[0].Parameters[0].Name != "id" {
+		t.Error("operations:", doc.Operations)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, src := range []string{
+		`swagger: "2.0"`,
+		"openapi: 3.0.0\npaths:\n  /a:\n    get:\n      requestBody:\n        content:\n          text/plain: {schema: {type: string}}",
+		"openapi: 3.0.0\ncomponents:\n  schemas:\n    A:\n      oneOf: []",
+		"openapi: 3.0.0\ncomponents:\n  schemas:\n    A:\n      $ref: '#/components/schemas/B'",
+		"openapi: 3.0.0\ninfo:\n  title: [a, b\n",
+	} {
+		if _, err := Parse(strings.NewReader(src)); err == nil {
+			t.Error("expected error for:", src)
+		}
+	}
+}
+
+func TestGoFile(t *testing.T) {
+	doc, err := Parse(strings.NewReader(testYAML))
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := GoFile(doc, "api", []string{"http"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := f.Render(&buf); err != nil {
+		t.Fatal(err)
+	}
+	src := buf.String()
+	for _, s := range []string{
+		"// @microgen http\ntype PetStoreService interface {",
+		"// @http-method GET\n\t// @http-path /pets\n\t// @http-query limit\n\tListPets(ctx context.Context, limit int32) (items []*Pet, err error)",
+		"CreatePet(ctx context.Context, name string, born time.Time) (pet *Pet, err error)",
+		"// @http-path /pets/{pet_id}\n\tDeletePetsPetId(ctx context.Context, petId int64) (err error)",
+		"Kind  Kind      `json:\"kind,omitempty\"`",
+		"Owner *PetOwner `json:\"owner,omitempty\"`",
+		"KindCat Kind = \"cat\"",
+		"type PetOwner struct {",
+	} {
+		if !strings.Contains(src, s) {
+			t.Errorf("%q not found in:\n%s", s, src)
+		}
+	}
+}
+
+func TestGoFileHttpParams(t *testing.T) {
+	doc, err := Parse(strings.NewReader(`{"openapi": "3.0.1", "info": {"title": "Notes"}, "paths": {
+		"/notes/{id}": {"put": {"operationId": "updateNote", "parameters": [
+			{"name": "id", "in": "path", "schema": {"type": "string"}},
+			{"name": "dry_run", "in": "query", "schema": {"type": "integer"}},
+			{"name": "pageSize", "in": "query", "schema": {"type": "integer"}},
+			{"name": "X-Request-ID", "in": "header", "schema": {"type": "string"}},
+			{"name": "since", "in": "query", "schema": {"type": "string", "format": "date-time"}},
+			{"name": "tags", "in": "query", "schema": {"type": "array", "items": {"type": "string"}}},
+			{"name": "force", "in": "query", "schema": {"type": "boolean"}}
+		], "requestBody": {"content": {"application/json": {"schema": {"type": "object", "properties": {"text": {"type": "string"}}}}}}}}
+	}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := GoFile(doc, "api", []string{"http"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := f.Render(&buf); err != nil {
+		t.Fatal(err)
+	}
+	want := "// @http-query dryRun, pageSize=pageSize, since, tags, force\n\t// @http-header X-Request-ID=xRequestID\n\t" +
+		"UpdateNote(ctx context.Context, id string, dryRun int, pageSize int, xRequestID string, since time.Time, tags []string, force bool, text string) (err error)"
+	if !strings.Contains(buf.String(), want) {
+		t.Errorf("%q not found in:\n%s", want, buf.String())
+	}
+}
+
+func TestGoName(t *testing.T) {
+	for in, out := range map[string]string{
+		"pet_id":       "PetId",
+		"X-Request-ID": "XRequestID",
+		"listPets":     "ListPets",
+		"2fa code":     "X2faCode",
+	} {
+		if goName(in) != out {
+			t.Error(in, ":", goName(in), "!=", out)
+		}
+	}
+}
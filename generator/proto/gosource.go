@@ -0,0 +1,379 @@
+package proto
+
+import (
+	"fmt"
+	gotoken "go/token"
+	"strings"
+
+	"github.com/dave/jennifer/jen"
+	mstrings "github.com/devimteam/microgen/generator/strings"
+)
+
+const (
+	googleProtobuf          = "google.protobuf."
+	googleProtobufEmpty     = googleProtobuf + "Empty"
+	googleProtobufTimestamp = googleProtobuf + "Timestamp"
+	googleProtobufValue     = googleProtobuf + "Value"
+	googleProtobufStruct    = googleProtobuf + "Struct"
+	googleProtobufAny       = googleProtobuf + "Any"
+
+	packagePathContext = "context"
+	packagePathTime    = "time"
+	packagePathProto   = "github.com/golang/protobuf/proto"
+)
+
+var goScalarTypes = map[string]string{
+	"double":   "float64",
+	"float":    "float32",
+	"int32":    "int32",
+	"sint32":   "int32",
+	"sfixed32": "int32",
+	"int64":    "int64",
+	"sint64":   "int64",
+	"sfixed64": "int64",
+	"uint32":   "uint32",
+	"fixed32":  "uint32",
+	"uint64":   "uint64",
+	"fixed64":  "uint64",
+	"bool":     "bool",
+	"string":   "string",
+}
+
+// Wrappers are transferred as pointers to scalar types, unless fields are optional.
+var goWrapperTypes = map[string]string{
+	googleProtobuf + "DoubleValue": "float64",
+	googleProtobuf + "FloatValue":  "float32",
+	googleProtobuf + "Int64Value":  "int64",
+	googleProtobuf + "UInt64Value": "uint64",
+	googleProtobuf + "Int32Value":  "int32",
+	googleProtobuf + "UInt32Value": "uint32",
+	googleProtobuf + "BoolValue":   "bool",
+	googleProtobuf + "StringValue": "string",
+}
+
+// GoFile renders go file with service interface, structures for messages and types for enums.
+// Method arguments are fields of <Method>Request message and results are fields of <Method>Response message,
+// names of structures and fields are the same as protoc-gen-go generates,
+// so mapping between go and protobuf types is known and converters may be generated without stubs.
+//
+//		// @microgen middleware, logging, grpc
+//		// @protobuf example.com/svc/pb
+//		type StringService interface {
+//			Count(ctx context.Context, text string, symbol string) (count int64, positions []int64, err error)
+//		}
+//
+func GoFile(file *File, pkgName string, tags []string) (*jen.File, error) {
+	if len(file.Services) != 1 {
+		return nil, fmt.Errorf("exactly one service should be declared, found %d", len(file.Services))
+	}
+	if file.GoPackage == "" {
+		return nil, fmt.Errorf("option go_package is required")
+	}
+	svc := file.Services[0]
+	exchanges := make(map[string]bool)
+	for _, rpc := range svc.Methods {
+		if rpc.ClientStreaming || rpc.ServerStreaming {
+			return nil, fmt.Errorf("%s: streaming is not supported", rpc.Name)
+		}
+		if rpc.Request != googleProtobufEmpty && rpc.Request != rpc.Name+"Request" {
+			return nil, fmt.Errorf("%s: request message should be %sRequest or %s, got %s", rpc.Name, rpc.Name, googleProtobufEmpty, rpc.Request)
+		}
+		if rpc.Response != googleProtobufEmpty && rpc.Response != rpc.Name+"Response" {
+			return nil, fmt.Errorf("%s: response message should be %sResponse or %s, got %s", rpc.Name, rpc.Name, googleProtobufEmpty, rpc.Response)
+		}
+		exchanges[rpc.Request] = true
+		exchanges[rpc.Response] = true
+	}
+
+	optional, err := optionalPointers(file)
+	if err != nil {
+		return nil, err
+	}
+
+	f := jen.NewFile(pkgName)
+	f.HeaderComment("Code generated by microgen from protobuf file. DO NOT EDIT.")
+
+	var methods []jen.Code
+	for _, rpc := range svc.Methods {
+		m, err := goMethod(file, rpc)
+		if err != nil {
+			return nil, err
+		}
+		methods = append(methods, m)
+	}
+	for _, doc := range svc.Docs {
+		f.Comment(doc)
+	}
+	f.Comment("@microgen " + strings.Join(tags, ", "))
+	f.Comment("@protobuf " + goPackageImport(file.GoPackage))
+	if optional {
+		f.Comment("@protobuf-pointers optional")
+	}
+	f.Type().Id(svc.Name).Interface(methods...)
+
+	// Messages, which are used only as requests or responses, are replaced with method params.
+	used := usedAsFieldType(file)
+	for _, msg := range file.Messages {
+		if exchanges[msg.Name] && !used[msg.Name] {
+			continue
+		}
+		s, err := goStruct(file, msg)
+		if err != nil {
+			return nil, err
+		}
+		f.Line().Add(s)
+	}
+	for _, enum := range file.Enums {
+		f.Line().Add(goEnum(enum))
+	}
+	return f, nil
+}
+
+// Returns import path of protobuf package.
+//
+//		example.com/svc/pb;pb -> example.com/svc/pb
+//
+func goPackageImport(goPackage string) string {
+	if i := strings.Index(goPackage, ";"); i != -1 {
+		return goPackage[:i]
+	}
+	return goPackage
+}
+
+// Returns true, when file declares optional fields, so pointers should be transferred as optional fields instead of wrappers.
+// Wrappers and optional fields can not be mixed, because both are pointers in go.
+func optionalPointers(file *File) (bool, error) {
+	var optional, wrapper *Field
+	for _, msg := range file.Messages {
+		for _, field := range msg.Fields {
+			if field.Optional && optional == nil {
+				optional = field
+			}
+			if _, ok := goWrapperTypes[field.Type]; ok && wrapper == nil {
+				wrapper = field
+			}
+		}
+	}
+	if optional != nil && wrapper != nil {
+		return false, fmt.Errorf("optional field %s and wrapper field %s can not be used together", optional.Name, wrapper.Name)
+	}
+	return optional != nil, nil
+}
+
+func usedAsFieldType(file *File) map[string]bool {
+	used := make(map[string]bool)
+	for _, msg := range file.Messages {
+		for _, field := range msg.Fields {
+			used[field.Type] = true
+		}
+	}
+	return used
+}
+
+func goMethod(file *File, rpc *RPC) (jen.Code, error) {
+	args, err := goParams(file, rpc.Request)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", rpc.Name, err)
+	}
+	results, err := goParams(file, rpc.Response)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", rpc.Name, err)
+	}
+	s := &jen.Statement{}
+	for _, doc := range rpc.Docs {
+		s.Comment(doc).Line()
+	}
+	return s.Id(rpc.Name).
+		Params(append([]jen.Code{jen.Id("ctx").Qual(packagePathContext, "Context")}, args...)...).
+		Params(append(results, jen.Id("err").Error())...), nil
+}
+
+// Renders fields of message as method params.
+func goParams(file *File, msgName string) (params []jen.Code, err error) {
+	if msgName == googleProtobufEmpty {
+		return nil, nil
+	}
+	msg := file.Message(msgName)
+	if msg == nil {
+		return nil, fmt.Errorf("unknown message %s", msgName)
+	}
+	// microgen uses google.protobuf.Timestamp directly instead of message with single timestamp field.
+	if len(msg.Fields) == 1 && msg.Fields[0].Type == googleProtobufTimestamp && !msg.Fields[0].Repeated {
+		return nil, fmt.Errorf("%s: message with single %s field is not supported, use %s instead", msg.Name, googleProtobufTimestamp, googleProtobufTimestamp)
+	}
+	for _, field := range msg.Fields {
+		name := mstrings.ToLowerFirst(CamelCase(field.Name))
+		if gotoken.Lookup(name).IsKeyword() || name == "ctx" || name == "err" {
+			return nil, fmt.Errorf("%s: field name %s can not be used as name of argument", msg.Name, field.Name)
+		}
+		t, err := goFieldType(file, field)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", msg.Name, err)
+		}
+		params = append(params, jen.Id(name).Add(t))
+	}
+	return params, nil
+}
+
+// Renders structure for message.
+//
+//		// Comment docs.
+//		type Comment struct {
+//			Text      string    `json:"text"`
+//			CreatedAt time.Time `json:"created_at"`
+//		}
+//
+func goStruct(file *File, msg *Message) (*jen.Statement, error) {
+	var fields []jen.Code
+	for _, field := range msg.Fields {
+		t, err := goFieldType(file, field)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", msg.Name, err)
+		}
+		s := &jen.Statement{}
+		for _, doc := range field.Docs {
+			s.Comment(doc).Line()
+		}
+		fields = append(fields, s.Id(CamelCase(field.Name)).Add(t).Tag(map[string]string{"json": field.Name}))
+	}
+	s := &jen.Statement{}
+	for _, doc := range msg.Docs {
+		s.Comment(doc).Line()
+	}
+	return s.Type().Id(CamelCase(msg.Name)).Struct(fields...), nil
+}
+
+// Renders named type and constants for enum.
+//
+//		type Status int32
+//
+//		const (
+//			StatusUnknown Status = 0
+//			StatusActive  Status = 1
+//		)
+//
+func goEnum(enum *Enum) *jen.Statement {
+	name := CamelCase(enum.Name)
+	s := &jen.Statement{}
+	for _, doc := range enum.Docs {
+		s.Comment(doc).Line()
+	}
+	s.Type().Id(name).Int32().Line().Line()
+	return s.Const().DefsFunc(func(g *jen.Group) {
+		for _, v := range enum.Values {
+			g.Id(EnumValueName(enum, v)).Id(name).Op("=").Lit(v.Number)
+		}
+	})
+}
+
+// EnumValueName returns name of go constant for enum value.
+// Prefix with enum name is removed from value name, because go constant has it already.
+//
+//		enum Status { STATUS_ACTIVE = 1; } -> StatusActive
+//
+func EnumValueName(enum *Enum, v *EnumValue) string {
+	short := enum.Name
+	if i := strings.LastIndex(short, "_"); i != -1 {
+		short = short[i+1:]
+	}
+	value := v.Name
+	if prefix := strings.ToUpper(mstrings.ToSnakeCase(short)) + "_"; strings.HasPrefix(value, prefix) && len(value) > len(prefix) {
+		value = value[len(prefix):]
+	}
+	return CamelCase(enum.Name) + CamelCase(strings.ToLower(value))
+}
+
+// Renders go type of message field.
+//
+//		map<string, Comment> -> map[string]*Comment
+//		optional string -> *string
+//		google.protobuf.StringValue -> *string
+//		google.protobuf.Struct -> map[string]interface{}
+//
+func goFieldType(file *File, field *Field) (*jen.Statement, error) {
+	s := &jen.Statement{}
+	if field.MapKey != "" {
+		key, ok := goScalarTypes[field.MapKey]
+		if !ok {
+			return nil, fmt.Errorf("%s: unsupported map key type %s", field.Name, field.MapKey)
+		}
+		s.Map(jen.Id(key))
+	} else if field.Repeated {
+		s.Index()
+	}
+	if field.Optional {
+		switch {
+		case field.Type == "bytes":
+			return nil, fmt.Errorf("%s: optional bytes fields are not supported", field.Name)
+		case goScalarTypes[field.Type] != "", file.Enum(field.Type) != nil:
+			s.Op("*")
+		}
+	}
+	switch {
+	case field.Type == "bytes":
+		return s.Index().Byte(), nil
+	case goScalarTypes[field.Type] != "":
+		return s.Id(goScalarTypes[field.Type]), nil
+	case field.Type == googleProtobufTimestamp:
+		return s.Qual(packagePathTime, "Time"), nil
+	case field.Type == googleProtobufValue:
+		return s.Interface(), nil
+	case field.Type == googleProtobufStruct:
+		return s.Map(jen.String()).Interface(), nil
+	case field.Type == googleProtobufAny:
+		return s.Qual(packagePathProto, "Message"), nil
+	case goWrapperTypes[field.Type] != "":
+		return s.Op("*").Id(goWrapperTypes[field.Type]), nil
+	case strings.HasPrefix(field.Type, googleProtobuf):
+		return nil, fmt.Errorf("%s: type %s is not supported", field.Name, field.Type)
+	case file.Enum(field.Type) != nil:
+		return s.Id(CamelCase(field.Type)), nil
+	case file.Message(field.Type) != nil:
+		return s.Op("*").Id(CamelCase(field.Type)), nil
+	}
+	return nil, fmt.Errorf("%s: unknown type %s", field.Name, field.Type)
+}
+
+// CamelCase returns name of go identifier for protobuf name, as protoc-gen-go does.
+//
+//		created_at -> CreatedAt
+//		Outer_Inner -> Outer_Inner
+//
+func CamelCase(s string) string {
+	if s == "" {
+		return ""
+	}
+	t := make([]byte, 0, len(s))
+	i := 0
+	if s[0] == '_' {
+		t = append(t, 'X')
+		i++
+	}
+	for ; i < len(s); i++ {
+		c := s[i]
+		if c == '_' && i+1 < len(s) && isLower(s[i+1]) {
+			continue
+		}
+		if isDigit(c) {
+			t = append(t, c)
+			continue
+		}
+		if isLower(c) {
+			c ^= ' '
+		}
+		t = append(t, c)
+		for i+1 < len(s) && isLower(s[i+1]) {
+			i++
+			t = append(t, s[i])
+		}
+	}
+	return string(t)
+}
+
+func isLower(c byte) bool {
+	return 'a' <= c && c <= 'z'
+}
+
+func isDigit(c byte) bool {
+	return '0' <= c && c <= '9'
+}
@@ -0,0 +1,621 @@
+package proto
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// ParseFile parses .proto file from provided path.
+func ParseFile(filename string) (*File, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	file, err := Parse(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", filename, err)
+	}
+	return file, nil
+}
+
+// Parse parses protobuf 3 source.
+// Only syntax, package, imports, options, messages, enums and services are supported.
+func Parse(r io.Reader) (*File, error) {
+	src, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokenize(string(src))}
+	file, err := p.parseFile()
+	if err != nil {
+		return nil, err
+	}
+	if err := resolveTypes(file); err != nil {
+		return nil, err
+	}
+	return file, nil
+}
+
+type token struct {
+	text string
+	line int
+	// Comments above token.
+	docs []string
+}
+
+func tokenize(src string) (tokens []token) {
+	var docs []string
+	line := 1
+	// Comments, which are placed after token on the same line, do not describe next token.
+	lastLine := 0
+	for i := 0; i < len(src); {
+		c := src[i]
+		switch {
+		case c == '\n':
+			line++
+			i++
+		case unicode.IsSpace(rune(c)):
+			i++
+		case strings.HasPrefix(src[i:], "//"):
+			end := strings.IndexByte(src[i:], '\n')
+			if end == -1 {
+				end = len(src) - i
+			}
+			if line != lastLine {
+				docs = append(docs, strings.TrimSpace(src[i+2:i+end]))
+			}
+			i += end
+		case strings.HasPrefix(src[i:], "/*"):
+			end := strings.Index(src[i+2:], "*/")
+			if end == -1 {
+				end = len(src) - i - 2
+			}
+			comment := src[i+2 : i+2+end]
+			line += strings.Count(comment, "\n")
+			for _, l := range strings.Split(comment, "\n") {
+				if l = strings.TrimSpace(strings.TrimLeft(strings.TrimSpace(l), "*")); l != "" {
+					docs = append(docs, l)
+				}
+			}
+			i += end + 4
+		case c == '"' || c == '\'':
+			j := i + 1
+			for j < len(src) && src[j] != c {
+				if src[j] == '\\' {
+					j++
+				}
+				j++
+			}
+			tokens = append(tokens, token{text: src[i:min(j+1, len(src))], line: line, docs: docs})
+			lastLine = line
+			docs = nil
+			i = j + 1
+		case isIdentChar(c):
+			j := i
+			for j < len(src) && (isIdentChar(src[j]) || src[j] == '.') {
+				j++
+			}
+			tokens = append(tokens, token{text: src[i:j], line: line, docs: docs})
+			lastLine = line
+			docs = nil
+			i = j
+		default:
+			tokens = append(tokens, token{text: string(c), line: line, docs: docs})
+			lastLine = line
+			docs = nil
+			i++
+		}
+	}
+	return
+}
+
+func isIdentChar(c byte) bool {
+	return c == '_' || c == '.' || c == '-' || c == '+' ||
+		'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9'
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *parser) eof() bool {
+	return p.pos >= len(p.tokens)
+}
+
+func (p *parser) peek() token {
+	if p.eof() {
+		return token{}
+	}
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.peek()
+	p.pos++
+	return t
+}
+
+func (p *parser) errorf(format string, args ...interface{}) error {
+	line := 0
+	if p.pos > 0 && p.pos <= len(p.tokens) {
+		line = p.tokens[p.pos-1].line
+	} else if !p.eof() {
+		line = p.peek().line
+	}
+	return fmt.Errorf("line %d: %s", line, fmt.Sprintf(format, args...))
+}
+
+func (p *parser) expect(text string) error {
+	if t := p.next(); t.text != text {
+		return p.errorf("expected '%s', got '%s'", text, t.text)
+	}
+	return nil
+}
+
+func (p *parser) ident() (string, error) {
+	t := p.next()
+	if t.text == "" || !isIdentChar(t.text[0]) {
+		return "", p.errorf("expected identifier, got '%s'", t.text)
+	}
+	return t.text, nil
+}
+
+func (p *parser) number() (int, error) {
+	t := p.next()
+	n, err := strconv.ParseInt(t.text, 0, 64)
+	if err != nil {
+		return 0, p.errorf("expected number, got '%s'", t.text)
+	}
+	return int(n), nil
+}
+
+func (p *parser) str() (string, error) {
+	t := p.next()
+	s, err := strconv.Unquote(strings.Replace(t.text, "'", "\"", -1))
+	if err != nil {
+		return "", p.errorf("expected string, got '%s'", t.text)
+	}
+	return s, nil
+}
+
+// Skips everything until the end of statement, including nested blocks.
+func (p *parser) skipStatement() error {
+	depth := 0
+	for !p.eof() {
+		switch t := p.next().text; t {
+		case "{", "[", "(":
+			depth++
+		case "}", "]", ")":
+			depth--
+			// Block is statement itself.
+			if depth == 0 && t == "}" {
+				if p.peek().text == ";" {
+					p.next()
+				}
+				return nil
+			}
+		case ";":
+			if depth == 0 {
+				return nil
+			}
+		}
+	}
+	return p.errorf("unexpected end of file")
+}
+
+func (p *parser) parseFile() (*File, error) {
+	file := &File{}
+	for !p.eof() {
+		t := p.peek()
+		switch t.text {
+		case "syntax":
+			p.next()
+			if err := p.expect("="); err != nil {
+				return nil, err
+			}
+			s, err := p.str()
+			if err != nil {
+				return nil, err
+			}
+			if s != "proto3" {
+				return nil, p.errorf("only proto3 syntax is supported, got %s", s)
+			}
+			file.Syntax = s
+			if err := p.expect(";"); err != nil {
+				return nil, err
+			}
+		case "package":
+			p.next()
+			name, err := p.ident()
+			if err != nil {
+				return nil, err
+			}
+			file.Package = name
+			if err := p.expect(";"); err != nil {
+				return nil, err
+			}
+		case "import":
+			p.next()
+			if n := p.peek().text; n == "public" || n == "weak" {
+				p.next()
+			}
+			s, err := p.str()
+			if err != nil {
+				return nil, err
+			}
+			file.Imports = append(file.Imports, s)
+			if err := p.expect(";"); err != nil {
+				return nil, err
+			}
+		case "option":
+			p.next()
+			if p.peek().text != "go_package" {
+				if err := p.skipStatement(); err != nil {
+					return nil, err
+				}
+				continue
+			}
+			p.next()
+			if err := p.expect("="); err != nil {
+				return nil, err
+			}
+			s, err := p.str()
+			if err != nil {
+				return nil, err
+			}
+			file.GoPackage = s
+			if err := p.expect(";"); err != nil {
+				return nil, err
+			}
+		case "message":
+			if err := p.parseMessage(file, ""); err != nil {
+				return nil, err
+			}
+		case "enum":
+			if err := p.parseEnum(file, ""); err != nil {
+				return nil, err
+			}
+		case "service":
+			s, err := p.parseService()
+			if err != nil {
+				return nil, err
+			}
+			file.Services = append(file.Services, s)
+		case ";":
+			p.next()
+		default:
+			return nil, p.errorf("unexpected '%s'", t.text)
+		}
+	}
+	if file.Syntax == "" {
+		return nil, fmt.Errorf("syntax is not declared, only proto3 is supported")
+	}
+	return file, nil
+}
+
+func (p *parser) parseMessage(file *File, prefix string) error {
+	docs := p.next().docs
+	name, err := p.ident()
+	if err != nil {
+		return err
+	}
+	msg := &Message{Name: prefix + name, Docs: docs}
+	file.Messages = append(file.Messages, msg)
+	if err := p.expect("{"); err != nil {
+		return err
+	}
+	for !p.eof() {
+		t := p.peek()
+		switch t.text {
+		case "}":
+			p.next()
+			return nil
+		case ";":
+			p.next()
+		case "message":
+			if err := p.parseMessage(file, msg.Name+"_"); err != nil {
+				return err
+			}
+		case "enum":
+			if err := p.parseEnum(file, msg.Name+"_"); err != nil {
+				return err
+			}
+		case "option", "reserved", "extensions":
+			if err := p.skipStatement(); err != nil {
+				return err
+			}
+		case "oneof":
+			return p.errorf("%s: oneof is not supported", msg.Name)
+		default:
+			field, err := p.parseField()
+			if err != nil {
+				return fmt.Errorf("%s: %v", msg.Name, err)
+			}
+			msg.Fields = append(msg.Fields, field)
+		}
+	}
+	return p.errorf("unexpected end of file in message %s", msg.Name)
+}
+
+// Parses message field.
+//
+//		repeated string tags = 1 [json_name = "tags"];
+//		map<string, int64> counts = 2;
+//		optional string bio = 3;
+//
+func (p *parser) parseField() (*Field, error) {
+	field := &Field{Docs: p.peek().docs}
+	switch p.peek().text {
+	case "repeated":
+		p.next()
+		field.Repeated = true
+	case "optional":
+		p.next()
+		field.Optional = true
+	}
+	if p.peek().text == "map" {
+		p.next()
+		if err := p.expect("<"); err != nil {
+			return nil, err
+		}
+		key, err := p.ident()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(","); err != nil {
+			return nil, err
+		}
+		value, err := p.ident()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(">"); err != nil {
+			return nil, err
+		}
+		field.MapKey, field.Type = key, value
+	} else {
+		typ, err := p.ident()
+		if err != nil {
+			return nil, err
+		}
+		field.Type = typ
+	}
+	name, err := p.ident()
+	if err != nil {
+		return nil, err
+	}
+	field.Name = name
+	if err := p.expect("="); err != nil {
+		return nil, err
+	}
+	if field.Number, err = p.number(); err != nil {
+		return nil, err
+	}
+	if p.peek().text == "[" {
+		if err := p.skipStatement(); err != nil {
+			return nil, err
+		}
+		return field, nil
+	}
+	return field, p.expect(";")
+}
+
+func (p *parser) parseEnum(file *File, prefix string) error {
+	docs := p.next().docs
+	name, err := p.ident()
+	if err != nil {
+		return err
+	}
+	enum := &Enum{Name: prefix + name, Docs: docs}
+	file.Enums = append(file.Enums, enum)
+	if err := p.expect("{"); err != nil {
+		return err
+	}
+	for !p.eof() {
+		switch p.peek().text {
+		case "}":
+			p.next()
+			return nil
+		case ";":
+			p.next()
+		case "option", "reserved":
+			if err := p.skipStatement(); err != nil {
+				return err
+			}
+		default:
+			value := &EnumValue{}
+			if value.Name, err = p.ident(); err != nil {
+				return err
+			}
+			if err := p.expect("="); err != nil {
+				return err
+			}
+			neg := false
+			if p.peek().text == "-" {
+				p.next()
+				neg = true
+			}
+			if value.Number, err = p.number(); err != nil {
+				return err
+			}
+			if neg {
+				value.Number = -value.Number
+			}
+			enum.Values = append(enum.Values, value)
+			if p.peek().text == "[" {
+				if err := p.skipStatement(); err != nil {
+					return err
+				}
+				continue
+			}
+			if err := p.expect(";"); err != nil {
+				return err
+			}
+		}
+	}
+	return p.errorf("unexpected end of file in enum %s", enum.Name)
+}
+
+func (p *parser) parseService() (*Service, error) {
+	docs := p.next().docs
+	name, err := p.ident()
+	if err != nil {
+		return nil, err
+	}
+	svc := &Service{Name: name, Docs: docs}
+	if err := p.expect("{"); err != nil {
+		return nil, err
+	}
+	for !p.eof() {
+		switch t := p.peek(); t.text {
+		case "}":
+			p.next()
+			return svc, nil
+		case ";":
+			p.next()
+		case "option":
+			if err := p.skipStatement(); err != nil {
+				return nil, err
+			}
+		case "rpc":
+			rpc, err := p.parseRPC()
+			if err != nil {
+				return nil, err
+			}
+			svc.Methods = append(svc.Methods, rpc)
+		default:
+			return nil, p.errorf("unexpected '%s' in service %s", t.text, svc.Name)
+		}
+	}
+	return nil, p.errorf("unexpected end of file in service %s", svc.Name)
+}
+
+// Parses service method.
+//
+//		rpc Count (CountRequest) returns (CountResponse);
+//		rpc Count (stream CountRequest) returns (stream CountResponse) { option ... }
+//
+func (p *parser) parseRPC() (*RPC, error) {
+	rpc := &RPC{Docs: p.next().docs}
+	var err error
+	if rpc.Name, err = p.ident(); err != nil {
+		return nil, err
+	}
+	if rpc.Request, rpc.ClientStreaming, err = p.parseRPCType(); err != nil {
+		return nil, err
+	}
+	if err := p.expect("returns"); err != nil {
+		return nil, err
+	}
+	if rpc.Response, rpc.ServerStreaming, err = p.parseRPCType(); err != nil {
+		return nil, err
+	}
+	if p.peek().text == "{" {
+		return rpc, p.skipStatement()
+	}
+	return rpc, p.expect(";")
+}
+
+func (p *parser) parseRPCType() (name string, stream bool, err error) {
+	if err = p.expect("("); err != nil {
+		return
+	}
+	if p.peek().text == "stream" {
+		p.next()
+		stream = true
+	}
+	if name, err = p.ident(); err != nil {
+		return
+	}
+	err = p.expect(")")
+	return
+}
+
+var scalarTypes = map[string]bool{
+	"double": true, "float": true,
+	"int32": true, "int64": true, "uint32": true, "uint64": true,
+	"sint32": true, "sint64": true, "fixed32": true, "fixed64": true, "sfixed32": true, "sfixed64": true,
+	"bool": true, "string": true, "bytes": true,
+}
+
+// IsScalar returns true for builtin protobuf types.
+func IsScalar(t string) bool {
+	return scalarTypes[t]
+}
+
+// Replaces relative names of messages and enums with flattened names.
+// Nested names are searched from innermost scope, as protoc does.
+//
+//		message Outer { message Inner {} Inner inner = 1; } -> Outer_Inner
+//
+func resolveTypes(file *File) error {
+	known := make(map[string]bool)
+	for _, m := range file.Messages {
+		known[m.Name] = true
+	}
+	for _, e := range file.Enums {
+		known[e.Name] = true
+	}
+	for _, m := range file.Messages {
+		for _, f := range m.Fields {
+			if IsScalar(f.Type) || strings.HasPrefix(f.Type, "google.protobuf.") {
+				continue
+			}
+			resolved, ok := resolveName(known, file.Package, m.Name, f.Type)
+			if !ok {
+				return fmt.Errorf("%s.%s: unknown type %s", m.Name, f.Name, f.Type)
+			}
+			f.Type = resolved
+		}
+	}
+	for _, s := range file.Services {
+		for _, rpc := range s.Methods {
+			for _, t := range []*string{&rpc.Request, &rpc.Response} {
+				if strings.HasPrefix(*t, "google.protobuf.") {
+					continue
+				}
+				resolved, ok := resolveName(known, file.Package, "", *t)
+				if !ok {
+					return fmt.Errorf("%s.%s: unknown type %s", s.Name, rpc.Name, *t)
+				}
+				*t = resolved
+			}
+		}
+	}
+	return nil
+}
+
+func resolveName(known map[string]bool, pkg, scope, name string) (string, bool) {
+	name = strings.Replace(trimPackage(pkg, name), ".", "_", -1)
+	for {
+		candidate := name
+		if scope != "" {
+			candidate = scope + "_" + name
+		}
+		if known[candidate] {
+			return candidate, true
+		}
+		if scope == "" {
+			return "", false
+		}
+		if i := strings.LastIndex(scope, "_"); i != -1 {
+			scope = scope[:i]
+		} else {
+			scope = ""
+		}
+	}
+}
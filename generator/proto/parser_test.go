@@ -0,0 +1,137 @@
+package proto
+
+import (
+	"strings"
+	"testing"
+)
+
+const testProto = `
+syntax = "proto3";
+
+package test.v1;
+
+option go_package = "example.com/test/pb;pb";
+
+import "google/protobuf/timestamp.proto";
+
+// Service docs.
+service StringService {
+    // Count docs.
+    rpc Count (CountRequest) returns (CountResponse);
+    rpc Get (GetRequest) returns (GetResponse) {
+        option (google.api.http) = { get: "/v1/get/{id}" };
+    }
+}
+
+enum Kind {
+    KIND_UNKNOWN = 0;
+    KIND_SIMPLE = 1; // trailing comment
+}
+
+message CountRequest {
+    string text = 1;
+    repeated int64 positions = 2 [packed = true];
+}
+
+message CountResponse {
+    map<string, Outer.Inner> counts = 1;
+}
+
+message Outer {
+    message Inner {
+        Kind kind = 1;
+    }
+    Inner inner = 1;
+    google.protobuf.Timestamp created_at = 2;
+}
+
+message GetRequest {
+    int64 id = 1;
+    optional Kind kind = 2;
+}
+
+message GetResponse {
+    .test.v1.Outer outer = 1;
+}
+`
+
+func TestParse(t *testing.T) {
+	file, err := Parse(strings.NewReader(testProto))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if file.Package != "test.v1" || file.GoPackage != "example.com/test/pb;pb" {
+		t.Error("package:", file.Package, file.GoPackage)
+	}
+	if len(file.Services) != 1 || len(file.Services[0].Methods) != 2 {
+		t.Fatal("services:", file.Services)
+	}
+	count := file.Services[0].Methods[0]
+	if count.Name != "Count" || count.Request != "CountRequest" || count.Response != "CountResponse" {
+		t.Error("rpc:", *count)
+	}
+	if len(count.Docs) != 1 || count.Docs[0] != "Count docs." {
+		t.Error("rpc docs:", count.Docs)
+	}
+	if get := file.Services[0].Methods[1]; get.Name != "Get" || get.Response != "GetResponse" {
+		t.Error("rpc with options:", *get)
+	}
+	if m := file.Message("CountResponse"); m == nil || m.Fields[0].MapKey != "string" || m.Fields[0].Type != "Outer_Inner" {
+		t.Error("map field:", m)
+	}
+	if m := file.Message("Outer"); m == nil || m.Fields[0].Type != "Outer_Inner" || m.Fields[1].Type != "google.protobuf.Timestamp" {
+		t.Error("nested message:", m)
+	}
+	if m := file.Message("Outer_Inner"); m == nil || m.Fields[0].Type != "Kind" {
+		t.Error("enum field:", m)
+	}
+	if m := file.Message("GetResponse"); m == nil || m.Fields[0].Type != "Outer" {
+		t.Error("full name field:", m)
+	}
+	if m := file.Message("CountRequest"); m == nil || !m.Fields[1].Repeated || m.Fields[1].Number != 2 {
+		t.Error("repeated field:", m)
+	}
+	if m := file.Message("GetRequest"); m == nil || m.Fields[0].Optional || !m.Fields[1].Optional || m.Fields[1].Type != "Kind" {
+		t.Error("optional field:", m)
+	}
+	if e := file.Enum("Kind"); e == nil || len(e.Values) != 2 || e.Values[1].Number != 1 {
+		t.Error("enum:", e)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, src := range []string{
+		`syntax = "proto2";`,
+		`syntax = "proto3"; message A { B b = 1; }`,
+		`syntax = "proto3"; message A { oneof x { string a = 1; } }`,
+		`syntax = "proto3"; message A { string a = 1 }`,
+	} {
+		if _, err := Parse(strings.NewReader(src)); err == nil {
+			t.Error("expected error for:", src)
+		}
+	}
+}
+
+func TestCamelCase(t *testing.T) {
+	for in, out := range map[string]string{
+		"created_at":  "CreatedAt",
+		"id":          "Id",
+		"Outer_Inner": "Outer_Inner",
+		"_hidden":     "XHidden",
+		"v2_value":    "V2Value",
+	} {
+		if CamelCase(in) != out {
+			t.Error(in, ":", CamelCase(in), "!=", out)
+		}
+	}
+}
+
+func TestEnumValueName(t *testing.T) {
+	e := &Enum{Name: "Kind", Values: []*EnumValue{{Name: "KIND_SIMPLE"}, {Name: "OTHER"}}}
+	if n := EnumValueName(e, e.Values[0]); n != "KindSimple" {
+		t.Error(n, "!= KindSimple")
+	}
+	if n := EnumValueName(e, e.Values[1]); n != "KindOther" {
+		t.Error(n, "!= KindOther")
+	}
+}
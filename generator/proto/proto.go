@@ -0,0 +1,90 @@
+// Package proto contains minimal parser of protobuf 3 files, which is enough to restore go service interface from it.
+package proto
+
+// File is parsed .proto file.
+type File struct {
+	Syntax    string
+	Package   string
+	GoPackage string
+	Imports   []string
+	Messages  []*Message
+	Enums     []*Enum
+	Services  []*Service
+}
+
+// Message declaration. Nested messages and enums are flattened to file level with names like Outer_Inner.
+type Message struct {
+	Name   string
+	Docs   []string
+	Fields []*Field
+}
+
+type Field struct {
+	Name     string
+	Docs     []string
+	Type     string
+	Number   int
+	Repeated bool
+	// Field is declared with proto3 optional label.
+	Optional bool
+	// Not empty for map fields, Type holds type of value.
+	MapKey string
+}
+
+type Enum struct {
+	Name   string
+	Docs   []string
+	Values []*EnumValue
+}
+
+type EnumValue struct {
+	Name   string
+	Number int
+}
+
+type Service struct {
+	Name    string
+	Docs    []string
+	Methods []*RPC
+}
+
+type RPC struct {
+	Name            string
+	Docs            []string
+	Request         string
+	Response        string
+	ClientStreaming bool
+	ServerStreaming bool
+}
+
+// Returns message by its full name, or nil if there is no such message.
+func (f *File) Message(name string) *Message {
+	name = trimPackage(f.Package, name)
+	for _, m := range f.Messages {
+		if m.Name == name {
+			return m
+		}
+	}
+	return nil
+}
+
+// Returns enum by its full name, or nil if there is no such enum.
+func (f *File) Enum(name string) *Enum {
+	name = trimPackage(f.Package, name)
+	for _, e := range f.Enums {
+		if e.Name == name {
+			return e
+		}
+	}
+	return nil
+}
+
+func trimPackage(pkg, name string) string {
+	if len(name) > 0 && name[0] == '.' {
+		name = name[1:]
+	}
+	if pkg != "" && len(name) > len(pkg)+1 && name[:len(pkg)+1] == pkg+"." {
+		name = name[len(pkg)+1:]
+	}
+	return name
+}
@@ -157,15 +157,21 @@ func (t *mainTemplate) mainFunc(ctx context.Context) *Statement {
 		if Tags(ctx).HasAny(GrpcTag, GrpcServerTag) {
 			main.Line()
 			main.Id("grpcAddr").Op(":=").Lit(":8081").Comment("TODO: use normal address")
+			if Tags(ctx).Has(GrpcHealthTag) {
+				main.Id("grpcDrainDelay").Op(":=").Lit(5).Op("*").Qual(PackagePathTime, "Second").Comment("TODO: use delay of your load balancer")
+			}
 			main.Comment(`Start grpc server.`)
 			main.Id("g").Dot("Go").Call(
 				Func().Params().Params(Error()).Block(
-					Return().Id(nameServeGRPC).Call(
-						Id(_ctx_),
-						Op("&").Id("endpoints"),
-						Id("grpcAddr"),
-						Qual(PackagePathGoKitLog, "With").Call(Id(_logger_), Lit("transport"), Lit("GRPC")),
-					),
+					Return().Id(nameServeGRPC).CallFunc(func(g *Group) {
+						g.Id(_ctx_)
+						g.Op("&").Id("endpoints")
+						g.Id("grpcAddr")
+						if Tags(ctx).Has(GrpcHealthTag) {
+							g.Id("grpcDrainDelay")
+						}
+						g.Qual(PackagePathGoKitLog, "With").Call(Id(_logger_), Lit("transport"), Lit("GRPC"))
+					}),
 				),
 			)
 		}
@@ -223,21 +229,31 @@ func (t *mainTemplate) initLogger() *Statement {
 // 			srv := transportgrpc.NewGRPCServer(endpoints)
 // 			grpcs := grpc.NewServer()
 // 			pb.RegisterClientServiceServer(grpcs, srv)
+// 			healthServer := health.NewServer()
+// 			grpc_health_v1.RegisterHealthServer(grpcs, healthServer)
+// 			reflection.Register(grpcs)
 //
 // 			logger.Log("addr", *bindAddr)
 // 			errCh <- grpcs.Serve(listener)
 // 		}
+// Health and reflection services are registered, when grpc-health and grpc-reflection tags are provided.
+// Health status of server and of each registered service is SERVING, while server is running, and NOT_SERVING
+// after context is canceled. Server waits for drain delay, before it stops accepting new calls.
 func (t *mainTemplate) serveGrpc(ctx context.Context) *Statement {
 	if !Tags(ctx).HasAny(GrpcTag, GrpcServerTag) || mstrings.IsInStringSlice(nameServeGRPC, t.rendered) {
 		return nil
 	}
+	health := Tags(ctx).Has(GrpcHealthTag)
 	return Comment(nameServeGRPC+` starts new GRPC server on address and sends first error to channel.`).Line().
-		Func().Id(nameServeGRPC).Params(
-		ctx_contextContext,
-		Id("endpoints").Op("*").Qual(filepath.Join(t.Info.OutputPackageImport, "transport"), EndpointsSetName),
-		Id("addr").Id("string"),
-		Id(_logger_).Qual(PackagePathGoKitLog, "Logger"),
-	).Params(
+		Func().Id(nameServeGRPC).ParamsFunc(func(g *Group) {
+		g.Add(ctx_contextContext)
+		g.Id("endpoints").Op("*").Qual(filepath.Join(t.Info.OutputPackageImport, "transport"), EndpointsSetName)
+		g.Id("addr").Id("string")
+		if health {
+			g.Id("drainDelay").Qual(PackagePathTime, "Duration")
+		}
+		g.Id(_logger_).Qual(PackagePathGoKitLog, "Logger")
+	}).Params(
 		Error(),
 	).BlockFunc(func(body *Group) {
 		body.List(Id("listener"), Err()).Op(":=").Qual(PackagePathNet, "Listen").Call(Lit("tcp"), Id("addr"))
@@ -248,18 +264,38 @@ func (t *mainTemplate) serveGrpc(ctx context.Context) *Statement {
 		body.Id("server").Op(":=").Qual(filepath.Join(t.Info.OutputPackageImport, "transport/grpc"), "NewGRPCServer").Call(t.newServerParams(ctx))
 		body.Id("grpcServer").Op(":=").Qual(PackagePathGoogleGRPC, "NewServer").Call()
 		body.Qual(t.Info.ProtobufPackageImport, "Register"+mstrings.ToUpperFirst(t.Info.Iface.Name)+"Server").Call(Id("grpcServer"), Id("server"))
+		if health {
+			body.Id("healthServer").Op(":=").Qual(PackagePathGoogleGRPCHealth, "NewServer").Call()
+			body.Qual(PackagePathGoogleGRPCHealthV1, "RegisterHealthServer").Call(Id("grpcServer"), Id("healthServer"))
+		}
+		if Tags(ctx).Has(GrpcReflectionTag) {
+			body.Qual(PackagePathGoogleGRPCReflection, "Register").Call(Id("grpcServer"))
+		}
 		body.Id(_logger_).Dot("Log").Call(Lit("listen on"), Id("addr"))
 		body.Id("ch").Op(":=").Make(Id("chan error"))
 		body.Go().Func().Call().Block(
 			Id("ch").Op("<-").Id("grpcServer").Dot("Serve").Call(Id("listener")),
 		).Call()
-		body.Select().Block(
-			Case(Err().Op(":= <-").Id("ch")),
-			Return().Qual(PackagePathFmt, "Errorf").Call(Lit("grpc server: serve: %v"), Err()),
-			Case(Op("<-").Id(_ctx_).Dot("Done").Call()),
-			Id("grpcServer").Dot("GracefulStop").Call(),
-			Return().Qual(PackagePathErrors, "New").Call(Lit("grpc server: context canceled")),
-		)
+		if health {
+			body.Id("healthServer").Dot("SetServingStatus").Call(Lit(""), Qual(PackagePathGoogleGRPCHealthV1, "HealthCheckResponse_SERVING"))
+			body.Comment(`Services are checked by fully-qualified names, e.g. package.` + t.Info.Iface.Name + `.`)
+			body.For(Id("name").Op(":=").Range().Id("grpcServer").Dot("GetServiceInfo").Call()).Block(
+				Id("healthServer").Dot("SetServingStatus").Call(Id("name"), Qual(PackagePathGoogleGRPCHealthV1, "HealthCheckResponse_SERVING")),
+			)
+		}
+		body.Select().BlockFunc(func(g *Group) {
+			g.Case(Err().Op(":= <-").Id("ch"))
+			g.Return().Qual(PackagePathFmt, "Errorf").Call(Lit("grpc server: serve: %v"), Err())
+			g.Case(Op("<-").Id(_ctx_).Dot("Done").Call())
+			if health {
+				g.Comment(`Clients and load balancers see NOT_SERVING, while active calls are finished.`)
+				g.Id("healthServer").Dot("Shutdown").Call()
+				g.Comment(`Load balancers stop sending new calls, before server stops accepting them.`)
+				g.Qual(PackagePathTime, "Sleep").Call(Id("drainDelay"))
+			}
+			g.Id("grpcServer").Dot("GracefulStop").Call()
+			g.Return().Qual(PackagePathErrors, "New").Call(Lit("grpc server: context canceled"))
+		})
 	})
 }
 
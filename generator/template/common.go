@@ -21,6 +21,9 @@ const (
 	PackagePathGoogleGRPC            = "google.golang.org/grpc"
 	PackagePathGoogleGRPCStatus      = "google.golang.org/grpc/status"
 	PackagePathGoogleGRPCCodes       = "google.golang.org/grpc/codes"
+	PackagePathGoogleGRPCHealth      = "google.golang.org/grpc/health"
+	PackagePathGoogleGRPCHealthV1    = "google.golang.org/grpc/health/grpc_health_v1"
+	PackagePathGoogleGRPCReflection  = "google.golang.org/grpc/reflection"
 	PackagePathNetContext            = "golang.org/x/net/context"
 	PackagePathGoKitTransportGRPC    = "github.com/go-kit/kit/transport/grpc"
 	PackagePathHttp                  = "net/http"
@@ -48,6 +51,9 @@ const (
 	PackagePathGoKitSD               = "github.com/go-kit/kit/sd"
 	PackagePathGoKitLB               = "github.com/go-kit/kit/sd/lb"
 	PackagePathSyncErrgroup          = "golang.org/x/sync/errgroup"
+	PackagePathSync                  = "sync"
+	PackagePathMath                  = "math"
+	PackagePathUnicodeUTF8           = "unicode/utf8"
 
 	TagMark         = "// @"
 	MicrogenMainTag = "microgen"
@@ -76,6 +82,9 @@ const (
 	TransportServer           = "transport-server"
 	MetricsMiddlewareTag      = "metrics"
 	ServiceDiscoveryTag       = "service-discovery"
+	GrpcHealthTag             = "grpc-health"
+	GrpcReflectionTag         = "grpc-reflection"
+	GrpcDirectTag             = "grpc-direct"
 )
 
 const (
@@ -183,6 +192,60 @@ func nameOfLastResultError(fn *types.Function) string {
 	return "err"
 }
 
+// Method is adapted, when it does not accept context.Context as first argument
+// or does not return error as last result.
+// Transport drops context on server side and injects context.Background() on client side for such methods.
+func isAdaptedMethod(fn *types.Function) bool {
+	return !IsContextFirst(fn.Args) || !IsErrorLast(fn.Results)
+}
+
+// Name of method, that provides context and error for adapted method.
+//
+//		CountWithContext
+//
+func withContextMethodName(fn *types.Function) string {
+	if isAdaptedMethod(fn) {
+		return fn.Name + "WithContext"
+	}
+	return fn.Name
+}
+
+var (
+	contextVariable = types.Variable{
+		Base: types.Base{Name: _ctx_},
+		Type: types.TImport{
+			Import: &types.Import{Base: types.Base{Name: "context"}, Package: PackagePathContext},
+			Next:   types.TName{TypeName: "Context"},
+		},
+	}
+	errorVariable = types.Variable{
+		Base: types.Base{Name: "err"},
+		Type: types.TName{TypeName: "error"},
+	}
+)
+
+// Renders return keyword, when function has results.
+func returnIfResults(results []types.Variable) *Statement {
+	if len(results) > 0 {
+		return Return()
+	}
+	return &Statement{}
+}
+
+// Returns copy of function, which first argument is context.Context and last result is error.
+func contextualFunction(fn *types.Function) *types.Function {
+	newFunc := &types.Function{Base: fn.Base}
+	if !IsContextFirst(fn.Args) {
+		newFunc.Args = append(newFunc.Args, contextVariable)
+	}
+	newFunc.Args = append(newFunc.Args, fn.Args...)
+	newFunc.Results = append(newFunc.Results, fn.Results...)
+	if !IsErrorLast(fn.Results) {
+		newFunc.Results = append(newFunc.Results, errorVariable)
+	}
+	return newFunc
+}
+
 // Renders struct field.
 //
 //  	Visit *entity.Visit `json:"visit"`
@@ -255,6 +318,24 @@ func fieldType(ctx context.Context, field types.Type, allowEllipsis bool) *State
 				c.Index()
 			}
 			field = f.Next
+		case types.Struct:
+			return c.StructFunc(func(g *Group) {
+				for _, field := range f.Fields {
+					g.Id(field.Name).Add(fieldType(ctx, field.Type, false)).Op(field.RawTags)
+				}
+			})
+		case *types.Function:
+			return c.Func().Params(funcDefinitionParams(ctx, f.Args)).Params(funcDefinitionParams(ctx, f.Results))
+		case types.TChan:
+			switch f.Direction {
+			case types.ChanDirRecv:
+				c.Op("<-").Chan()
+			case types.ChanDirSend:
+				c.Chan().Op("<-")
+			default:
+				c.Chan()
+			}
+			field = f.Next
 		default:
 			return c
 		}
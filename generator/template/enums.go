@@ -0,0 +1,344 @@
+package template
+
+import (
+	"context"
+	"fmt"
+	"go/ast"
+	"go/constant"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	gotypes "go/types"
+	"os"
+	"sort"
+	"strings"
+
+	. "github.com/dave/jennifer/jen"
+	mstrings "github.com/devimteam/microgen/generator/strings"
+	"github.com/vetcher/go-astra/types"
+)
+
+// Constant of enum type.
+type enumValue struct {
+	Name  string
+	Value constant.Value
+}
+
+var (
+	checkedCache = map[string]*gotypes.Package{}
+	// Imported packages are cached by importer, so it is shared between checked packages.
+	sourceImporter = importer.For("source", nil)
+)
+
+// Returns package, checked by go/types, to evaluate constants. Imports are not resolved,
+// so constants, which depend on imported packages, are unknown.
+func checkedPackage(dir string) *gotypes.Package {
+	if pkg, ok := checkedCache[dir]; ok {
+		return pkg
+	}
+	fset := token.NewFileSet()
+	pkgs, err := parser.ParseDir(fset, dir, func(fi os.FileInfo) bool {
+		return !strings.HasSuffix(fi.Name(), "_test.go")
+	}, 0)
+	if err != nil {
+		checkedCache[dir] = nil
+		return nil
+	}
+	var names []string
+	for name := range pkgs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var pkg *gotypes.Package
+	if len(names) > 0 {
+		var files []*ast.File
+		for _, f := range pkgs[names[0]].Files {
+			files = append(files, f)
+		}
+		sort.Slice(files, func(i, j int) bool { return files[i].Pos() < files[j].Pos() })
+		conf := gotypes.Config{
+			Importer: sourceImporter,
+			Error:    func(error) {},
+		}
+		pkg, _ = conf.Check(dir, fset, files, nil)
+	}
+	checkedCache[dir] = pkg
+	return pkg
+}
+
+// Returns exported constants of named integer or string type in order of declaration.
+// Named types with constants are enums.
+//
+//		type Status string
+//
+//		const (
+//			StatusDraft     Status = "draft"
+//			StatusPublished Status = "published"
+//		)
+//
+func enumValues(info *GenerationInfo, t types.Type) []enumValue {
+	dir, name := declarationDir(info, t)
+	if dir == "" {
+		return nil
+	}
+	pkg := checkedPackage(dir)
+	if pkg == nil {
+		return nil
+	}
+	obj, ok := pkg.Scope().Lookup(name).(*gotypes.TypeName)
+	if !ok {
+		return nil
+	}
+	basic, ok := obj.Type().Underlying().(*gotypes.Basic)
+	if !ok || basic.Info()&(gotypes.IsInteger|gotypes.IsString) == 0 {
+		return nil
+	}
+	var consts []*gotypes.Const
+	for _, n := range pkg.Scope().Names() {
+		c, ok := pkg.Scope().Lookup(n).(*gotypes.Const)
+		if ok && c.Exported() && gotypes.Identical(c.Type(), obj.Type()) && c.Val().Kind() != constant.Unknown {
+			consts = append(consts, c)
+		}
+	}
+	sort.Slice(consts, func(i, j int) bool { return consts[i].Pos() < consts[j].Pos() })
+	values := make([]enumValue, len(consts))
+	for i, c := range consts {
+		values[i] = enumValue{Name: c.Name(), Value: c.Val()}
+	}
+	return values
+}
+
+// Returns the first constant for each value of enum, because other constants are aliases.
+func distinctEnumValues(values []enumValue) (res []enumValue) {
+	seen := make(map[string]bool)
+	for _, v := range values {
+		if !seen[v.Value.ExactString()] {
+			seen[v.Value.ExactString()] = true
+			res = append(res, v)
+		}
+	}
+	return res
+}
+
+func isEnumType(info *GenerationInfo, t types.Type) bool {
+	return len(enumValues(info, t)) > 0
+}
+
+// Integer enums are converted to protobuf enums by cast, values of string enums are mapped by converters.
+func isIntegerEnumType(info *GenerationInfo, t types.Type) bool {
+	values := enumValues(info, t)
+	return len(values) > 0 && values[0].Value.Kind() == constant.Int
+}
+
+// Returns name of protobuf enum value for go constant. Prefix with name of enum is removed from name of constant,
+// because all values are prefixed with name of enum in protobuf, as proto-first generation expects.
+//
+//		Status, StatusDraft -> STATUS_DRAFT
+//
+func protoEnumValueName(enum, name string) string {
+	if short := strings.TrimPrefix(name, enum); short != "" {
+		name = short
+	}
+	return strings.ToUpper(mstrings.ToSnakeCase(enum) + "_" + mstrings.ToSnakeCase(name))
+}
+
+func protoEnumZeroName(enum string) string {
+	return strings.ToUpper(mstrings.ToSnakeCase(enum)) + "_UNSPECIFIED"
+}
+
+// Returns go constant of protobuf enum value, as protoc generates it.
+//
+//		pb.Status_STATUS_DRAFT
+//
+func protoEnumConstant(info *GenerationInfo, enum, value string) *Statement {
+	return Qual(info.ProtobufPackageImport, enum+"_"+value)
+}
+
+// Returns constant of enum, qualified by package of enum.
+func enumConstant(info *GenerationInfo, t types.Type, name string) *Statement {
+	if imp := types.TypeImport(t); imp != nil {
+		return Qual(imp.Package, name)
+	}
+	return Qual(info.SourcePackageImport, name)
+}
+
+// Returns zero value of string enum, which is transferred as UNSPECIFIED value,
+// or constant with empty string value, when it is declared.
+func stringEnumZero(info *GenerationInfo, t types.Type, values []enumValue) (string, *Statement) {
+	enum := *types.TypeName(t)
+	for _, v := range values {
+		if v.Value.Kind() == constant.String && constant.StringVal(v.Value) == "" {
+			return protoEnumValueName(enum, v.Name), enumConstant(info, t, v.Name)
+		}
+	}
+	return protoEnumZeroName(enum), Lit("")
+}
+
+// Renders body of converter from go enum to protobuf enum.
+// Integer enums are casted, values of string enums are mapped.
+//
+//		switch value {
+//		case service.StatusDraft:
+//			return pb.Status_STATUS_DRAFT, nil
+//		case "":
+//			return pb.Status_STATUS_UNSPECIFIED, nil
+//		}
+//		return pb.Status_STATUS_UNSPECIFIED, fmt.Errorf("unknown value %q of service.Status", value)
+//
+func (t *stubGRPCTypeConverterTemplate) enumToProto(ctx context.Context, p types.Type, name string, values []enumValue) *Statement {
+	enum := *types.TypeName(p)
+	if isIntegerEnumType(t.info, p) {
+		return Return(Qual(t.info.ProtobufPackageImport, enum).Call(Id(name)), Nil())
+	}
+	zeroName, zero := stringEnumZero(t.info, p, values)
+	return Switch(Id(name)).BlockFunc(func(g *Group) {
+		for _, v := range distinctEnumValues(values) {
+			if v.Value.Kind() == constant.String && constant.StringVal(v.Value) != "" {
+				g.Case(enumConstant(t.info, p, v.Name)).Block(Return(protoEnumConstant(t.info, enum, protoEnumValueName(enum, v.Name)), Nil()))
+			}
+		}
+		g.Case(zero).Block(Return(protoEnumConstant(t.info, enum, zeroName), Nil()))
+	}).Line().Return(
+		protoEnumConstant(t.info, enum, zeroName),
+		Qual(PackagePathFmt, "Errorf").Call(Lit(fmt.Sprintf("unknown value %%q of %#v", fieldType(ctx, p, false))), Id(name)),
+	)
+}
+
+// Renders body of converter from protobuf enum to go enum.
+// Integer enums are casted, values of string enums are mapped.
+//
+//		switch protoValue {
+//		case pb.Status_STATUS_DRAFT:
+//			return service.StatusDraft, nil
+//		case pb.Status_STATUS_UNSPECIFIED:
+//			return "", nil
+//		}
+//		return "", fmt.Errorf("unknown value %d of pb.Status", protoValue)
+//
+func (t *stubGRPCTypeConverterTemplate) enumProtoTo(ctx context.Context, p types.Type, name string, values []enumValue) *Statement {
+	enum := *types.TypeName(p)
+	if isIntegerEnumType(t.info, p) {
+		return Return(fieldType(ctx, p, false).Call(Id(name)), Nil())
+	}
+	zeroName, zero := stringEnumZero(t.info, p, values)
+	return Switch(Id(name)).BlockFunc(func(g *Group) {
+		for _, v := range values {
+			if v.Value.Kind() == constant.String && constant.StringVal(v.Value) != "" {
+				g.Case(protoEnumConstant(t.info, enum, protoEnumValueName(enum, v.Name))).Block(Return(enumConstant(t.info, p, v.Name), Nil()))
+			}
+		}
+		g.Case(protoEnumConstant(t.info, enum, zeroName)).Block(Return(zero.Clone(), Nil()))
+	}).Line().Return(
+		zero.Clone(),
+		Qual(PackagePathFmt, "Errorf").Call(Lit(fmt.Sprintf("unknown value %%d of %#v", t.protoFieldType(ctx, p))), Id(name)),
+	)
+}
+
+// Enum, declared in service.proto for go type.
+type protoEnum struct {
+	Name   string
+	GoType string
+	Values []enumValue
+}
+
+// Draws enum. Values of integer enums are numbers of constants, values of string enums are numbered by lock file.
+// Zero value is UNSPECIFIED, when enum does not have constant for zero value.
+//
+//		// entity.Status
+//		enum Status {
+//		    STATUS_UNSPECIFIED = 0;
+//		    STATUS_DRAFT = 1;
+//		    STATUS_PUBLISHED = 2;
+//		}
+//
+func (t *protoTemplate) drawEnum(d *DelayBuffer, e *protoEnum) {
+	type value struct {
+		name   string
+		number int64
+	}
+	var values []value
+	zero := value{name: protoEnumZeroName(e.Name)}
+	var fields []protoField
+	for _, v := range e.Values {
+		name := protoEnumValueName(e.Name, v.Name)
+		switch v.Value.Kind() {
+		case constant.Int:
+			n, _ := constant.Int64Val(v.Value)
+			if n == 0 {
+				zero.name = name
+				continue
+			}
+			values = append(values, value{name: name, number: n})
+		case constant.String:
+			if constant.StringVal(v.Value) == "" {
+				zero.name = name
+				continue
+			}
+			fields = append(fields, protoField{Name: name, Type: "enum"})
+		}
+	}
+	if len(fields) > 0 {
+		for i, n := range t.lock.assign(e.Name, fields) {
+			values = append(values, value{name: fields[i].Name, number: int64(n)})
+		}
+	}
+	numbers := make(map[int64]bool)
+	alias := false
+	for _, v := range values {
+		alias = alias || numbers[v.number]
+		numbers[v.number] = true
+	}
+	d.Lnf("// %s", e.GoType)
+	d.Lnf("enum %s {", e.Name)
+	if alias {
+		d.Ln(tab + "option allow_alias = true;")
+	}
+	d.Lnf(tab+"%s = 0;", zero.name)
+	for _, v := range values {
+		d.Lnf(tab+"%s = %d;", v.name, v.number)
+	}
+	if nums, names := t.lock.reserved(e.Name); len(nums) > 0 {
+		d.Lnf(tab+"reserved %s;", nums)
+		d.Lnf(tab+"reserved %s;", names)
+	}
+	d.Ln("}")
+}
+
+// Checks, that values of enum can be declared in protobuf.
+func checkProtoEnum(e *protoEnum) error {
+	for _, v := range e.Values {
+		if v.Value.Kind() != constant.Int {
+			continue
+		}
+		if n, ok := constant.Int64Val(v.Value); !ok || n < -1<<31 || n >= 1<<31 {
+			return fmt.Errorf("value of %s is out of range of protobuf enum %s", v.Name, e.Name)
+		}
+		if n, _ := constant.Int64Val(v.Value); n != 0 && protoEnumValueName(e.Name, v.Name) == protoEnumZeroName(e.Name) {
+			return fmt.Errorf("%s is reserved for zero value of protobuf enum %s", v.Name, e.Name)
+		}
+	}
+	return nil
+}
+
+// Keys of protobuf maps can not be enums, so maps with enum keys use underlying type of enum for keys.
+//
+//		map[Status]int -> map<string, int64>
+//
+func enumMapKey(info *GenerationInfo, key types.Type) types.Type {
+	if !isEnumType(info, key) {
+		return key
+	}
+	_, named := sourceDeclaration(info, key)
+	if named == nil {
+		return key
+	}
+	return qualifiedType(key, named.Type)
+}
+
+// Returns go value of map key, converted to underlying type, when key is enum.
+func enumMapKeyValue(ctx context.Context, info *GenerationInfo, key types.Type, value *Statement) *Statement {
+	if !isEnumType(info, key) {
+		return value
+	}
+	return fieldType(ctx, enumMapKey(info, key), false).Call(value)
+}
@@ -0,0 +1,232 @@
+package template
+
+import (
+	"fmt"
+	"strings"
+
+	. "github.com/dave/jennifer/jen"
+	mstrings "github.com/devimteam/microgen/generator/strings"
+	"github.com/vetcher/go-astra/types"
+)
+
+const GrpcErrorTag = "grpc-error"
+
+// Names of grpc status codes from google.golang.org/grpc/codes.
+var grpcCodes = []string{
+	"OK", "Canceled", "Unknown", "InvalidArgument", "DeadlineExceeded", "NotFound", "AlreadyExists",
+	"PermissionDenied", "ResourceExhausted", "FailedPrecondition", "Aborted", "OutOfRange",
+	"Unimplemented", "Internal", "Unavailable", "DataLoss", "Unauthenticated",
+}
+
+// GRPCError is a mapping of error to grpc status code, declared by @grpc-error tag of interface or method.
+// Error is a name of variable from source package or qualified by name of package, imported by source file.
+//
+//		// @grpc-error ErrNotFound=NotFound entity.ErrExists=AlreadyExists
+//
+type GRPCError struct {
+	Error string
+	Code  string
+}
+
+// ParseGRPCErrors returns mappings, declared by @grpc-error tags.
+func ParseGRPCErrors(docs []string) ([]GRPCError, error) {
+	var res []GRPCError
+	for _, doc := range docs {
+		if !strings.HasPrefix(doc, TagMark+GrpcErrorTag+" ") {
+			continue
+		}
+		for _, field := range strings.Fields(strings.TrimPrefix(doc, TagMark+GrpcErrorTag)) {
+			kv := strings.SplitN(field, "=", 2)
+			if len(kv) != 2 || kv[0] == "" {
+				return nil, fmt.Errorf("@%s: %s should be in form of Error=Code", GrpcErrorTag, field)
+			}
+			if !mstrings.IsInStringSlice(kv[1], grpcCodes) {
+				return nil, fmt.Errorf("@%s: %s is not grpc status code", GrpcErrorTag, kv[1])
+			}
+			res = append(res, GRPCError{Error: kv[0], Code: kv[1]})
+		}
+	}
+	return res, nil
+}
+
+// Returns mappings of method. Mappings of interface are overridden by mappings of method for the same errors.
+func methodGRPCErrors(iface *types.Interface, fn *types.Function) []GRPCError {
+	res, _ := ParseGRPCErrors(iface.Docs)
+	own, _ := ParseGRPCErrors(fn.Docs)
+Own:
+	for _, e := range own {
+		for i := range res {
+			if res[i].Error == e.Error {
+				res[i] = e
+				continue Own
+			}
+		}
+		res = append(res, e)
+	}
+	return res
+}
+
+// Checks, that all errors of allowed methods can be resolved.
+func prepareGRPCErrors(info *GenerationInfo) error {
+	for _, fn := range info.Iface.Methods {
+		if !info.AllowedMethods[fn.Name] {
+			continue
+		}
+		for _, e := range methodGRPCErrors(info.Iface, fn) {
+			if _, err := sourceValue(info, e.Error); err != nil {
+				return fmt.Errorf("@%s: %v", GrpcErrorTag, err)
+			}
+		}
+	}
+	return nil
+}
+
+func encodeErrorName(fn *types.Function) string {
+	return "_Encode_" + fn.Name + "_Error"
+}
+
+func decodeErrorName(fn *types.Function) string {
+	return "_Decode_" + fn.Name + "_Error"
+}
+
+// Renders endpoint of server, which replaces errors with grpc statuses, when method has mappings.
+//
+//		encodeGRPCErrors(endpoints.CountEndpoint, _Encode_Count_Error)
+//
+func grpcServerEndpoint(info *GenerationInfo, fn *types.Function) *Statement {
+	e := Id("endpoints").Dot(endpointsStructFieldName(fn.Name))
+	if len(methodGRPCErrors(info.Iface, fn)) == 0 {
+		return e
+	}
+	return Id("encodeGRPCErrors").Call(e, Id(encodeErrorName(fn)))
+}
+
+// Renders error encoders for methods with mappings and endpoint wrapper, which applies them.
+//
+//		func _Encode_Count_Error(err error) error {
+//			switch err {
+//			case service.ErrNotFound:
+//				return status.Error(codes.NotFound, err.Error())
+//			}
+//			return err
+//		}
+//
+//		func encodeGRPCErrors(e endpoint.Endpoint, encode func(error) error) endpoint.Endpoint {
+//			return func(ctx context.Context, request interface{}) (interface{}, error) {
+//				response, err := e(ctx, request)
+//				if err != nil {
+//					return response, encode(err)
+//				}
+//				return response, nil
+//			}
+//		}
+//
+func grpcErrorEncoders(info *GenerationInfo) *Statement {
+	s := &Statement{}
+	for _, fn := range info.Iface.Methods {
+		errs := methodGRPCErrors(info.Iface, fn)
+		if !info.AllowedMethods[fn.Name] || len(errs) == 0 {
+			continue
+		}
+		s.Comment("Replaces errors of " + fn.Name + " with grpc statuses.").Line()
+		s.Func().Id(encodeErrorName(fn)).Params(Err().Error()).Error().Block(
+			Switch(Err()).BlockFunc(func(g *Group) {
+				for _, e := range errs {
+					value, _ := sourceValue(info, e.Error)
+					g.Case(value).Block(
+						Return(Qual(PackagePathGoogleGRPCStatus, "Error").Call(Qual(PackagePathGoogleGRPCCodes, e.Code), Err().Dot("Error").Call())),
+					)
+				}
+			}),
+			Return(Err()),
+		).Line().Line()
+	}
+	if len(*s) == 0 {
+		return s
+	}
+	s.Func().Id("encodeGRPCErrors").Params(
+		Id("e").Qual(PackagePathGoKitEndpoint, "Endpoint"),
+		Id("encode").Func().Params(Error()).Error(),
+	).Qual(PackagePathGoKitEndpoint, "Endpoint").Block(
+		Return(Func().Params(Id("ctx").Qual(PackagePathContext, "Context"), Id("request").Interface()).Params(Interface(), Error()).Block(
+			List(Id("response"), Err()).Op(":=").Id("e").Call(Id("ctx"), Id("request")),
+			If(Err().Op("!=").Nil()).Block(
+				Return(Id("response"), Id("encode").Call(Err())),
+			),
+			Return(Id("response"), Nil()),
+		)),
+	)
+	return s
+}
+
+// Renders endpoint of client, which restores errors from grpc statuses, when method has mappings.
+//
+//		decodeGRPCErrors(grpckit.NewClient(...).Endpoint(), _Decode_Count_Error)
+//
+func grpcClientEndpoint(info *GenerationInfo, fn *types.Function, e *Statement) *Statement {
+	if len(methodGRPCErrors(info.Iface, fn)) == 0 {
+		return e
+	}
+	return Id("decodeGRPCErrors").Call(e, Id(decodeErrorName(fn)))
+}
+
+// Renders error decoders for methods with mappings and endpoint wrapper, which applies them.
+// Errors with the same code are distinguished by messages.
+//
+//		func _Decode_Count_Error(err error) error {
+//			st, ok := status.FromError(err)
+//			if !ok {
+//				return err
+//			}
+//			switch {
+//			case st.Code() == codes.NotFound:
+//				return service.ErrNotFound
+//			}
+//			return err
+//		}
+//
+func grpcErrorDecoders(info *GenerationInfo) *Statement {
+	s := &Statement{}
+	for _, fn := range info.Iface.Methods {
+		errs := methodGRPCErrors(info.Iface, fn)
+		if !info.AllowedMethods[fn.Name] || len(errs) == 0 {
+			continue
+		}
+		codes := make(map[string]int)
+		for _, e := range errs {
+			codes[e.Code]++
+		}
+		s.Comment("Restores errors of " + fn.Name + " from grpc statuses.").Line()
+		s.Func().Id(decodeErrorName(fn)).Params(Err().Error()).Error().Block(
+			List(Id("st"), Id("ok")).Op(":=").Qual(PackagePathGoogleGRPCStatus, "FromError").Call(Err()),
+			If(Op("!").Id("ok")).Block(Return(Err())),
+			Switch().BlockFunc(func(g *Group) {
+				for _, e := range errs {
+					value, _ := sourceValue(info, e.Error)
+					cond := Id("st").Dot("Code").Call().Op("==").Qual(PackagePathGoogleGRPCCodes, e.Code)
+					if codes[e.Code] > 1 {
+						cond.Op("&&").Id("st").Dot("Message").Call().Op("==").Add(value.Clone()).Dot("Error").Call()
+					}
+					g.Case(cond).Block(Return(value))
+				}
+			}),
+			Return(Err()),
+		).Line().Line()
+	}
+	if len(*s) == 0 {
+		return s
+	}
+	s.Func().Id("decodeGRPCErrors").Params(
+		Id("e").Qual(PackagePathGoKitEndpoint, "Endpoint"),
+		Id("decode").Func().Params(Error()).Error(),
+	).Qual(PackagePathGoKitEndpoint, "Endpoint").Block(
+		Return(Func().Params(Id("ctx").Qual(PackagePathContext, "Context"), Id("request").Interface()).Params(Interface(), Error()).Block(
+			List(Id("response"), Err()).Op(":=").Id("e").Call(Id("ctx"), Id("request")),
+			If(Err().Op("!=").Nil()).Block(
+				Return(Id("response"), Id("decode").Call(Err())),
+			),
+			Return(Id("response"), Nil()),
+		)),
+	)
+	return s
+}
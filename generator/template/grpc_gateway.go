@@ -0,0 +1,384 @@
+package template
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	. "github.com/dave/jennifer/jen"
+	mstrings "github.com/devimteam/microgen/generator/strings"
+	"github.com/devimteam/microgen/generator/write_strategy"
+	"github.com/vetcher/go-astra/types"
+)
+
+const (
+	PackagePathGolangProtobufJsonpb = "github.com/golang/protobuf/jsonpb"
+	PackagePathGolangProtobufProto  = "github.com/golang/protobuf/proto"
+
+	importGoogleAPIAnnotations = "google/api/annotations.proto"
+)
+
+// Methods with @http-method or @http-path tags are served by grpc gateway.
+// Streaming methods are not.
+func hasGatewayRoute(fn *types.Function) bool {
+	if methodStreams(fn).IsStream() {
+		return false
+	}
+	return mstrings.ContainTag(fn.Docs, TagMark+HttpMethodTag) || fetchHttpPath(fn.Docs) != ""
+}
+
+// Methods without body receive arguments from path and query.
+func gatewayHasBody(httpMethod string) bool {
+	return httpMethod != "GET" && httpMethod != "DELETE"
+}
+
+// Returns true, when request of method is a message of type mapping or empty message,
+// so its fields are not the arguments of method.
+func gatewaySpecialRequest(fn *types.Function) bool {
+	_, externalImport := protoMessageName(grpcRequestParams(fn), requestStructName(fn))
+	return externalImport != nil
+}
+
+// Returns lines of google.api.http option of rpc. Path variables become fields of request,
+// variables without arguments and variables inside of segments are matched by wildcard.
+//
+//		option (google.api.http) = {
+//		    get: "/comments/{comment_id}/*"
+//		};
+//
+func googleAPIHTTPOption(fn *types.Function) []string {
+	method := FetchHttpMethodTag(fn.Docs)
+	vars := MethodPathVars(fn)
+	special := gatewaySpecialRequest(fn)
+	var segments []string
+	for _, segment := range strings.Split(buildMethodPath(fn), "/") {
+		names := pathTemplateVars(segment)
+		if len(names) == 0 {
+			segments = append(segments, segment)
+			continue
+		}
+		whole := len(names) == 1 && strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}")
+		segment = "*"
+		for _, v := range vars {
+			if whole && !special && v.Name == names[0] && v.Arg != nil {
+				segment = "{" + mstrings.ToSnakeCase(v.Arg.Name) + "}"
+			}
+		}
+		segments = append(segments, segment)
+	}
+	url := "/" + strings.Join(segments, "/")
+	lines := []string{"option (google.api.http) = {"}
+	switch method {
+	case "GET", "PUT", "POST", "DELETE", "PATCH":
+		lines = append(lines, fmt.Sprintf(tab+"%s: %q", strings.ToLower(method), url))
+	default:
+		lines = append(lines, fmt.Sprintf(tab+"custom: {kind: %q path: %q}", method, url))
+	}
+	if gatewayHasBody(method) {
+		lines = append(lines, tab+`body: "*"`)
+	}
+	return append(lines, "};")
+}
+
+type gRPCGatewayTemplate struct {
+	info *GenerationInfo
+}
+
+func NewGRPCGatewayTemplate(info *GenerationInfo) Template {
+	return &gRPCGatewayTemplate{
+		info: info,
+	}
+}
+
+// Render http handler, which calls grpc server in the same process.
+//
+//		// NewGRPCGateway returns http handler, which translates requests to calls of grpc server,
+//		// as described by google.api.http options of service.proto.
+//		func NewGRPCGateway(server pb.CommentServiceServer) http.Handler {
+//			mux := mux.NewRouter()
+//			mux.Methods("GET").Path("/comments/{id}").Handler(_Get_Gateway(server))
+//			return mux
+//		}
+//
+func (t *gRPCGatewayTemplate) Render(ctx context.Context) write_strategy.Renderer {
+	f := NewFile("transportgrpc")
+	f.ImportAlias(t.info.ProtobufPackageImport, "pb")
+	f.HeaderComment(t.info.FileHeader)
+
+	f.Comment("NewGRPCGateway returns http handler, which translates requests to calls of grpc server,").Line().
+		Comment("as described by google.api.http options of service.proto.").Line().
+		Comment("JSON body, path and query parameters are fields of request, http headers are incoming grpc metadata.")
+	f.Func().Id("NewGRPCGateway").Params(
+		Id("server").Qual(t.info.ProtobufPackageImport, t.info.Iface.Name+"Server"),
+	).Qual(PackagePathHttp, "Handler").BlockFunc(func(g *Group) {
+		g.Id("mux").Op(":=").Qual(PackagePathGorillaMux, "NewRouter").Call()
+		for _, fn := range t.info.Iface.Methods {
+			if !t.info.AllowedMethods[fn.Name] || !hasGatewayRoute(fn) {
+				continue
+			}
+			g.Id("mux").Dot("Methods").Call(Lit(FetchHttpMethodTag(fn.Docs))).Dot("Path").
+				Call(Lit("/" + buildMethodPath(fn))).Dot("Handler").Call(Id(gatewayHandlerName(fn)).Call(Id("server")))
+		}
+		g.Return(Id("mux"))
+	})
+
+	for _, fn := range t.info.Iface.Methods {
+		if !t.info.AllowedMethods[fn.Name] || !hasGatewayRoute(fn) {
+			continue
+		}
+		f.Line().Add(t.handler(fn))
+	}
+	f.Line().Add(gatewayHelpers())
+	return f
+}
+
+func gatewayHandlerName(fn *types.Function) string {
+	return "_" + fn.Name + "_Gateway"
+}
+
+// Returns type of request message without pointer.
+func (t *gRPCGatewayTemplate) requestType(fn *types.Function) *Statement {
+	args := grpcRequestParams(fn)
+	if len(args) == 0 {
+		return Qual(PackagePathEmptyProtobuf, "Empty")
+	}
+	if len(args) == 1 {
+		if m := lookupTypeMapping(args[0].Type); m != nil && m.isMessage() {
+			return goTypeCode(strings.TrimPrefix(m.ProtoGoType, "*"))
+		}
+	}
+	return Qual(t.info.ProtobufPackageImport, requestStructName(fn))
+}
+
+// Render handler of method.
+//
+//		func _Get_Gateway(server pb.CommentServiceServer) http.HandlerFunc {
+//			return func(w http.ResponseWriter, r *http.Request) {
+//				req := &pb.GetRequest{}
+//				fields := make(map[string]json.RawMessage)
+//				vars := mux.Vars(r)
+//				fields["id"] = gatewayValue(vars["id"], false)
+//				query := r.URL.Query()
+//				if v, ok := query["verbose"]; ok {
+//					fields["verbose"] = gatewayValue(v[0], true)
+//				}
+//				if err := gatewayUnmarshal(fields, req); err != nil {
+//					writeGatewayError(w, status.Error(codes.InvalidArgument, err.Error()))
+//					return
+//				}
+//				resp, err := server.Get(gatewayContext(r), req)
+//				if err != nil {
+//					writeGatewayError(w, err)
+//					return
+//				}
+//				writeGatewayResponse(w, resp)
+//			}
+//		}
+//
+func (t *gRPCGatewayTemplate) handler(fn *types.Function) *Statement {
+	method := FetchHttpMethodTag(fn.Docs)
+	args := grpcRequestParams(fn)
+	vars := MethodPathVars(fn)
+	badRequest := Id("writeGatewayError").Call(Id("w"), Qual(PackagePathGoogleGRPCStatus, "Error").Call(
+		Qual(PackagePathGoogleGRPCCodes, "InvalidArgument"), Err().Dot("Error").Call(),
+	)).Line().Return()
+	return Func().Id(gatewayHandlerName(fn)).Params(
+		Id("server").Qual(t.info.ProtobufPackageImport, t.info.Iface.Name+"Server"),
+	).Qual(PackagePathHttp, "HandlerFunc").Block(
+		Return(Func().Params(
+			Id("w").Qual(PackagePathHttp, "ResponseWriter"),
+			Id("r").Op("*").Qual(PackagePathHttp, "Request"),
+		).BlockFunc(func(g *Group) {
+			g.Id("req").Op(":=").Op("&").Add(t.requestType(fn)).Values()
+			switch {
+			case len(args) == 0:
+			case gatewaySpecialRequest(fn):
+				if gatewayHasBody(method) {
+					g.If(
+						Err().Op(":=").Qual(PackagePathGolangProtobufJsonpb, "Unmarshal").Call(Id("r").Dot("Body"), Id("req")),
+						Err().Op("!=").Nil().Op("&&").Err().Op("!=").Qual(PackagePathIO, "EOF"),
+					).Block(badRequest.Clone())
+				}
+			default:
+				g.Id("fields").Op(":=").Make(Map(String()).Qual(PackagePathJson, "RawMessage"))
+				if gatewayHasBody(method) {
+					g.If(
+						Err().Op(":=").Qual(PackagePathJson, "NewDecoder").Call(Id("r").Dot("Body")).Dot("Decode").Call(Op("&").Id("fields")),
+						Err().Op("!=").Nil().Op("&&").Err().Op("!=").Qual(PackagePathIO, "EOF"),
+					).Block(badRequest.Clone())
+				}
+				var inPath []string
+				for _, v := range vars {
+					if v.Arg != nil {
+						inPath = append(inPath, v.Arg.Name)
+					}
+				}
+				if len(inPath) > 0 {
+					g.Id("vars").Op(":=").Qual(PackagePathGorillaMux, "Vars").Call(Id("r"))
+					for _, v := range vars {
+						if v.Arg == nil {
+							continue
+						}
+						g.Id("fields").Index(Lit(mstrings.ToSnakeCase(v.Arg.Name))).Op("=").
+							Id("gatewayValue").Call(Id("vars").Index(Lit(v.Name)), Lit(isBoolType(v.Arg.Type)))
+					}
+				}
+				if !gatewayHasBody(method) && len(inPath) < len(args) {
+					g.Id("query").Op(":=").Id("r").Dot("URL").Dot("Query").Call()
+					for _, arg := range args {
+						if mstrings.IsInStringSlice(arg.Name, inPath) {
+							continue
+						}
+						name := mstrings.ToSnakeCase(arg.Name)
+						value := Id("gatewayValue").Call(Id("v").Index(Lit(0)), Lit(isBoolType(arg.Type)))
+						if elem, ok := sliceElem(arg.Type); ok && arg.Type.String() != "[]byte" {
+							value = Id("gatewayValues").Call(Id("v"), Lit(isBoolType(elem)))
+						}
+						g.If(List(Id("v"), Id("ok")).Op(":=").Id("query").Index(Lit(httpQueryName(&arg))), Id("ok")).Block(
+							Id("fields").Index(Lit(name)).Op("=").Add(value),
+						)
+					}
+				}
+				g.If(
+					Err().Op(":=").Id("gatewayUnmarshal").Call(Id("fields"), Id("req")),
+					Err().Op("!=").Nil(),
+				).Block(badRequest.Clone())
+			}
+			g.List(Id("resp"), Err()).Op(":=").Id("server").Dot(fn.Name).Call(Id("gatewayContext").Call(Id("r")), Id("req"))
+			g.If(Err().Op("!=").Nil()).Block(
+				Id("writeGatewayError").Call(Id("w"), Err()),
+				Return(),
+			)
+			g.Id("writeGatewayResponse").Call(Id("w"), Id("resp"))
+		})),
+	)
+}
+
+func isBoolType(t types.Type) bool {
+	return t.String() == "bool"
+}
+
+// Returns element of slice type.
+func sliceElem(t types.Type) (types.Type, bool) {
+	if arr, ok := t.(types.TArray); ok && arr.IsSlice {
+		return arr.Next, true
+	}
+	return nil, false
+}
+
+// Http statuses for grpc codes, as in grpc-gateway.
+var gatewayHTTPStatuses = []struct{ code, status string }{
+	{"OK", "StatusOK"},
+	{"Canceled", "StatusRequestTimeout"},
+	{"InvalidArgument", "StatusBadRequest"},
+	{"DeadlineExceeded", "StatusGatewayTimeout"},
+	{"NotFound", "StatusNotFound"},
+	{"AlreadyExists", "StatusConflict"},
+	{"PermissionDenied", "StatusForbidden"},
+	{"Unauthenticated", "StatusUnauthorized"},
+	{"ResourceExhausted", "StatusTooManyRequests"},
+	{"FailedPrecondition", "StatusBadRequest"},
+	{"Aborted", "StatusConflict"},
+	{"OutOfRange", "StatusBadRequest"},
+	{"Unimplemented", "StatusNotImplemented"},
+	{"Unavailable", "StatusServiceUnavailable"},
+}
+
+// Render helpers of gateway handlers.
+//
+//		// Returns json value of parameter. Numbers are quoted too, jsonpb accepts them as strings.
+//		func gatewayValue(value string, boolean bool) json.RawMessage {...}
+//
+//		// Returns context of request with http headers as incoming grpc metadata.
+//		func gatewayContext(r *http.Request) context.Context {...}
+//
+func gatewayHelpers() *Statement {
+	s := &Statement{}
+	s.Comment("Returns json value of parameter. Numbers are quoted too, jsonpb accepts them as strings.").Line()
+	s.Func().Id("gatewayValue").Params(Id("value").String(), Id("boolean").Bool()).Qual(PackagePathJson, "RawMessage").Block(
+		If(Id("boolean").Op("&&").Parens(Id("value").Op("==").Lit("true").Op("||").Id("value").Op("==").Lit("false"))).Block(
+			Return(Qual(PackagePathJson, "RawMessage").Call(Id("value"))),
+		),
+		Return(Qual(PackagePathJson, "RawMessage").Call(Qual(PackagePathStrconv, "Quote").Call(Id("value")))),
+	).Line().Line()
+
+	s.Func().Id("gatewayValues").Params(Id("values").Index().String(), Id("boolean").Bool()).Qual(PackagePathJson, "RawMessage").Block(
+		Id("list").Op(":=").Make(Index().Qual(PackagePathJson, "RawMessage"), Len(Id("values"))),
+		For(List(Id("i"), Id("v")).Op(":=").Range().Id("values")).Block(
+			Id("list").Index(Id("i")).Op("=").Id("gatewayValue").Call(Id("v"), Id("boolean")),
+		),
+		List(Id("data"), Id("_")).Op(":=").Qual(PackagePathJson, "Marshal").Call(Id("list")),
+		Return(Id("data")),
+	).Line().Line()
+
+	s.Func().Id("gatewayUnmarshal").Params(
+		Id("fields").Map(String()).Qual(PackagePathJson, "RawMessage"),
+		Id("msg").Qual(PackagePathGolangProtobufProto, "Message"),
+	).Error().Block(
+		List(Id("data"), Err()).Op(":=").Qual(PackagePathJson, "Marshal").Call(Id("fields")),
+		If(Err().Op("!=").Nil()).Block(Return(Err())),
+		Return(Qual(PackagePathGolangProtobufJsonpb, "Unmarshal").Call(Qual(PackagePathBytes, "NewReader").Call(Id("data")), Id("msg"))),
+	).Line().Line()
+
+	s.Comment("Returns context of request with http headers as incoming grpc metadata.").Line()
+	s.Func().Id("gatewayContext").Params(Id("r").Op("*").Qual(PackagePathHttp, "Request")).Qual(PackagePathContext, "Context").Block(
+		Id("md").Op(":=").Qual(PackagePathGoogleGRPCMetadata, "MD").Values(),
+		For(List(Id("name"), Id("values")).Op(":=").Range().Id("r").Dot("Header")).Block(
+			Id("md").Dot("Append").Call(Id("name"), Id("values").Op("...")),
+		),
+		Return(Qual(PackagePathGoogleGRPCMetadata, "NewIncomingContext").Call(Id("r").Dot("Context").Call(), Id("md"))),
+	).Line().Line()
+
+	s.Func().Id("writeGatewayResponse").Params(
+		Id("w").Qual(PackagePathHttp, "ResponseWriter"),
+		Id("resp").Qual(PackagePathGolangProtobufProto, "Message"),
+	).Block(
+		List(Id("data"), Err()).Op(":=").Parens(Op("&").Qual(PackagePathGolangProtobufJsonpb, "Marshaler").Values()).Dot("MarshalToString").Call(Id("resp")),
+		If(Err().Op("!=").Nil()).Block(
+			Id("writeGatewayError").Call(Id("w"), Err()),
+			Return(),
+		),
+		Id("w").Dot("Header").Call().Dot("Set").Call(Lit("Content-Type"), Lit("application/json")),
+		Qual(PackagePathIO, "WriteString").Call(Id("w"), Id("data")),
+	).Line().Line()
+
+	s.Comment("Writes status of error with http status, which corresponds to grpc code.").Line()
+	s.Func().Id("writeGatewayError").Params(Id("w").Qual(PackagePathHttp, "ResponseWriter"), Err().Error()).Block(
+		List(Id("st"), Id("_")).Op(":=").Qual(PackagePathGoogleGRPCStatus, "FromError").Call(Err()),
+		Id("code").Op(":=").Qual(PackagePathHttp, "StatusInternalServerError"),
+		Switch(Id("st").Dot("Code").Call()).BlockFunc(func(g *Group) {
+			for _, s := range gatewayHTTPStatuses {
+				g.Case(Qual(PackagePathGoogleGRPCCodes, s.code)).Block(
+					Id("code").Op("=").Qual(PackagePathHttp, s.status),
+				)
+			}
+		}),
+		Id("w").Dot("Header").Call().Dot("Set").Call(Lit("Content-Type"), Lit("application/json")),
+		Id("w").Dot("WriteHeader").Call(Id("code")),
+		Qual(PackagePathJson, "NewEncoder").Call(Id("w")).Dot("Encode").Call(Map(String()).Interface().Values(Dict{
+			Lit("code"):    Id("st").Dot("Code").Call(),
+			Lit("message"): Id("st").Dot("Message").Call(),
+		})),
+	)
+	return s
+}
+
+func (gRPCGatewayTemplate) DefaultPath() string {
+	return filenameBuilder(PathTransport, "grpc", "gateway")
+}
+
+func (t *gRPCGatewayTemplate) Prepare(ctx context.Context) error {
+	if t.info.ProtobufPackageImport == "" {
+		return ErrProtobufEmpty
+	}
+	return nil
+}
+
+func (t *gRPCGatewayTemplate) ChooseStrategy(ctx context.Context) (write_strategy.Strategy, error) {
+	for _, fn := range t.info.Iface.Methods {
+		if t.info.AllowedMethods[fn.Name] && hasGatewayRoute(fn) {
+			return write_strategy.NewCreateFileStrategy(t.info.OutputFilePath, t.DefaultPath()), nil
+		}
+	}
+	return write_strategy.NewNopStrategy("", ""), nil
+}
@@ -0,0 +1,278 @@
+package template
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	. "github.com/dave/jennifer/jen"
+	mstrings "github.com/devimteam/microgen/generator/strings"
+	"github.com/devimteam/microgen/generator/write_strategy"
+	"github.com/vetcher/go-astra/types"
+)
+
+const (
+	GrpcMetadataTag = "grpc-metadata"
+
+	PackagePathGoogleGRPCMetadata = "google.golang.org/grpc/metadata"
+)
+
+// GRPCMetadata is a mapping of grpc metadata key to context key, declared by @grpc-metadata tag of interface or method.
+// Context key is a name of variable or constant from source package or qualified by name of package, imported by source file.
+// Values are strings in context as in metadata.
+//
+//		// @grpc-metadata x-tenant-id=TenantKey x-request-id=ctxkeys.RequestID
+//
+type GRPCMetadata struct {
+	Metadata string
+	Key      string
+}
+
+var grpcMetadataKeyRegexp = regexp.MustCompile(`^[0-9a-z_.\-]+$`)
+
+// ParseGRPCMetadata returns mappings, declared by @grpc-metadata tags.
+func ParseGRPCMetadata(docs []string) ([]GRPCMetadata, error) {
+	var res []GRPCMetadata
+	for _, doc := range docs {
+		if !strings.HasPrefix(doc, TagMark+GrpcMetadataTag+" ") {
+			continue
+		}
+		for _, field := range strings.Fields(strings.TrimPrefix(doc, TagMark+GrpcMetadataTag)) {
+			kv := strings.SplitN(field, "=", 2)
+			if len(kv) != 2 || kv[1] == "" {
+				return nil, fmt.Errorf("@%s: %s should be in form of metadata-key=ContextKey", GrpcMetadataTag, field)
+			}
+			if !grpcMetadataKeyRegexp.MatchString(kv[0]) || strings.HasSuffix(kv[0], "-bin") || strings.HasPrefix(kv[0], "grpc-") {
+				return nil, fmt.Errorf("@%s: %s is not allowed as metadata key, use lower case ascii key without grpc- prefix and -bin suffix", GrpcMetadataTag, kv[0])
+			}
+			res = append(res, GRPCMetadata{Metadata: kv[0], Key: kv[1]})
+		}
+	}
+	return res, nil
+}
+
+// Returns mappings of method: mappings of interface and of method itself.
+func methodGRPCMetadata(iface *types.Interface, fn *types.Function) []GRPCMetadata {
+	res, _ := ParseGRPCMetadata(iface.Docs)
+	own, _ := ParseGRPCMetadata(fn.Docs)
+Own:
+	for _, m := range own {
+		for i := range res {
+			if res[i].Metadata == m.Metadata {
+				res[i] = m
+				continue Own
+			}
+		}
+		res = append(res, m)
+	}
+	return res
+}
+
+// Returns all mappings of allowed methods, each mapping once.
+func allGRPCMetadata(info *GenerationInfo) (res []GRPCMetadata) {
+	seen := make(map[GRPCMetadata]bool)
+	for _, fn := range info.Iface.Methods {
+		if !info.AllowedMethods[fn.Name] {
+			continue
+		}
+		for _, m := range methodGRPCMetadata(info.Iface, fn) {
+			if !seen[m] {
+				seen[m] = true
+				res = append(res, m)
+			}
+		}
+	}
+	return res
+}
+
+// Name of value for helpers: name of context key without package and Key suffix.
+//
+//		ctxkeys.TenantKey -> Tenant
+//
+func (m GRPCMetadata) valueName() string {
+	name := m.Key[strings.LastIndex(m.Key, ".")+1:]
+	if trimmed := strings.TrimSuffix(name, "Key"); trimmed != "" {
+		name = trimmed
+	}
+	return mstrings.ToUpperFirst(name)
+}
+
+func (m GRPCMetadata) toContextName() string {
+	return "_" + m.valueName() + "_Metadata_To_Context"
+}
+
+func (m GRPCMetadata) toMetadataName() string {
+	return "_" + m.valueName() + "_Context_To_Metadata"
+}
+
+// Renders grpc server options, which copy metadata of method to context.
+//
+//		grpckit.ServerBefore(_Tenant_Metadata_To_Context)
+//
+func grpcMetadataServerOptions(info *GenerationInfo, fn *types.Function) (opts []Code) {
+	for _, m := range methodGRPCMetadata(info.Iface, fn) {
+		opts = append(opts, Qual(PackagePathGoKitTransportGRPC, "ServerBefore").Call(Id(m.toContextName())))
+	}
+	return opts
+}
+
+// Renders grpc client options, which copy values of context to metadata of method.
+//
+//		grpckit.ClientBefore(_Tenant_Context_To_Metadata)
+//
+func grpcMetadataClientOptions(info *GenerationInfo, fn *types.Function) (opts []Code) {
+	for _, m := range methodGRPCMetadata(info.Iface, fn) {
+		opts = append(opts, Qual(PackagePathGoKitTransportGRPC, "ClientBefore").Call(Id(m.toMetadataName())))
+	}
+	return opts
+}
+
+// Renders copying of incoming metadata to context for methods, which are not served by grpc kit server.
+//
+//		if md, ok := metadata.FromIncomingContext(ctx); ok {
+//			ctx = _Tenant_Metadata_To_Context(ctx, md)
+//		}
+//
+func grpcMetadataFromIncoming(info *GenerationInfo, fn *types.Function) *Statement {
+	mds := methodGRPCMetadata(info.Iface, fn)
+	if len(mds) == 0 {
+		return Null()
+	}
+	return If(List(Id("md"), Id("ok")).Op(":=").Qual(PackagePathGoogleGRPCMetadata, "FromIncomingContext").Call(Id("ctx")), Id("ok")).BlockFunc(func(g *Group) {
+		for _, m := range mds {
+			g.Id("ctx").Op("=").Id(m.toContextName()).Call(Id("ctx"), Id("md"))
+		}
+	})
+}
+
+// Renders copying of context values to outgoing metadata for methods, which are not called by grpc kit client.
+//
+//		md := metadata.MD{}
+//		ctx = _Tenant_Context_To_Metadata(ctx, &md)
+//		ctx = metadata.NewOutgoingContext(ctx, md)
+//
+func grpcMetadataToOutgoing(info *GenerationInfo, fn *types.Function) *Statement {
+	mds := methodGRPCMetadata(info.Iface, fn)
+	if len(mds) == 0 {
+		return Null()
+	}
+	s := Id("md").Op(":=").Qual(PackagePathGoogleGRPCMetadata, "MD").Values().Line()
+	for _, m := range mds {
+		s.Id("ctx").Op("=").Id(m.toMetadataName()).Call(Id("ctx"), Op("&").Id("md")).Line()
+	}
+	return s.Id("ctx").Op("=").Qual(PackagePathGoogleGRPCMetadata, "NewOutgoingContext").Call(Id("ctx"), Id("md"))
+}
+
+type gRPCMetadataTemplate struct {
+	info     *GenerationInfo
+	metadata []GRPCMetadata
+}
+
+func NewGRPCMetadataTemplate(info *GenerationInfo) Template {
+	return &gRPCMetadataTemplate{
+		info: info,
+	}
+}
+
+// Render file with converters between grpc metadata and context and with accessors for values in context.
+//
+//		// Copies x-tenant-id grpc metadata to context by TenantKey.
+//		func _Tenant_Metadata_To_Context(ctx context.Context, md metadata.MD) context.Context {
+//			if v := md.Get("x-tenant-id"); len(v) > 0 {
+//				return context.WithValue(ctx, service.TenantKey, v[0])
+//			}
+//			return ctx
+//		}
+//
+//		// Copies value of TenantKey from context to x-tenant-id grpc metadata.
+//		func _Tenant_Context_To_Metadata(ctx context.Context, md *metadata.MD) context.Context {
+//			if v, ok := TenantFromContext(ctx); ok {
+//				md.Set("x-tenant-id", v)
+//			}
+//			return ctx
+//		}
+//
+//		// TenantFromContext returns value of x-tenant-id grpc metadata, which is stored in context by grpc server.
+//		func TenantFromContext(ctx context.Context) (string, bool) {
+//			v, ok := ctx.Value(service.TenantKey).(string)
+//			return v, ok
+//		}
+//
+//		// ContextWithTenant returns context with value, which is sent by grpc client as x-tenant-id metadata.
+//		func ContextWithTenant(ctx context.Context, value string) context.Context {
+//			return context.WithValue(ctx, service.TenantKey, value)
+//		}
+//
+func (t *gRPCMetadataTemplate) Render(ctx context.Context) write_strategy.Renderer {
+	f := NewFile("transportgrpc")
+	f.ImportAlias(t.info.SourcePackageImport, serviceAlias)
+	f.HeaderComment(t.info.FileHeader)
+
+	for _, m := range t.metadata {
+		key, _ := sourceValue(t.info, m.Key)
+		f.Comment(fmt.Sprintf("Copies %s grpc metadata to context by %s.", m.Metadata, m.Key))
+		f.Func().Id(m.toContextName()).Params(
+			Id("ctx").Qual(PackagePathContext, "Context"),
+			Id("md").Qual(PackagePathGoogleGRPCMetadata, "MD"),
+		).Qual(PackagePathContext, "Context").Block(
+			If(Id("v").Op(":=").Id("md").Dot("Get").Call(Lit(m.Metadata)), Len(Id("v")).Op(">").Lit(0)).Block(
+				Return(Qual(PackagePathContext, "WithValue").Call(Id("ctx"), key, Id("v").Index(Lit(0)))),
+			),
+			Return(Id("ctx")),
+		).Line()
+
+		f.Comment(fmt.Sprintf("Copies value of %s from context to %s grpc metadata.", m.Key, m.Metadata))
+		f.Func().Id(m.toMetadataName()).Params(
+			Id("ctx").Qual(PackagePathContext, "Context"),
+			Id("md").Op("*").Qual(PackagePathGoogleGRPCMetadata, "MD"),
+		).Qual(PackagePathContext, "Context").Block(
+			If(List(Id("v"), Id("ok")).Op(":=").Id(m.valueName()+"FromContext").Call(Id("ctx")), Id("ok")).Block(
+				Id("md").Dot("Set").Call(Lit(m.Metadata), Id("v")),
+			),
+			Return(Id("ctx")),
+		).Line()
+
+		f.Comment(fmt.Sprintf("%sFromContext returns value of %s grpc metadata, which is stored in context by grpc server.", m.valueName(), m.Metadata))
+		f.Func().Id(m.valueName()+"FromContext").Params(Id("ctx").Qual(PackagePathContext, "Context")).Params(String(), Bool()).Block(
+			List(Id("v"), Id("ok")).Op(":=").Id("ctx").Dot("Value").Call(key).Assert(String()),
+			Return(Id("v"), Id("ok")),
+		).Line()
+
+		f.Comment(fmt.Sprintf("ContextWith%s returns context with value, which is sent by grpc client as %s metadata.", m.valueName(), m.Metadata))
+		f.Func().Id("ContextWith"+m.valueName()).Params(
+			Id("ctx").Qual(PackagePathContext, "Context"),
+			Id("value").String(),
+		).Qual(PackagePathContext, "Context").Block(
+			Return(Qual(PackagePathContext, "WithValue").Call(Id("ctx"), key, Id("value"))),
+		).Line()
+	}
+
+	return f
+}
+
+func (gRPCMetadataTemplate) DefaultPath() string {
+	return filenameBuilder(PathTransport, "grpc", "metadata")
+}
+
+func (t *gRPCMetadataTemplate) Prepare(ctx context.Context) error {
+	t.metadata = allGRPCMetadata(t.info)
+	names := make(map[string]GRPCMetadata)
+	for _, m := range t.metadata {
+		if _, err := sourceValue(t.info, m.Key); err != nil {
+			return fmt.Errorf("@%s: %v", GrpcMetadataTag, err)
+		}
+		if prev, ok := names[m.valueName()]; ok && prev != m {
+			return fmt.Errorf("@%s: %s=%s and %s=%s have the same name of helpers %s", GrpcMetadataTag, prev.Metadata, prev.Key, m.Metadata, m.Key, m.valueName())
+		}
+		names[m.valueName()] = m
+	}
+	return nil
+}
+
+func (t *gRPCMetadataTemplate) ChooseStrategy(ctx context.Context) (write_strategy.Strategy, error) {
+	if len(t.metadata) == 0 {
+		return write_strategy.NewNopStrategy("", ""), nil
+	}
+	return write_strategy.NewCreateFileStrategy(t.info.OutputFilePath, t.DefaultPath()), nil
+}
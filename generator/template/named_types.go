@@ -0,0 +1,108 @@
+package template
+
+import (
+	"context"
+	"strings"
+
+	. "github.com/dave/jennifer/jen"
+	mstrings "github.com/devimteam/microgen/generator/strings"
+	"github.com/vetcher/go-astra/types"
+)
+
+const (
+	TypeNameTag = "type-name"
+)
+
+// Inline struct or function type of method parameter, that is declared as named type in transport package.
+type namedType struct {
+	Name string
+	// types.Struct or *types.Function
+	Inline types.Type
+}
+
+// Inline types are struct{...} and func(...) types, declared right in method signature.
+func IsInlineType(t types.Type) bool {
+	switch t.(type) {
+	case types.Struct, *types.Function:
+		return true
+	}
+	return false
+}
+
+// Returns name of named type for inline parameter type.
+// Name is method name with parameter name by default and may be overridden with tag.
+//
+//		// @type-name opts=SearchFilter
+//		Search(ctx context.Context, opts struct{ ... }) (...)
+//
+func namedTypeName(fn *types.Function, param *types.Variable) string {
+	for _, tag := range mstrings.FetchTags(fn.Docs, TagMark+TypeNameTag) {
+		if kv := strings.SplitN(tag, "=", 2); len(kv) == 2 && kv[0] == param.Name && kv[1] != "" {
+			return kv[1]
+		}
+	}
+	return fn.Name + mstrings.ToUpperFirst(param.Name)
+}
+
+// Returns all named types for inline parameter types of allowed methods in order of declaration.
+func namedTypes(info *GenerationInfo) (nn []namedType) {
+	for _, fn := range info.Iface.Methods {
+		if !info.AllowedMethods[fn.Name] {
+			continue
+		}
+		for _, param := range append(RemoveContextIfFirst(fn.Args), removeErrorIfLast(fn.Results)...) {
+			if IsInlineType(param.Type) {
+				nn = append(nn, namedType{Name: namedTypeName(fn, &param), Inline: param.Type})
+			}
+		}
+	}
+	return
+}
+
+// Returns copy of function, which inline parameter types are replaced by named types from transport package.
+// Values of named and inline types are assignable to each other, so transport uses them directly.
+func transportFunction(info *GenerationInfo, fn *types.Function) *types.Function {
+	newFunc := &types.Function{Base: fn.Base}
+	replace := func(params []types.Variable) (res []types.Variable) {
+		for _, param := range params {
+			if IsInlineType(param.Type) {
+				param.Type = types.TImport{
+					Import: &types.Import{Base: types.Base{Name: "transport"}, Package: info.OutputPackageImport + "/" + PathTransport},
+					Next:   types.TName{TypeName: namedTypeName(fn, &param)},
+				}
+			}
+			res = append(res, param)
+		}
+		return
+	}
+	newFunc.Args = replace(fn.Args)
+	newFunc.Results = replace(fn.Results)
+	return newFunc
+}
+
+// Returns named type, if variable has type of named type from transport package.
+func findNamedType(info *GenerationInfo, t types.Type) *namedType {
+	imp := types.TypeImport(t)
+	name := types.TypeName(t)
+	if imp == nil || name == nil || imp.Package != info.OutputPackageImport+"/"+PathTransport {
+		return nil
+	}
+	for _, n := range namedTypes(info) {
+		if n.Name == *name {
+			return &n
+		}
+	}
+	return nil
+}
+
+// Renders declaration of named type.
+// Fields and tags should be the same as in inline type, otherwise types are not assignable.
+//
+//		type SearchFilter struct {
+//			Query string `json:"query"`
+//			Limit int
+//		}
+//
+func namedTypeDeclaration(ctx context.Context, n namedType) *Statement {
+	return Type().Id(n.Name).Add(fieldType(ctx, n.Inline, false))
+}
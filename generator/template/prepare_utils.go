@@ -3,8 +3,11 @@ package template
 import (
 	"fmt"
 	"os"
+	"path"
 	"path/filepath"
+	"strings"
 
+	. "github.com/dave/jennifer/jen"
 	"github.com/vetcher/go-astra"
 	"github.com/vetcher/go-astra/types"
 )
@@ -16,11 +19,16 @@ func ParseFile(filename string) (*types.File, error) {
 var parsedCache = map[string]*types.File{}
 
 func parsePackage(path string) (*types.File, error) {
-	path = filepath.Dir(path)
+	return parsePackageDir(filepath.Dir(path))
+}
+
+// Constants are not parsed, because astra can not parse implicitly typed ones, e.g. iota enums.
+// Values of constants are evaluated by go/types.
+func parsePackageDir(path string) (*types.File, error) {
 	if file, ok := parsedCache[path]; ok {
 		return file, nil
 	}
-	files, err := astra.ParsePackage(path, astra.AllowAnyImportAliases)
+	files, err := astra.ParsePackage(path, astra.AllowAnyImportAliases|astra.IgnoreConstants)
 	if err != nil {
 		return nil, err
 	}
@@ -32,6 +40,105 @@ func parsePackage(path string) (*types.File, error) {
 	return file, nil
 }
 
+// Returns directory of package, where named type is declared, and name of the type.
+// Local types are declared in source package, imported ones are looked up in vendor directories and GOPATH.
+func declarationDir(info *GenerationInfo, t types.Type) (string, string) {
+	switch t := t.(type) {
+	case types.TName:
+		if types.IsBuiltin(t) {
+			return "", ""
+		}
+		return filepath.Dir(info.SourceFilePath), t.TypeName
+	case types.TImport:
+		next, ok := t.Next.(types.TName)
+		if !ok || t.Import == nil {
+			return "", ""
+		}
+		return importedPackageDir(t.Import.Package, filepath.Dir(info.SourceFilePath)), next.TypeName
+	}
+	return "", ""
+}
+
+// Returns parsed package, where named type is declared, and name of the type.
+func declarationPackage(info *GenerationInfo, t types.Type) (*types.File, string) {
+	dir, name := declarationDir(info, t)
+	if dir == "" {
+		return nil, ""
+	}
+	file, err := parsePackageDir(dir)
+	if err != nil {
+		return nil, ""
+	}
+	return file, name
+}
+
+// Returns declaration of named type, which is declared in source package or in imported from source package one.
+// Exactly one of results is not nil, when declaration is found.
+func sourceDeclaration(info *GenerationInfo, t types.Type) (*types.Struct, *types.FileType) {
+	file, name := declarationPackage(info, t)
+	if file == nil {
+		return nil, nil
+	}
+	for i := range file.Structures {
+		if file.Structures[i].Name == name {
+			return &file.Structures[i], nil
+		}
+	}
+	for i := range file.Types {
+		if file.Types[i].Name == name {
+			return nil, &file.Types[i]
+		}
+	}
+	return nil, nil
+}
+
+// Returns qualified variable or constant, which is declared in source package
+// or in package, imported by source file.
+//
+//		ErrNotFound        -> service.ErrNotFound
+//		entity.ErrNotFound -> entity.ErrNotFound
+//
+func sourceValue(info *GenerationInfo, name string) (*Statement, error) {
+	i := strings.LastIndex(name, ".")
+	if i < 0 {
+		return Qual(info.SourcePackageImport, name), nil
+	}
+	file, err := parsePackage(info.SourceFilePath)
+	if err != nil {
+		return nil, err
+	}
+	for _, imp := range file.Imports {
+		if imp == nil {
+			continue
+		}
+		if imp.Name == name[:i] || (imp.Name == "" && path.Base(imp.Package) == name[:i]) {
+			return Qual(imp.Package, name[i+1:]), nil
+		}
+	}
+	return nil, fmt.Errorf("package %s of %s is not imported", name[:i], name)
+}
+
+// Returns directory of imported package, which is looked up in vendor directories and GOPATH,
+// or empty string, if package is not found.
+func importedPackageDir(importPath, srcDir string) string {
+	var dirs []string
+	for dir := srcDir; ; dir = filepath.Dir(dir) {
+		dirs = append(dirs, filepath.Join(dir, "vendor", importPath))
+		if dir == filepath.Dir(dir) {
+			break
+		}
+	}
+	for _, path := range filepath.SplitList(os.Getenv("GOPATH")) {
+		dirs = append(dirs, filepath.Join(path, "src", importPath))
+	}
+	for _, dir := range dirs {
+		if fi, err := os.Stat(dir); err == nil && fi.IsDir() {
+			return dir
+		}
+	}
+	return ""
+}
+
 func statFile(absPath, relPath string) error {
 	outpath, err := filepath.Abs(filepath.Join(absPath, relPath))
 	if err != nil {
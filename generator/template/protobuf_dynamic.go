@@ -0,0 +1,179 @@
+package template
+
+import (
+	"context"
+
+	. "github.com/dave/jennifer/jen"
+	"github.com/vetcher/go-astra/types"
+)
+
+// Go types of numbers, which are transferred as number values of google.protobuf.Value.
+var dynamicNumberTypes = []string{"float64", "float32", "int", "int64", "int32", "int16", "int8", "uint", "uint64", "uint32", "uint16", "uint8"}
+
+var (
+	emptyInterfaceType = types.TInterface{Interface: &types.Interface{}}
+	dynamicStructType  = types.TMap{Key: types.TName{TypeName: "string"}, Value: emptyInterfaceType}
+)
+
+// ContainsProtoMessage returns true, when type contains proto.Message, which is transferred as google.protobuf.Any.
+// Any can not be decoded from json without registry of types, so it is transferred only by grpc.
+func ContainsProtoMessage(t types.Type) bool {
+	if t == nil {
+		return false
+	}
+	if m := lookupTypeMapping(t); m != nil {
+		return m.ProtoType == googleProtobufAny
+	}
+	switch f := t.(type) {
+	case types.TMap:
+		return ContainsProtoMessage(f.Key) || ContainsProtoMessage(f.Value)
+	case types.LinearType:
+		return ContainsProtoMessage(f.NextType())
+	}
+	return false
+}
+
+// Returns statement, which constructs google.protobuf.Value with kind.
+//
+//		&structpb.Value{Kind: &structpb.Value_StringValue{StringValue: value}}
+//
+func dynamicValue(kind string, value Code) *Statement {
+	return Op("&").Qual(GolangProtobufPtypesStruct, "Value").Values(Dict{
+		Id("Kind"): Op("&").Qual(GolangProtobufPtypesStruct, "Value_"+kind).Values(Dict{Id(kind): value}),
+	})
+}
+
+// Renders body of converter from dynamic go value to google.protobuf.Value, google.protobuf.Struct or google.protobuf.Any.
+// Values are converted the same way, as encoding/json does for http transport,
+// so values of other types, e.g. structures, are converted to their json representation.
+// Returns nil, when type is not dynamic.
+//
+//		func InterfaceToProto(value interface{}) (*structpb.Value, error) {
+//			switch v := value.(type) {
+//			case nil:
+//				return &structpb.Value{Kind: &structpb.Value_NullValue{NullValue: structpb.NullValue_NULL_VALUE}}, nil
+//			case string:
+//				return &structpb.Value{Kind: &structpb.Value_StringValue{StringValue: v}}, nil
+//			...
+//			}
+//			data, err := json.Marshal(value)
+//			...
+//		}
+//
+func (t *stubGRPCTypeConverterTemplate) dynamicToProto(ctx context.Context, p types.Type, name string) *Statement {
+	m := lookupTypeMapping(p)
+	if m == nil || m.ToProto != "" {
+		return nil
+	}
+	switch m.ProtoType {
+	case googleProtobufValue:
+		s := Switch(Id("v").Op(":=").Id(name).Assert(Type())).BlockFunc(func(g *Group) {
+			g.Case(Nil()).Block(Return(dynamicValue("NullValue", Qual(GolangProtobufPtypesStruct, "NullValue_NULL_VALUE")), Nil()))
+			g.Case(Bool()).Block(Return(dynamicValue("BoolValue", Id("v")), Nil()))
+			g.Case(String()).Block(Return(dynamicValue("StringValue", Id("v")), Nil()))
+			for _, number := range dynamicNumberTypes {
+				value := Code(Id("v"))
+				if number != "float64" {
+					value = Float64().Call(Id("v"))
+				}
+				g.Case(Id(number)).Block(Return(dynamicValue("NumberValue", value), Nil()))
+			}
+			g.Case(Index().Interface()).Block(
+				Id("values").Op(":=").Make(Index().Op("*").Qual(GolangProtobufPtypesStruct, "Value"), Lit(0), Len(Id("v"))).Line().
+					For(List(Id("_"), Id("elem")).Op(":=").Range().Id("v")).Block(
+					List(Id("conv"), Err()).Op(":=").Id(t.nestedToProto(emptyInterfaceType)).Call(Id("elem")),
+					If(Err().Op("!=").Nil()).Block(Return(Nil(), Err())),
+					Id("values").Op("=").Append(Id("values"), Id("conv")),
+				).Line().
+					Return(dynamicValue("ListValue", Op("&").Qual(GolangProtobufPtypesStruct, "ListValue").Values(Dict{Id("Values"): Id("values")})), Nil()),
+			)
+			g.Case(Map(String()).Interface()).Block(
+				List(Id("conv"), Err()).Op(":=").Id(t.nestedToProto(dynamicStructType)).Call(Id("v")).Line().
+					If(Err().Op("!=").Nil()).Block(Return(Nil(), Err())).Line().
+					Return(dynamicValue("StructValue", Id("conv")), Nil()),
+			)
+		}).Line()
+		s.Comment("Other values are converted to their json representation, as http transport transfers them.").Line()
+		s.List(Id("data"), Err()).Op(":=").Qual(PackagePathJson, "Marshal").Call(Id(name)).Line()
+		s.If(Err().Op("!=").Nil()).Block(Return(Nil(), Err())).Line()
+		s.Var().Id("decoded").Interface().Line()
+		s.If(Err().Op(":=").Qual(PackagePathJson, "Unmarshal").Call(Id("data"), Op("&").Id("decoded")), Err().Op("!=").Nil()).Block(Return(Nil(), Err())).Line()
+		return s.Return(Id(t.nestedToProto(emptyInterfaceType)).Call(Id("decoded")))
+	case googleProtobufStruct:
+		s := If(Id(name).Op("==").Nil()).Block(Return(Nil(), Nil())).Line()
+		s.Id("fields").Op(":=").Make(Map(String()).Op("*").Qual(GolangProtobufPtypesStruct, "Value"), Len(Id(name))).Line()
+		s.For(List(Id("key"), Id("elem")).Op(":=").Range().Id(name)).Block(
+			List(Id("conv"), Err()).Op(":=").Id(t.nestedToProto(emptyInterfaceType)).Call(Id("elem")),
+			If(Err().Op("!=").Nil()).Block(Return(Nil(), Err())),
+			Id("fields").Index(Id("key")).Op("=").Id("conv"),
+		).Line()
+		return s.Return(Op("&").Qual(GolangProtobufPtypesStruct, "Struct").Values(Dict{Id("Fields"): Id("fields")}), Nil())
+	case googleProtobufAny:
+		s := If(Id(name).Op("==").Nil()).Block(Return(Nil(), Nil())).Line()
+		return s.Return(Qual(GolangProtobufPtypes, "MarshalAny").Call(Id(name)))
+	}
+	return nil
+}
+
+// Renders body of converter from google.protobuf.Value, google.protobuf.Struct or google.protobuf.Any to dynamic go value.
+// Values are the same, as encoding/json decodes for http transport: numbers are float64,
+// lists are []interface{} and structures are map[string]interface{}. Any is unpacked to message, registered by protoc.
+// Returns nil, when type is not dynamic.
+//
+//		func ProtoToInterface(protoValue *structpb.Value) (interface{}, error) {
+//			switch kind := protoValue.GetKind().(type) {
+//			case nil, *structpb.Value_NullValue:
+//				return nil, nil
+//			case *structpb.Value_StringValue:
+//				return kind.StringValue, nil
+//			...
+//			}
+//			return nil, fmt.Errorf("unknown kind %T of google.protobuf.Value", protoValue.GetKind())
+//		}
+//
+func (t *stubGRPCTypeConverterTemplate) dynamicProtoTo(ctx context.Context, p types.Type, name string) *Statement {
+	m := lookupTypeMapping(p)
+	if m == nil || m.FromProto != "" {
+		return nil
+	}
+	kind := func(k string) *Statement {
+		return Op("*").Qual(GolangProtobufPtypesStruct, "Value_"+k)
+	}
+	switch m.ProtoType {
+	case googleProtobufValue:
+		s := Switch(Id("kind").Op(":=").Id(name).Dot("GetKind").Call().Assert(Type())).Block(
+			Case(Nil(), kind("NullValue")).Block(Return(Nil(), Nil())),
+			Case(kind("BoolValue")).Block(Return(Id("kind").Dot("BoolValue"), Nil())),
+			Case(kind("NumberValue")).Block(Return(Id("kind").Dot("NumberValue"), Nil())),
+			Case(kind("StringValue")).Block(Return(Id("kind").Dot("StringValue"), Nil())),
+			Case(kind("ListValue")).Block(
+				Id("values").Op(":=").Make(Index().Interface(), Lit(0), Len(Id("kind").Dot("ListValue").Dot("GetValues").Call())).Line().
+					For(List(Id("_"), Id("elem")).Op(":=").Range().Id("kind").Dot("ListValue").Dot("GetValues").Call()).Block(
+					List(Id("conv"), Err()).Op(":=").Id(t.nestedProtoTo(emptyInterfaceType)).Call(Id("elem")),
+					If(Err().Op("!=").Nil()).Block(Return(Nil(), Err())),
+					Id("values").Op("=").Append(Id("values"), Id("conv")),
+				).Line().
+					Return(Id("values"), Nil()),
+			),
+			Case(kind("StructValue")).Block(
+				Return(Id(t.nestedProtoTo(dynamicStructType)).Call(Id("kind").Dot("StructValue"))),
+			),
+		).Line()
+		return s.Return(Nil(), Qual(PackagePathFmt, "Errorf").Call(Lit("unknown kind %T of "+googleProtobufValue), Id(name).Dot("GetKind").Call()))
+	case googleProtobufStruct:
+		s := If(Id(name).Op("==").Nil()).Block(Return(Nil(), Nil())).Line()
+		s.Id("value").Op(":=").Make(Map(String()).Interface(), Len(Id(name).Dot("Fields"))).Line()
+		s.For(List(Id("key"), Id("elem")).Op(":=").Range().Id(name).Dot("Fields")).Block(
+			List(Id("conv"), Err()).Op(":=").Id(t.nestedProtoTo(emptyInterfaceType)).Call(Id("elem")),
+			If(Err().Op("!=").Nil()).Block(Return(Nil(), Err())),
+			Id("value").Index(Id("key")).Op("=").Id("conv"),
+		).Line()
+		return s.Return(Id("value"), Nil())
+	case googleProtobufAny:
+		s := If(Id(name).Op("==").Nil()).Block(Return(Nil(), Nil())).Line()
+		s.Var().Id("dynamic").Qual(GolangProtobufPtypes, "DynamicAny").Line()
+		s.If(Err().Op(":=").Qual(GolangProtobufPtypes, "UnmarshalAny").Call(Id(name), Op("&").Id("dynamic")), Err().Op("!=").Nil()).Block(Return(Nil(), Err())).Line()
+		return s.Return(Id("dynamic").Dot("Message"), Nil())
+	}
+	return nil
+}
@@ -0,0 +1,127 @@
+package template
+
+import (
+	"fmt"
+	"strings"
+
+	mstrings "github.com/devimteam/microgen/generator/strings"
+	"github.com/vetcher/go-astra/types"
+)
+
+const (
+	ProtobufPointersTag = "protobuf-pointers"
+
+	// Pointers to scalar types are transferred as google.protobuf wrappers, e.g. *string as google.protobuf.StringValue.
+	ProtobufPointersWrappers = "wrappers"
+	// Pointers to scalar types are transferred as proto3 optional fields, e.g. *string as optional string.
+	ProtobufPointersOptional = "optional"
+)
+
+// ParseProtobufPointers returns mode of transferring pointers to scalar types, declared by @protobuf-pointers tag.
+// Wrappers are used by default.
+//
+//		// @protobuf-pointers optional
+//
+func ParseProtobufPointers(docs []string) (string, error) {
+	mode := mstrings.FetchMetaInfo(TagMark+ProtobufPointersTag, docs)
+	switch mode {
+	case "":
+		return ProtobufPointersWrappers, nil
+	case ProtobufPointersWrappers, ProtobufPointersOptional:
+		return mode, nil
+	}
+	return "", fmt.Errorf("@%s: %s is unknown mode, use %s or %s", ProtobufPointersTag, mode, ProtobufPointersOptional, ProtobufPointersWrappers)
+}
+
+// UseOptionalPointers removes wrappers from registry of type mappings,
+// so pointers to scalar types are transferred as proto3 optional fields.
+// Mappings, registered after call, are kept.
+func UseOptionalPointers() {
+	for goType, m := range typeMappings {
+		if isPointerGoType(goType) && strings.HasPrefix(m.ProtoGoType, "*"+GolangProtobufWrappers+".") {
+			delete(typeMappings, goType)
+		}
+	}
+}
+
+func isPointerGoType(goType string) bool {
+	return strings.HasPrefix(goType, "*") && !strings.HasPrefix(goType, "**")
+}
+
+// Returns true, when type is transferred as protobuf scalar or enum, so pointer to it may be optional field.
+func isProtoScalar(info *GenerationInfo, p types.Type) bool {
+	if m := lookupTypeMapping(p); m != nil {
+		return m.ProtoType != "" && !m.isMessage() && m.ProtoGoType != "[]byte"
+	}
+	switch f := p.(type) {
+	case types.TName:
+		if types.IsBuiltin(f) {
+			_, ok := builtinProtoTypes[f.TypeName]
+			return ok
+		}
+	case types.TImport:
+	default:
+		return false
+	}
+	if isEnumType(info, p) {
+		return true
+	}
+	if findNamedType(info, p) != nil {
+		return false
+	}
+	// Named types, which are not structures, are transferred as their underlying types.
+	if _, named := sourceDeclaration(info, p); named != nil {
+		return isProtoScalar(info, qualifiedType(p, named.Type))
+	}
+	return false
+}
+
+// Returns true, when type is pointer to scalar, which is transferred as proto3 optional field:
+// it is not mapped to wrapper or to other type by registry of type mappings.
+//
+//		*int -> optional int64
+//		*entity.Status -> optional Status
+//
+func isOptionalPointer(info *GenerationInfo, p types.Type) bool {
+	ptr, ok := p.(types.TPointer)
+	return ok && ptr.NumberOfPointers == 1 && lookupTypeMapping(p) == nil && isProtoScalar(info, ptr.Next)
+}
+
+// Returns protobuf type of field with label, pointers to scalars are optional fields.
+//
+//		*int -> optional int64
+//
+func (t *protoTemplate) protoFieldType(v types.Type, imports map[string]struct{}) string {
+	if isOptionalPointer(t.info, v) {
+		return "optional " + t.protoType(v, imports)
+	}
+	return t.protoType(v, imports)
+}
+
+// Returns error, when type contains pointer to scalar, which is element of repeated or map field,
+// because protobuf elements can not be optional.
+func checkOptionalElements(info *GenerationInfo, p types.Type) error {
+	var elems []types.Type
+	switch f := p.(type) {
+	case types.TPointer:
+		if f.NumberOfPointers == 1 && !isOptionalPointer(info, f) {
+			return checkOptionalElements(info, f.Next)
+		}
+		return nil
+	case types.TArray:
+		elems = append(elems, f.Next)
+	case types.TEllipsis:
+		elems = append(elems, f.Next)
+	case types.TMap:
+		elems = append(elems, f.Key, f.Value)
+	}
+	for _, elem := range elems {
+		if isOptionalPointer(info, elem) {
+			return fmt.Errorf("%s: elements of repeated and map fields can not be optional, use %s instead of %s", p, elem.(types.TPointer).Next, elem)
+		}
+		if err := checkOptionalElements(info, elem); err != nil {
+			return err
+		}
+	}
+	return nil
+}
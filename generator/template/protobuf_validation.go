@@ -0,0 +1,280 @@
+package template
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	mstrings "github.com/devimteam/microgen/generator/strings"
+	"github.com/vetcher/go-astra/types"
+)
+
+// Go types of protobuf fields for builtin go types, which are not declared in registry of type mappings.
+var builtinProtoTypes = map[string]string{
+	"string":  "string",
+	"bool":    "bool",
+	"int64":   "int64",
+	"int32":   "int32",
+	"uint64":  "uint64",
+	"uint32":  "uint32",
+	"float64": "float64",
+	"float32": "float32",
+	"int16":   "int32",
+	"int8":    "int32",
+	"rune":    "int32",
+	"uint16":  "uint32",
+	"uint8":   "uint32",
+	"byte":    "uint32",
+}
+
+var packageQualifier = regexp.MustCompile(`[A-Za-z_][A-Za-z0-9_]*\.`)
+
+// Types are compared without package qualifiers, because well-known types are available from several packages
+// and messages of protobuf package are compared with local types of parsed package.
+func sameProtoType(a, b string) bool {
+	return packageQualifier.ReplaceAllString(a, "") == packageQualifier.ReplaceAllString(b, "")
+}
+
+type protobufValidator struct {
+	pb     *types.File
+	source *types.File
+	// Names of messages, which fields are already checked.
+	checked map[string]bool
+	errs    []error
+}
+
+// ValidateProtobufPackage checks, that go package compiled from .proto file declares service, rpc, messages and fields,
+// which are used by generated grpc transport, and that types of fields are compatible with types of parameters.
+// Source package is used to check fields of messages for structures and may be nil.
+func ValidateProtobufPackage(iface *types.Interface, pb *types.File, source *types.File) []error {
+	v := &protobufValidator{pb: pb, source: source, checked: make(map[string]bool)}
+	var server *types.Interface
+	for i := range pb.Interfaces {
+		if pb.Interfaces[i].Name == serverStructName(iface) {
+			server = &pb.Interfaces[i]
+		}
+	}
+	if server == nil {
+		return []error{fmt.Errorf("service %s is not declared: interface %s not found", iface.Name, serverStructName(iface))}
+	}
+	for _, fn := range iface.Methods {
+		if mstrings.ContainTag(mstrings.FetchTags(fn.Docs, TagMark+MicrogenMainTag), "-") {
+			continue
+		}
+		v.validateMethod(iface, server, fn)
+	}
+	return v.errs
+}
+
+func (v *protobufValidator) errorf(format string, args ...interface{}) {
+	v.errs = append(v.errs, fmt.Errorf(format, args...))
+}
+
+func (v *protobufValidator) validateMethod(iface *types.Interface, server *types.Interface, fn *types.Function) {
+	var rpc *types.Function
+	for _, m := range server.Methods {
+		if m.Name == fn.Name {
+			rpc = m
+		}
+	}
+	if rpc == nil {
+		v.errorf("%s: rpc %s is not declared in service %s", fn.Name, fn.Name, iface.Name)
+		return
+	}
+	// Streams are generated by protoc as interfaces, so only messages are checked.
+	if methodStreams(fn).IsStream() {
+		t := &gRPCServerTemplate{info: &GenerationInfo{ProtobufPackageImport: "pb"}}
+		if fmt.Sprintf("%#v", t.grpcServerReqStruct(fn)) == "*pb."+requestStructName(fn) {
+			v.validateMessage(fn, requestStructName(fn), streamMessageFields(grpcRequestParams(fn)))
+		}
+		if fmt.Sprintf("%#v", t.grpcServerRespStruct(fn)) == "*pb."+responseStructName(fn) {
+			v.validateMessage(fn, responseStructName(fn), streamMessageFields(grpcResponseParams(fn)))
+		}
+		return
+	}
+	if len(rpc.Args) != 2 || len(rpc.Results) != 2 {
+		v.errorf("%s: rpc should accept request and return response", fn.Name)
+		return
+	}
+	// Generated grpc server implements rpc with the same types, as these.
+	t := &gRPCServerTemplate{info: &GenerationInfo{ProtobufPackageImport: "pb"}}
+	if req := fmt.Sprintf("%#v", t.grpcServerReqStruct(fn)); !sameProtoType(rpc.Args[1].Type.String(), req) {
+		v.errorf("%s: rpc accepts %s, but %s is expected", fn.Name, rpc.Args[1].Type, req)
+	} else if req == "*pb."+requestStructName(fn) {
+		v.validateMessage(fn, requestStructName(fn), RemoveContextIfFirst(fn.Args))
+	}
+	if resp := fmt.Sprintf("%#v", t.grpcServerRespStruct(fn)); !sameProtoType(rpc.Results[0].Type.String(), resp) {
+		v.errorf("%s: rpc returns %s, but %s is expected", fn.Name, rpc.Results[0].Type, resp)
+	} else if resp == "*pb."+responseStructName(fn) {
+		v.validateMessage(fn, responseStructName(fn), removeErrorIfLast(fn.Results))
+	}
+}
+
+func (v *protobufValidator) findStruct(file *types.File, name string) *types.Struct {
+	if file == nil {
+		return nil
+	}
+	for i := range file.Structures {
+		if file.Structures[i].Name == name {
+			return &file.Structures[i]
+		}
+	}
+	return nil
+}
+
+// Checks, that message has fields for parameters with compatible types.
+// Fields of messages are named as parameters with upper first letter, because converters use these names.
+func (v *protobufValidator) validateMessage(fn *types.Function, message string, params []types.Variable) {
+	v.checked[message] = true
+	strct := v.findStruct(v.pb, message)
+	if strct == nil {
+		v.errorf("%s: message %s is not declared", fn.Name, message)
+		return
+	}
+	for _, param := range params {
+		var expected string
+		switch inline := param.Type.(type) {
+		case *types.Function:
+			// Function types are not transferred.
+			continue
+		case types.Struct:
+			name := namedTypeName(fn, &param)
+			expected = "*" + name
+			if v.findStruct(v.pb, name) != nil && !v.checked[name] {
+				v.validateStructMessage(fn, name, inline.Fields)
+			}
+		default:
+			var ok bool
+			if expected, ok = v.protoType(fn, param.Type); !ok {
+				continue
+			}
+		}
+		v.validateField(fn, strct, &param, mstrings.ToUpperFirst(param.Name), expected)
+	}
+}
+
+func (v *protobufValidator) validateStructMessage(fn *types.Function, message string, fields []types.StructField) {
+	v.checked[message] = true
+	strct := v.findStruct(v.pb, message)
+	for _, field := range fields {
+		if field.Name == "" || field.Name != mstrings.ToUpperFirst(field.Name) {
+			continue
+		}
+		if expected, ok := v.protoType(fn, field.Type); ok {
+			v.validateField(fn, strct, &field.Variable, protoGoFieldName(field.Name), expected)
+		}
+	}
+}
+
+func (v *protobufValidator) validateField(fn *types.Function, message *types.Struct, param *types.Variable, name, expected string) {
+	for _, field := range message.Fields {
+		if field.Name != name {
+			continue
+		}
+		if !sameProtoType(field.Type.String(), expected) {
+			v.errorf("%s: field %s.%s has type %s, but %s is expected for %s", fn.Name, message.Name, name, field.Type, expected, param)
+		}
+		return
+	}
+	v.errorf("%s: message %s does not have field %s (%s in .proto file)", fn.Name, message.Name, name, mstrings.ToSnakeCase(param.Name))
+}
+
+// Returns go type of protobuf field for go type, the same as protoc generates for service.proto.
+// Second result is false, when type can not be checked.
+//
+//		[]*Comment -> []*Comment
+//		map[string]int -> map[string]int64
+//		time.Time -> *timestamp.Timestamp
+//
+func (v *protobufValidator) protoType(fn *types.Function, t types.Type) (string, bool) {
+	if m := lookupTypeMapping(t); m != nil && m.ProtoGoType != "" {
+		return shortGoType(m.ProtoGoType), true
+	}
+	if s := t.String(); s == "[]byte" || s == "[]uint8" {
+		return "[]byte", true
+	}
+	switch t := t.(type) {
+	case types.TPointer:
+		if t.NumberOfPointers != 1 {
+			return "", false
+		}
+		// Optional scalar fields are pointers, messages are pointers already.
+		elem, ok := v.protoType(fn, t.Next)
+		if ok && !strings.HasPrefix(elem, "*") && !strings.HasPrefix(elem, "[]") && !strings.HasPrefix(elem, "map[") {
+			return "*" + elem, true
+		}
+		return elem, ok
+	case types.TArray:
+		if isBytesType(t) {
+			return "[]byte", true
+		}
+		elem, ok := v.protoType(fn, t.Next)
+		return "[]" + elem, ok
+	case types.TEllipsis:
+		elem, ok := v.protoType(fn, t.Next)
+		return "[]" + elem, ok
+	case types.TMap:
+		key, ok := v.mapKeyProtoType(fn, t.Key)
+		if !ok {
+			return "", false
+		}
+		value, ok := v.protoType(fn, t.Value)
+		return "map[" + key + "]" + value, ok
+	case types.TImport:
+		if name, ok := t.Next.(types.TName); ok {
+			return v.namedProtoType(fn, name.TypeName)
+		}
+	case types.TName:
+		if pt, ok := builtinProtoTypes[t.TypeName]; ok {
+			return pt, true
+		}
+		if !types.IsBuiltin(t) {
+			return v.namedProtoType(fn, t.TypeName)
+		}
+	}
+	return "", false
+}
+
+// Keys of protobuf maps can not be enums, so enums of source package are replaced by underlying types.
+// Keys of imported named types are not checked.
+func (v *protobufValidator) mapKeyProtoType(fn *types.Function, t types.Type) (string, bool) {
+	switch key := t.(type) {
+	case types.TImport:
+		return "", false
+	case types.TName:
+		if types.IsBuiltin(key) || v.source == nil {
+			break
+		}
+		for _, named := range v.source.Types {
+			if named.Name == key.TypeName {
+				return v.protoType(fn, named.Type)
+			}
+		}
+	}
+	return v.protoType(fn, t)
+}
+
+// Messages are pointers to structures and enums are named types.
+func (v *protobufValidator) namedProtoType(fn *types.Function, name string) (string, bool) {
+	if strct := v.findStruct(v.pb, name); strct != nil {
+		if src := v.findStruct(v.source, name); src != nil && !v.checked[name] {
+			v.validateStructMessage(fn, name, src.Fields)
+		}
+		return "*" + name, true
+	}
+	for _, t := range v.pb.Types {
+		if t.Name == name {
+			return name, true
+		}
+	}
+	// Named types of source package, which are not enums, are replaced by underlying types.
+	if v.source != nil {
+		for _, t := range v.source.Types {
+			if t.Name == name {
+				return v.protoType(fn, t.Type)
+			}
+		}
+	}
+	v.errorf("%s: type %s is not declared in protobuf package", fn.Name, name)
+	return "", false
+}
@@ -0,0 +1,87 @@
+package template
+
+import (
+	"go/parser"
+	"go/token"
+	"strings"
+	"testing"
+
+	"github.com/vetcher/go-astra"
+	"github.com/vetcher/go-astra/types"
+)
+
+func parseSource(t *testing.T, src string) *types.File {
+	f, err := parser.ParseFile(token.NewFileSet(), "", src, parser.ParseComments)
+	if err != nil {
+		t.Fatal(err)
+	}
+	file, err := astra.ParseAstFile(f, astra.AllowAnyImportAliases)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return file
+}
+
+func TestValidateProtobufPackage(t *testing.T) {
+	source := parseSource(t, `package svc
+
+import "context"
+
+type Service interface {
+	Count(ctx context.Context, text string) (count int, err error)
+}
+`)
+	const (
+		request  = "type CountRequest struct {\n\tText string\n}\n"
+		response = "type CountResponse struct {\n\tCount int64\n}\n"
+		server   = "type ServiceServer interface {\n\tCount(context.Context, *CountRequest) (*CountResponse, error)\n}\n"
+	)
+	tests := []struct {
+		name string
+		pb   string
+		errs []string
+	}{
+		{
+			name: "valid",
+			pb:   request + response + server,
+		},
+		{
+			name: "missing service",
+			pb:   request + response,
+			errs: []string{"service Service is not declared: interface ServiceServer not found"},
+		},
+		{
+			name: "missing rpc",
+			pb:   request + response + "type ServiceServer interface {\n}\n",
+			errs: []string{"Count: rpc Count is not declared in service Service"},
+		},
+		{
+			name: "missing message",
+			pb:   response + server,
+			errs: []string{"Count: message CountRequest is not declared"},
+		},
+		{
+			name: "missing field",
+			pb:   "type CountRequest struct {\n}\n" + response + server,
+			errs: []string{"Count: message CountRequest does not have field Text (text in .proto file)"},
+		},
+		{
+			name: "type mismatch",
+			pb:   request + "type CountResponse struct {\n\tCount int32\n}\n" + server,
+			errs: []string{"Count: field CountResponse.Count has type int32, but int64 is expected for count int"},
+		},
+	}
+	iface := &source.Interfaces[0]
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			pb := parseSource(t, "package pb\n\nimport \"context\"\n\n"+test.pb)
+			var errs []string
+			for _, err := range ValidateProtobufPackage(iface, pb, source) {
+				errs = append(errs, err.Error())
+			}
+			if strings.Join(errs, "\n") != strings.Join(test.errs, "\n") {
+				t.Errorf("errors:\n%s\nexpected:\n%s", strings.Join(errs, "\n"), strings.Join(test.errs, "\n"))
+			}
+		})
+	}
+}
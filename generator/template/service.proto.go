@@ -3,6 +3,7 @@ package template
 import (
 	"context"
 	"fmt"
+	"path/filepath"
 	"sort"
 
 	"github.com/devimteam/microgen/generator/strings"
@@ -15,6 +16,25 @@ const tab = "    "
 type protoTemplate struct {
 	info     *GenerationInfo
 	protoPkg string
+	// Messages for structures, referenced by parameters, in order of discovery.
+	messages []*protoMessage
+	// Messages by full name of go type.
+	known map[string]*protoMessage
+	// Enums for named types with constants, in order of discovery.
+	enums []*protoEnum
+	// Enums by full name of go type.
+	knownEnums map[string]*protoEnum
+	// Named non-struct types, which underlying type is resolving now.
+	resolving map[string]bool
+	errs      []error
+	lock      *protoLock
+}
+
+// Message, declared in service.proto for go structure.
+type protoMessage struct {
+	Name   string
+	GoType string
+	Fields []types.StructField
 }
 
 func NewProtoTemplate(info *GenerationInfo, protoPkg string) Template {
@@ -56,15 +76,31 @@ func (t *protoTemplate) Render(ctx context.Context) write_strategy.Renderer {
 			if !t.info.AllowedMethods[method.Name] {
 				continue
 			}
-			reqTypeName, externalImport := protoMessageName(RemoveContextIfFirst(method.Args), requestStructName(method))
-			if externalImport != nil {
+			reqTypeName, externalImport := protoMessageName(grpcRequestParams(method), requestStructName(method))
+			if externalImport != nil && *externalImport != "" {
 				imports[*externalImport] = struct{}{}
 			}
-			respTypeName, externalImport := protoMessageName(removeErrorIfLast(method.Results), responseStructName(method))
-			if externalImport != nil {
+			respTypeName, externalImport := protoMessageName(grpcResponseParams(method), responseStructName(method))
+			if externalImport != nil && *externalImport != "" {
 				imports[*externalImport] = struct{}{}
 			}
-			d.Lnf(tab+"rpc %s (%s) returns (%s);", method.Name, reqTypeName, respTypeName)
+			streams := methodStreams(method)
+			if streams.Request != nil {
+				reqTypeName = "stream " + reqTypeName
+			}
+			if streams.Response != nil {
+				respTypeName = "stream " + respTypeName
+			}
+			if !hasGatewayRoute(method) {
+				d.Lnf(tab+"rpc %s (%s) returns (%s);", method.Name, reqTypeName, respTypeName)
+				continue
+			}
+			imports[importGoogleAPIAnnotations] = struct{}{}
+			d.Lnf(tab+"rpc %s (%s) returns (%s) {", method.Name, reqTypeName, respTypeName)
+			for _, line := range googleAPIHTTPOption(method) {
+				d.Ln(tab + tab + line)
+			}
+			d.Ln(tab + "}")
 		}
 		d.Ln("}")
 
@@ -73,39 +109,56 @@ func (t *protoTemplate) Render(ctx context.Context) write_strategy.Renderer {
 			if !t.info.AllowedMethods[method.Name] {
 				continue
 			}
-			{
-				args := RemoveContextIfFirst(method.Args)
-				reqTypeName, externalImport := protoMessageName(args, requestStructName(method))
-				if externalImport == nil {
-					d.Ln()
-					d.Lnf("message %s {", reqTypeName)
-					for i, arg := range args {
-						n, imp := protoTypeName(arg.Type)
-						if imp != nil {
-							imports[*imp] = struct{}{}
-						}
-						d.Lnf(tab+"%s %s = %d;", n, strings.ToSnakeCase(arg.Name), i+1)
-					}
-					d.Ln("}")
-				}
+			method = transportFunction(t.info, method)
+			args := streamMessageFields(grpcRequestParams(method))
+			if reqTypeName, externalImport := protoMessageName(args, requestStructName(method)); externalImport == nil {
+				d.Ln()
+				t.drawMessage(d, reqTypeName, t.paramsFields(args, imports))
+			}
+			results := streamMessageFields(grpcResponseParams(method))
+			if respTypeName, externalImport := protoMessageName(results, responseStructName(method)); externalImport == nil {
+				d.Ln()
+				t.drawMessage(d, respTypeName, t.paramsFields(results, imports))
+			}
+		}
+
+		// Draw messages for inline structs
+		for _, n := range namedTypes(t.info) {
+			strct, ok := n.Inline.(types.Struct)
+			if !ok {
+				continue
 			}
-			{
-				params := removeErrorIfLast(method.Results)
-				reqTypeName, externalImport := protoMessageName(params, responseStructName(method))
-				if externalImport == nil {
-					d.Ln()
-					d.Lnf("message %s {", reqTypeName)
-					for i, arg := range params {
-						n, imp := protoTypeName(arg.Type)
-						if imp != nil {
-							imports[*imp] = struct{}{}
-						}
-						d.Lnf(tab+"%s %s = %d;", n, strings.ToSnakeCase(arg.Name), i+1)
-					}
-					d.Ln("}")
+			var fields []protoField
+			for _, field := range strct.Fields {
+				fields = append(fields, protoField{Name: strings.ToSnakeCase(field.Name), Type: t.protoFieldType(field.Type, imports)})
+			}
+			d.Ln()
+			t.drawMessage(d, n.Name, fields)
+		}
+
+		// Draw messages for structures, referenced by parameters and fields of other messages.
+		// Messages are appended while fields are drawn, so the list is iterated by index.
+		for i := 0; i < len(t.messages); i++ {
+			m := t.messages[i]
+			var fields []protoField
+			for _, field := range m.Fields {
+				if reason := untransferableField(field); reason != "" {
+					fields = append(fields, protoField{Name: strings.ToSnakeCase(field.Name), Comment: reason})
+					continue
 				}
+				fields = append(fields, protoField{Name: strings.ToSnakeCase(field.Name), Type: t.protoFieldType(field.Type, imports)})
 			}
+			d.Ln()
+			d.Lnf("// %s", m.GoType)
+			t.drawMessage(d, m.Name, fields)
+		}
+
+		// Draw enums for named types with constants.
+		for _, e := range t.enums {
+			d.Ln()
+			t.drawEnum(d, e)
 		}
+		t.lock.removeUnused()
 
 		for _, imp := range sortedSliceFromStringSet(imports) {
 			f.Lnf(`import "%s";`, imp)
@@ -117,16 +170,119 @@ func (t *protoTemplate) Render(ctx context.Context) write_strategy.Renderer {
 	return f
 }
 
+// Field of message. Fields without type are not transferred and drawn as comments.
+type protoField struct {
+	Name    string
+	Type    string
+	Comment string
+}
+
+func (t *protoTemplate) paramsFields(params []types.Variable, imports map[string]struct{}) (fields []protoField) {
+	for _, param := range params {
+		fields = append(fields, protoField{Name: strings.ToSnakeCase(param.Name), Type: t.protoFieldType(param.Type, imports)})
+	}
+	return
+}
+
+// Draws message with numbers of fields from lock file. Numbers of removed fields are reserved.
+//
+//		message CountRequest {
+//		    string text = 1;
+//		    string symbol = 3;
+//		    reserved 2;
+//		    reserved "old_symbol";
+//		}
+//
+func (t *protoTemplate) drawMessage(d *DelayBuffer, name string, fields []protoField) {
+	numbers := t.lock.assign(name, fields)
+	d.Lnf("message %s {", name)
+	for i, field := range fields {
+		if field.Type == "" && field.Name == "" {
+			d.Lnf(tab+"// %s", field.Comment)
+			continue
+		} else if field.Type == "" {
+			d.Lnf(tab+"// %s: %s", field.Name, field.Comment)
+			continue
+		}
+		d.Lnf(tab+"%s %s = %d;", field.Type, field.Name, numbers[i])
+	}
+	if nums, names := t.lock.reserved(name); len(nums) > 0 {
+		d.Lnf(tab+"reserved %s;", nums)
+		d.Lnf(tab+"reserved %s;", names)
+	}
+	d.Ln("}")
+}
+
 func (protoTemplate) DefaultPath() string {
 	return "service.proto"
 }
 
+// Collects messages for referenced structures and checks, that they can be declared.
 func (t *protoTemplate) Prepare(ctx context.Context) error {
+	t.messages, t.known, t.resolving, t.errs = nil, make(map[string]*protoMessage), make(map[string]bool), nil
+	t.enums, t.knownEnums = nil, make(map[string]*protoEnum)
+	lock, err := readProtoLock(filepath.Join(t.info.OutputFilePath, protoLockPath))
+	if err != nil {
+		return err
+	}
+	t.lock = lock
+	declared := make(map[string]bool)
+	for _, method := range t.info.Iface.Methods {
+		if !t.info.AllowedMethods[method.Name] {
+			continue
+		}
+		declared[requestStructName(method)] = true
+		declared[responseStructName(method)] = true
+		for _, param := range streamMessageFields(append(grpcRequestParams(method), grpcResponseParams(method)...)) {
+			t.protoType(param.Type, nil)
+			if err := checkOptionalElements(t.info, param.Type); err != nil {
+				t.errs = append(t.errs, fmt.Errorf("%s: %s: %v", method.Name, param.Name, err))
+			}
+		}
+	}
+	for _, n := range namedTypes(t.info) {
+		declared[n.Name] = true
+		if strct, ok := n.Inline.(types.Struct); ok {
+			for _, field := range strct.Fields {
+				t.protoType(field.Type, nil)
+			}
+		}
+	}
+	for i := 0; i < len(t.messages); i++ {
+		m := t.messages[i]
+		if declared[m.Name] {
+			t.errs = append(t.errs, fmt.Errorf("message %s for %s is already declared", m.Name, m.GoType))
+		}
+		declared[m.Name] = true
+		for _, field := range m.Fields {
+			if untransferableField(field) == "" {
+				t.protoType(field.Type, nil)
+				if err := checkOptionalElements(t.info, field.Type); err != nil {
+					t.errs = append(t.errs, fmt.Errorf("%s.%s: %v", m.GoType, field.Name, err))
+				}
+			}
+		}
+	}
+	for _, e := range t.enums {
+		if declared[e.Name] {
+			t.errs = append(t.errs, fmt.Errorf("enum %s for %s conflicts with message of the same name", e.Name, e.GoType))
+		}
+		declared[e.Name] = true
+		if err := checkProtoEnum(e); err != nil {
+			t.errs = append(t.errs, err)
+		}
+	}
+	if len(t.errs) > 0 {
+		return t.errs[0]
+	}
 	return nil
 }
 
 func (t *protoTemplate) ChooseStrategy(ctx context.Context) (write_strategy.Strategy, error) {
-	return write_strategy.NewCreateRawFileStrategy(t.info.OutputFilePath, t.DefaultPath()), nil
+	return protoLockStrategy{
+		Strategy: write_strategy.NewCreateRawFileStrategy(t.info.OutputFilePath, t.DefaultPath()),
+		t:        t,
+	}, nil
 }
 
 const (
@@ -140,12 +296,20 @@ const (
 	googleProtobufUInt32Value  = googleProtobuf + "UInt32Value"
 	googleProtobufFloat64Value = googleProtobuf + "DoubleValue"
 	googleProtobufFloat32Value = googleProtobuf + "FloatValue"
+	googleProtobufBytesValue   = googleProtobuf + "BytesValue"
 	googleProtobufTimestamp    = googleProtobuf + "Timestamp"
+	googleProtobufDuration     = googleProtobuf + "Duration"
+	googleProtobufValue        = googleProtobuf + "Value"
+	googleProtobufStruct       = googleProtobuf + "Struct"
+	googleProtobufAny          = googleProtobuf + "Any"
 
 	importGoogleProtobuf          = "google/protobuf/"
 	importGoogleProtobufWrappers  = importGoogleProtobuf + "wrappers.proto"
 	importGoogleProtobufEmpty     = importGoogleProtobuf + "empty.proto"
 	importGoogleProtobufTimestamp = importGoogleProtobuf + "timestamp.proto"
+	importGoogleProtobufDuration  = importGoogleProtobuf + "duration.proto"
+	importGoogleProtobufStruct    = importGoogleProtobuf + "struct.proto"
+	importGoogleProtobufAny       = importGoogleProtobuf + "any.proto"
 )
 
 func protoMessageName(params []types.Variable, def string) (string, *string) {
@@ -153,62 +317,26 @@ func protoMessageName(params []types.Variable, def string) (string, *string) {
 	case 0:
 		return googleProtobufEmpty, sp(importGoogleProtobufEmpty)
 	case 1:
-		switch params[0].Type.String() {
-		case "*string":
-			return googleProtobufStringValue, sp(importGoogleProtobufWrappers)
-		case "*bool":
-			return googleProtobufBoolValue, sp(importGoogleProtobufWrappers)
-		case "*int64":
-			return googleProtobufInt64Value, sp(importGoogleProtobufWrappers)
-		case "*int32":
-			return googleProtobufInt32Value, sp(importGoogleProtobufWrappers)
-		case "*uint64":
-			return googleProtobufUInt64Value, sp(importGoogleProtobufWrappers)
-		case "*uint32":
-			return googleProtobufUInt32Value, sp(importGoogleProtobufWrappers)
-		case "*float64":
-			return googleProtobufFloat64Value, sp(importGoogleProtobufWrappers)
-		case "*float32":
-			return googleProtobufFloat32Value, sp(importGoogleProtobufWrappers)
-		case "time.Time", "*time.Time":
-			return googleProtobufTimestamp, sp(importGoogleProtobufTimestamp)
-		}
-		fallthrough
-	default:
-		return def, nil
+		// Import is empty, when message is declared in the same package.
+		if m := lookupTypeMapping(params[0].Type); m != nil && m.isMessage() {
+			return m.ProtoType, sp(m.ProtoImport)
+		}
 	}
+	return def, nil
 }
 
 func protoTypeName(v types.Type) (t string, imp *string) {
-	switch v.String() {
-	case "*string":
-		return googleProtobufStringValue, sp(importGoogleProtobufWrappers)
-	case "*bool":
-		return googleProtobufBoolValue, sp(importGoogleProtobufWrappers)
-	case "*int64":
-		return googleProtobufInt64Value, sp(importGoogleProtobufWrappers)
-	case "*int32":
-		return googleProtobufInt32Value, sp(importGoogleProtobufWrappers)
-	case "*uint64":
-		return googleProtobufUInt64Value, sp(importGoogleProtobufWrappers)
-	case "*uint32":
-		return googleProtobufUInt32Value, sp(importGoogleProtobufWrappers)
-	case "*float64":
-		return googleProtobufFloat64Value, sp(importGoogleProtobufWrappers)
-	case "*float32":
-		return googleProtobufFloat32Value, sp(importGoogleProtobufWrappers)
-	case "time.Time", "*time.Time":
-		return googleProtobufTimestamp, sp(importGoogleProtobufTimestamp)
-	case "[]byte":
-		t = "bytes"
-	case "int":
-		t = "int64"
-	case "uint":
-		t = "uint64"
-	default:
-		if n := types.TypeName(v); n != nil {
-			t = *n
+	if m := protoTypeMapping(v); m != nil && m.ProtoType != "" {
+		if m.ProtoImport != "" {
+			imp = sp(m.ProtoImport)
 		}
+		return m.ProtoType, imp
+	}
+	if isBytesType(v) {
+		return "bytes", nil
+	}
+	if n := types.TypeName(v); n != nil {
+		t = *n
 	}
 	if types.IsMap(v) {
 		m := types.TypeMap(v).(types.TMap)
@@ -223,6 +351,136 @@ func protoTypeName(v types.Type) (t string, imp *string) {
 	return t, nil
 }
 
+// Returns protobuf type for go type. Structures, which are declared in source package or in imported packages,
+// become messages, named types with constants become enums, other named types are replaced by their underlying types.
+// Imports of well-known types are added to imports set.
+//
+//		[]*entity.Comment -> repeated Comment
+//		map[string]Status -> map<string, Status>
+//
+func (t *protoTemplate) protoType(v types.Type, imports map[string]struct{}) string {
+	addImport := func(imp *string) {
+		if imp != nil && imports != nil {
+			imports[*imp] = struct{}{}
+		}
+	}
+	name, imp := protoTypeName(v)
+	if m := protoTypeMapping(v); (m != nil && m.ProtoType != "") || isBytesType(v) {
+		addImport(imp)
+		return name
+	}
+	switch f := v.(type) {
+	case types.TMap:
+		return fmt.Sprintf("map<%s, %s>", t.protoType(enumMapKey(t.info, f.Key), imports), t.protoType(f.Value, imports))
+	case types.TArray:
+		return "repeated " + t.protoType(f.Next, imports)
+	case types.TEllipsis:
+		return "repeated " + t.protoType(f.Next, imports)
+	case types.TPointer:
+		if f.NumberOfPointers == 1 {
+			return t.protoType(f.Next, imports)
+		}
+	case types.TName, types.TImport:
+		if findNamedType(t.info, v) != nil {
+			return name
+		}
+		goType := t.info.SourcePackageImport + "." + *types.TypeName(v)
+		if imp := types.TypeImport(v); imp != nil {
+			goType = imp.Package + "." + *types.TypeName(v)
+		}
+		if m, ok := t.known[goType]; ok {
+			return m.Name
+		}
+		if e, ok := t.knownEnums[goType]; ok {
+			return e.Name
+		}
+		if values := enumValues(t.info, v); len(values) > 0 {
+			e := &protoEnum{Name: *types.TypeName(v), GoType: goType, Values: values}
+			t.knownEnums[goType] = e
+			t.enums = append(t.enums, e)
+			return e.Name
+		}
+		strct, named := sourceDeclaration(t.info, v)
+		switch {
+		case strct != nil:
+			m := &protoMessage{Name: strct.Name, GoType: goType}
+			for _, field := range strct.Fields {
+				field.Type = qualifiedType(v, field.Type)
+				m.Fields = append(m.Fields, field)
+			}
+			t.known[goType] = m
+			t.messages = append(t.messages, m)
+			return m.Name
+		case named != nil:
+			if t.resolving[goType] {
+				t.errs = append(t.errs, fmt.Errorf("type %s is recursive and can not be declared in protobuf", goType))
+				return name
+			}
+			t.resolving[goType] = true
+			defer delete(t.resolving, goType)
+			return t.protoType(qualifiedType(v, named.Type), imports)
+		}
+	}
+	return name
+}
+
+// Types of fields and underlying types of imported named types are declared relatively to package of named type,
+// so local names are qualified with import of this package.
+func qualifiedType(named, t types.Type) types.Type {
+	imp := types.TypeImport(named)
+	if imp == nil {
+		return t
+	}
+	var qualify func(types.Type) types.Type
+	qualify = func(t types.Type) types.Type {
+		switch f := t.(type) {
+		case types.TName:
+			if types.IsBuiltin(f) {
+				return f
+			}
+			return types.TImport{Import: imp, Next: f}
+		case types.TPointer:
+			f.Next = qualify(f.Next)
+			return f
+		case types.TArray:
+			f.Next = qualify(f.Next)
+			return f
+		case types.TMap:
+			f.Key, f.Value = qualify(f.Key), qualify(f.Value)
+			return f
+		}
+		return t
+	}
+	return qualify(t)
+}
+
+// Returns reason, why field of structure is not declared in message, or empty string.
+// Fields of embedded structures are not promoted, so embedded fields are skipped too.
+func untransferableField(field types.StructField) string {
+	switch {
+	case field.Name == "":
+		return "embedded " + field.Type.String() + " is not supported"
+	case field.Name != strings.ToUpperFirst(field.Name):
+		return "unexported field is not transferred"
+	}
+	switch iface := types.TypeInterface(field.Type).(type) {
+	case types.TInterface:
+		// Empty interfaces are transferred as google.protobuf.Value.
+		if lookupTypeMapping(iface) == nil {
+			return "interface type can not be transferred with protobuf"
+		}
+	}
+	switch field.Type.(type) {
+	case *types.Function:
+		return "function type can not be transferred with protobuf"
+	case types.TChan:
+		return "channel type can not be transferred with protobuf"
+	case types.Struct:
+		return "anonymous structure is not supported, declare it outside"
+	}
+	return ""
+}
+
 func sp(s string) *string {
 	return &s
 }
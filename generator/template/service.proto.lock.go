@@ -0,0 +1,178 @@
+package template
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/devimteam/microgen/generator/write_strategy"
+)
+
+const protoLockPath = "service.proto.lock"
+
+// Lock file keeps numbers of protobuf fields between generations, so reordering, adding and removing
+// of parameters does not break wire compatibility with deployed clients.
+// Numbers of removed fields are kept to declare them as reserved.
+//
+//		{
+//		  "messages": {
+//		    "CountRequest": {
+//		      "fields": {
+//		        "symbol": 3,
+//		        "text": 1
+//		      },
+//		      "removed": {
+//		        "old_symbol": 2
+//		      }
+//		    }
+//		  }
+//		}
+//
+type protoLock struct {
+	Messages map[string]*protoLockMessage `json:"messages"`
+	// Names of messages, which are drawn by current generation.
+	used map[string]bool
+}
+
+type protoLockMessage struct {
+	Fields  map[string]int `json:"fields"`
+	Removed map[string]int `json:"removed,omitempty"`
+}
+
+func readProtoLock(path string) (*protoLock, error) {
+	l := &protoLock{used: make(map[string]bool)}
+	data, err := ioutil.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	if err == nil {
+		if err := json.Unmarshal(data, l); err != nil {
+			return nil, fmt.Errorf("%s: %v", path, err)
+		}
+	}
+	if l.Messages == nil {
+		l.Messages = make(map[string]*protoLockMessage)
+	}
+	return l, nil
+}
+
+// Returns numbers for fields of message. Fields keep numbers from lock file and new fields get the next free numbers.
+// Fields of new messages are numbered by position, as they were numbered before lock file.
+func (l *protoLock) assign(message string, fields []protoField) []int {
+	l.used[message] = true
+	numbers := make([]int, len(fields))
+	m, ok := l.Messages[message]
+	if !ok {
+		m = &protoLockMessage{Fields: make(map[string]int)}
+		l.Messages[message] = m
+		for i, field := range fields {
+			if field.Type != "" {
+				numbers[i] = i + 1
+				m.Fields[field.Name] = i + 1
+			}
+		}
+		return numbers
+	}
+	if m.Fields == nil {
+		m.Fields = make(map[string]int)
+	}
+	last := 0
+	for _, nn := range []map[string]int{m.Fields, m.Removed} {
+		for _, n := range nn {
+			if n > last {
+				last = n
+			}
+		}
+	}
+	present := make(map[string]bool)
+	for i, field := range fields {
+		if field.Type == "" {
+			continue
+		}
+		present[field.Name] = true
+		if n, ok := m.Fields[field.Name]; ok {
+			numbers[i] = n
+			continue
+		}
+		// Field, that was removed earlier, is restored with the same number.
+		if n, ok := m.Removed[field.Name]; ok {
+			delete(m.Removed, field.Name)
+			m.Fields[field.Name] = n
+			numbers[i] = n
+			continue
+		}
+		last++
+		m.Fields[field.Name] = last
+		numbers[i] = last
+	}
+	for name, n := range m.Fields {
+		if present[name] {
+			continue
+		}
+		if m.Removed == nil {
+			m.Removed = make(map[string]int)
+		}
+		delete(m.Fields, name)
+		m.Removed[name] = n
+	}
+	return numbers
+}
+
+// Returns reserved numbers and names of removed fields of message, ordered by numbers.
+//
+//		2, 5
+//		"old_symbol", "count"
+//
+func (l *protoLock) reserved(message string) (string, string) {
+	m, ok := l.Messages[message]
+	if !ok || len(m.Removed) == 0 {
+		return "", ""
+	}
+	names := make([]string, 0, len(m.Removed))
+	for name := range m.Removed {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool { return m.Removed[names[i]] < m.Removed[names[j]] })
+	nums := make([]string, len(names))
+	for i, name := range names {
+		nums[i] = strconv.Itoa(m.Removed[name])
+		names[i] = strconv.Quote(name)
+	}
+	return strings.Join(nums, ", "), strings.Join(names, ", ")
+}
+
+// Removes messages, which are not drawn anymore.
+func (l *protoLock) removeUnused() {
+	for name := range l.Messages {
+		if !l.used[name] {
+			delete(l.Messages, name)
+		}
+	}
+}
+
+func (l *protoLock) Render(w io.Writer) error {
+	data, err := json.MarshalIndent(l, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(data, '\n'))
+	return err
+}
+
+// Writes lock file after service.proto, because numbers are assigned while service.proto is rendered.
+type protoLockStrategy struct {
+	write_strategy.Strategy
+	t *protoTemplate
+}
+
+func (s protoLockStrategy) Write(renderer write_strategy.Renderer) error {
+	if err := s.Strategy.Write(renderer); err != nil {
+		return err
+	}
+	return write_strategy.NewCreateRawFileStrategy(s.t.info.OutputFilePath, protoLockPath).Write(s.t.lock)
+}
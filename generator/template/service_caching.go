@@ -132,7 +132,9 @@ func (t *cacheMiddlewareTemplate) cacheFuncBody(signature *types.Function, norma
 					for _, field := range removeErrorIfLast(signature.Results) {
 						group.Id("value").Assert(Op("*").Id(cacheEntityStructName(normalized))).Op(".").Add(structFieldName(&field))
 					}
-					group.Id(nameOfLastResultError(normalized))
+					if IsErrorLast(signature.Results) {
+						group.Id(nameOfLastResultError(normalized))
+					}
 				}),
 			)
 			g.Defer().Func().Params().Block(
@@ -145,7 +147,7 @@ func (t *cacheMiddlewareTemplate) cacheFuncBody(signature *types.Function, norma
 				),
 			).Call()
 		}
-		g.Return().Id(rec(cachingMiddlewareStructName)).Dot(_next_).Dot(signature.Name).Call(paramNames(normalized.Args))
+		g.Add(returnIfResults(normalized.Results).Id(rec(cachingMiddlewareStructName)).Dot(_next_).Dot(signature.Name).Call(paramNames(normalized.Args)))
 	}
 }
 
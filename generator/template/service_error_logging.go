@@ -85,7 +85,7 @@ func (t *errorLoggingTemplate) recoverFunc(ctx context.Context, signature *types
 
 func (t *errorLoggingTemplate) recoverFuncBody(signature *types.Function) func(g *Group) {
 	return func(g *Group) {
-		if !t.info.AllowedMethods[signature.Name] {
+		if !t.info.AllowedMethods[signature.Name] || !IsErrorLast(signature.Results) {
 			s := &Statement{}
 			if len(signature.Results) > 0 {
 				s.Return()
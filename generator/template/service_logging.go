@@ -227,7 +227,7 @@ func (t *loggingTemplate) loggingFuncBody(signature *types.Function) func(g *Gro
 				if t.calcParamAmount(signature.Name, removeErrorIfLast(signature.Results)) > 0 {
 					g.Line().List(Lit("response"), t.logResponse(normal))
 				}
-				if !mstrings.IsInStringSlice(nameOfLastResultError(signature), t.ignoreParams[signature.Name]) {
+				if IsErrorLast(signature.Results) && !mstrings.IsInStringSlice(nameOfLastResultError(signature), t.ignoreParams[signature.Name]) {
 					g.Line().List(Lit(nameOfLastResultError(signature)), Id(nameOfLastResultError(&normal.Function)))
 				}
 
@@ -235,7 +235,7 @@ func (t *loggingTemplate) loggingFuncBody(signature *types.Function) func(g *Gro
 				g.Qual(PackagePathTime, "Since").Call(Id("begin"))
 			}),
 		).Call(Qual(PackagePathTime, "Now").Call())
-		g.Return().Id(rec(serviceLoggingStructName)).Dot(_next_).Dot(signature.Name).Call(paramNames(normal.Args))
+		g.Add(returnIfResults(normal.Results).Id(rec(serviceLoggingStructName)).Dot(_next_).Dot(signature.Name).Call(paramNames(normal.Args)))
 	}
 }
 
@@ -95,14 +95,17 @@ func (t *recoverTemplate) recoverFuncBody(signature *types.Function) func(g *Gro
 			return
 		}
 		g.Defer().Func().Params().Block(
-			If(Id("r").Op(":=").Recover(), Id("r").Op("!=").Nil()).Block(
-				Id(rec(serviceRecoveringStructName)).Dot(_logger_).Dot("Log").Call(
+			If(Id("r").Op(":=").Recover(), Id("r").Op("!=").Nil()).BlockFunc(func(ifg *Group) {
+				ifg.Id(rec(serviceRecoveringStructName)).Dot(_logger_).Dot("Log").Call(
 					Lit("method"), Lit(signature.Name),
 					Lit("message"), Id("r"),
-				),
-				Id(nameOfLastResultError(signature)).Op("=").Qual(PackagePathFmt, "Errorf").Call(Lit("%v"), Id("r")),
-			),
+				)
+				// Panic can be returned only as error.
+				if IsErrorLast(signature.Results) {
+					ifg.Id(nameOfLastResultError(signature)).Op("=").Qual(PackagePathFmt, "Errorf").Call(Lit("%v"), Id("r"))
+				}
+			}),
 		).Call()
-		g.Return().Id(rec(serviceRecoveringStructName)).Dot(_next_).Dot(signature.Name).Call(paramNames(signature.Args))
+		g.Add(returnIfResults(signature.Results).Id(rec(serviceRecoveringStructName)).Dot(_next_).Dot(signature.Name).Call(paramNames(signature.Args)))
 	}
 }
@@ -59,18 +59,36 @@ func NewEndpointsClientTemplate(info *GenerationInfo) Template {
 //		}
 //
 func (t *endpointsClientTemplate) Render(ctx context.Context) write_strategy.Renderer {
-	f := NewFile("transport")
+	f := NewFilePathName(t.info.OutputPackageImport+"/"+PathTransport, "transport")
 	f.HeaderComment(t.info.FileHeader)
+	if IsGRPCDirect(t.info.Iface) {
+		f.ImportAlias(t.info.ProtobufPackageImport, "pb")
+	}
 	if Tags(ctx).HasAny(TracingMiddlewareTag) {
 		f.Comment("TraceClientEndpoints is used for tracing endpoints on client side.")
 		f.Add(t.clientTracingMiddleware()).Line()
 	}
 	for _, signature := range t.info.Iface.Methods {
 		f.Add(t.serviceEndpointMethod(ctx, signature)).Line().Line()
+		if isAdaptedMethod(signature) && t.info.AllowedMethods[signature.Name] {
+			f.Add(t.adapterMethod(ctx, signature)).Line().Line()
+		}
+	}
+	if t.hasAdaptedMethods() {
+		f.Add(t.withContextInterface(ctx))
 	}
 	return f
 }
 
+func (t *endpointsClientTemplate) hasAdaptedMethods() bool {
+	for _, signature := range t.info.Iface.Methods {
+		if isAdaptedMethod(signature) && t.info.AllowedMethods[signature.Name] {
+			return true
+		}
+	}
+	return false
+}
+
 func (endpointsClientTemplate) DefaultPath() string {
 	return filenameBuilder(PathTransport, "client")
 }
@@ -98,11 +116,75 @@ func (t *endpointsClientTemplate) ChooseStrategy(ctx context.Context) (write_str
 //		}
 //
 func (t *endpointsClientTemplate) serviceEndpointMethod(ctx context.Context, signature *types.Function) *Statement {
+	name := signature.Name
+	if isAdaptedMethod(signature) && t.info.AllowedMethods[signature.Name] {
+		name = withContextMethodName(signature)
+		signature = contextualFunction(signature)
+	}
 	normal := normalizeFunction(signature)
+	normal.Name = name
+	if IsGRPCDirect(t.info.Iface) {
+		return methodDefinitionFull(ctx, EndpointsSetName, &normal.Function).
+			BlockFunc(directClientMethodBody(ctx, t.info, signature, &normal.Function))
+	}
 	return methodDefinitionFull(ctx, EndpointsSetName, &normal.Function).
 		BlockFunc(t.serviceEndpointMethodBody(ctx, signature, &normal.Function))
 }
 
+// Render method with original signature, that calls method with context and error.
+// Transport error is dropped, when method does not return error, use CountWithContext to get it.
+//
+//		func (E EndpointsSet) Count(arg0 string) (res0 int) {
+//			res0, _ = E.CountWithContext(context.Background(), arg0)
+//			return res0
+//		}
+//
+func (t *endpointsClientTemplate) adapterMethod(ctx context.Context, signature *types.Function) *Statement {
+	normal := normalizeFunction(signature)
+	recv := strings.LastWordFromName(EndpointsSetName)
+	call := Id(recv).Dot(withContextMethodName(signature)).CallFunc(func(g *Group) {
+		if IsContextFirst(signature.Args) {
+			g.Id(firstArgName(&normal.Function))
+		} else {
+			g.Qual(PackagePathContext, "Background").Call()
+		}
+		g.Add(paramNames(RemoveContextIfFirst(normal.Args)))
+	})
+	return methodDefinitionFull(ctx, EndpointsSetName, &normal.Function).BlockFunc(func(g *Group) {
+		if IsErrorLast(signature.Results) {
+			g.Return(call)
+			return
+		}
+		if len(normal.Results) == 0 {
+			g.List(Id("_")).Op("=").Add(call)
+			return
+		}
+		g.List(paramNames(normal.Results), Id("_")).Op("=").Add(call)
+		g.Return(paramNames(normal.Results))
+	})
+}
+
+// Render interface, which is implemented by EndpointsSet and provides context and error for every method.
+//
+//		type StringServiceWithContext interface {
+//			CountWithContext(ctx context.Context, text string) (count int, err error)
+//		}
+//
+func (t *endpointsClientTemplate) withContextInterface(ctx context.Context) *Statement {
+	name := t.info.Iface.Name + "WithContext"
+	return Comment(name + " is the same as " + t.info.Iface.Name + ", but each method accepts context and returns error.").
+		Line().Type().Id(name).InterfaceFunc(func(g *Group) {
+		for _, signature := range t.info.Iface.Methods {
+			if !t.info.AllowedMethods[signature.Name] {
+				continue
+			}
+			fn := contextualFunction(signature)
+			fn.Name = withContextMethodName(signature)
+			g.Add(functionDefinition(ctx, fn))
+		}
+	})
+}
+
 // Render interface method body.
 //
 //		endpointCountRequest := CountRequest{
@@ -140,12 +222,17 @@ func (t *endpointsClientTemplate) serviceEndpointMethodBody(ctx context.Context,
 	}
 }
 
+// Render replacing of grpc status with error with the same message.
+// Other errors, e.g. restored from statuses by @grpc-error mappings, are kept as is.
+//
+//		if e, ok := status.FromError(err); ok {
+//			err = errors.New(e.Message())
+//		}
+//
 func checkGRPCError(fn *types.Function) *Statement {
 	s := &Statement{}
 	s.If(List(Id("e"), Id("ok")).Op(":=").Qual(PackagePathGoogleGRPCStatus, "FromError").Call(Id(nameOfLastResultError(fn))),
-		Id("ok").Op("||").
-			Id("e").Dot("Code").Call().Op("==").Qual(PackagePathGoogleGRPCCodes, "Internal").Op("||").
-			Id("e").Dot("Code").Call().Op("==").Qual(PackagePathGoogleGRPCCodes, "Unknown"),
+		Id("ok"),
 	).Block(
 		Id(nameOfLastResultError(fn)).Op("=").Qual("errors", "New").Call(Id("e").Dot("Message").Call()),
 	)
@@ -43,15 +43,34 @@ func responseStructName(signature *types.Function) string {
 //  	Err error         `json:"err"`
 //  }
 //
+// Inline struct and function types of params are declared as named types.
+//
+//  type SearchFilter struct {
+//  	Query string
+//  }
+//
+//  type SearchRequest struct {
+//  	Opts SearchFilter `json:"opts"`
+//  }
+//
 func (t *exchangeTemplate) Render(ctx context.Context) write_strategy.Renderer {
-	f := NewFile("transport")
+	f := NewFilePathName(t.info.OutputPackageImport+"/"+PathTransport, "transport")
 	f.HeaderComment(t.info.FileHeader)
 
+	for _, n := range namedTypes(t.info) {
+		f.Add(namedTypeDeclaration(ctx, n)).Line()
+	}
+
+	// Endpoints of direct grpc transport read and write protobuf messages, so exchanges are not needed.
+	if IsGRPCDirect(t.info.Iface) {
+		return f
+	}
 	if len(t.info.Iface.Methods) > 0 {
 		f.Type().Op("(")
 	}
 	for _, signature := range t.info.Iface.Methods {
 		if t.info.AllowedMethods[signature.Name] {
+			signature = transportFunction(t.info, signature)
 			f.Add(exchange(ctx, requestStructName(signature), RemoveContextIfFirst(signature.Args))) //.Line()
 			f.Add(exchange(ctx, responseStructName(signature), removeErrorIfLast(signature.Results))).Line()
 		}
@@ -71,7 +90,12 @@ func (exchangeTemplate) Prepare(ctx context.Context) error {
 	return nil
 }
 
+// Exchanges file is not generated for direct grpc transport, when methods do not have inline types,
+// because it would be empty.
 func (t *exchangeTemplate) ChooseStrategy(ctx context.Context) (write_strategy.Strategy, error) {
+	if IsGRPCDirect(t.info.Iface) && len(namedTypes(t.info)) == 0 {
+		return write_strategy.NewNopStrategy("", ""), nil
+	}
 	return write_strategy.NewCreateFileStrategy(t.info.OutputFilePath, t.DefaultPath()), nil
 }
 
@@ -0,0 +1,675 @@
+package template
+
+import (
+	"context"
+
+	. "github.com/dave/jennifer/jen"
+	mstrings "github.com/devimteam/microgen/generator/strings"
+	"github.com/devimteam/microgen/generator/write_strategy"
+	"github.com/vetcher/go-astra/types"
+)
+
+// Kinds of builtin types, which are encoded and decoded by generated code without reflection.
+const (
+	jsonKindString = "String"
+	jsonKindBool   = "Bool"
+	jsonKindInt    = "Int"
+	jsonKindUint   = "Uint"
+	jsonKindFloat  = "Float"
+)
+
+var jsonScalarKinds = map[string]struct {
+	kind string
+	bits int
+}{
+	"string":  {jsonKindString, 0},
+	"bool":    {jsonKindBool, 0},
+	"int":     {jsonKindInt, 0},
+	"int8":    {jsonKindInt, 8},
+	"int16":   {jsonKindInt, 16},
+	"int32":   {jsonKindInt, 32},
+	"rune":    {jsonKindInt, 32},
+	"int64":   {jsonKindInt, 64},
+	"uint":    {jsonKindUint, 0},
+	"uint8":   {jsonKindUint, 8},
+	"byte":    {jsonKindUint, 8},
+	"uint16":  {jsonKindUint, 16},
+	"uint32":  {jsonKindUint, 32},
+	"uint64":  {jsonKindUint, 64},
+	"float32": {jsonKindFloat, 32},
+	"float64": {jsonKindFloat, 64},
+}
+
+type exchangeJSONTemplate struct {
+	info *GenerationInfo
+}
+
+func NewExchangeJSONTemplate(info *GenerationInfo) Template {
+	return &exchangeJSONTemplate{
+		info: info,
+	}
+}
+
+// Renders json marshalers of exchanges.
+// Builtin scalars, pointers to them and slices of them are written without reflection,
+// other types are encoded and decoded by encoding/json, so output is the same as encoding/json produces.
+//
+//		// Code generated by microgen. DO NOT EDIT.
+//
+//		package transport
+//
+//		func (R CountRequest) MarshalJSON() ([]byte, error) {
+//			b := make([]byte, 0, 64)
+//			b = append(b, "{\"text\":"...)
+//			b = jsonAppendString(b, R.Text)
+//			b = append(b, ",\"symbol\":"...)
+//			b = jsonAppendString(b, R.Symbol)
+//			return append(b, '}'), nil
+//		}
+//
+//		func (R *CountRequest) UnmarshalJSON(data []byte) error {
+//			return jsonDecodeObject(data, "CountRequest", func(key []byte, value []byte) error {
+//				switch jsonFieldIndex(key, "text", "symbol") {
+//				case 0:
+//					if v, ok := jsonParseString(value); ok {
+//						R.Text = v
+//						return nil
+//					}
+//					return json.Unmarshal(value, &R.Text)
+//				...
+//				}
+//				return nil
+//			}, func() error {
+//				type plain CountRequest
+//				return json.Unmarshal(data, (*plain)(R))
+//			})
+//		}
+//
+func (t *exchangeJSONTemplate) Render(ctx context.Context) write_strategy.Renderer {
+	f := NewFilePathName(t.info.OutputPackageImport+"/"+PathTransport, "transport")
+	f.HeaderComment(t.info.FileHeader)
+
+	for _, signature := range t.info.Iface.Methods {
+		if !t.info.AllowedMethods[signature.Name] {
+			continue
+		}
+		signature = transportFunction(t.info, signature)
+		for _, e := range []struct {
+			name   string
+			params []types.Variable
+		}{
+			{requestStructName(signature), RemoveContextIfFirst(signature.Args)},
+			{responseStructName(signature), removeErrorIfLast(signature.Results)},
+		} {
+			f.Add(exchangeMarshalJSON(ctx, e.name, e.params)).Line().Line()
+			f.Add(exchangeUnmarshalJSON(ctx, e.name, e.params)).Line().Line()
+		}
+	}
+	f.Add(jsonHelpers())
+	return f
+}
+
+func (exchangeJSONTemplate) DefaultPath() string {
+	return filenameBuilder(PathTransport, "exchanges_json")
+}
+
+func (exchangeJSONTemplate) Prepare(ctx context.Context) error {
+	return nil
+}
+
+func (t *exchangeJSONTemplate) ChooseStrategy(ctx context.Context) (write_strategy.Strategy, error) {
+	return write_strategy.NewCreateFileStrategy(t.info.OutputFilePath, t.DefaultPath()), nil
+}
+
+// Returns kind and bit size of builtin scalar type, which is encoded without reflection.
+func jsonScalarKind(t types.Type) (string, int, bool) {
+	name, ok := t.(types.TName)
+	if !ok || !types.IsBuiltin(name) {
+		return "", 0, false
+	}
+	k, ok := jsonScalarKinds[name.TypeName]
+	return k.kind, k.bits, ok
+}
+
+// Returns type of elements of slice or pointer, which elements are builtin scalars.
+// Slices of bytes are encoded as base64 strings, so they are not returned.
+func jsonScalarElem(t types.Type) (types.Type, bool) {
+	var next types.Type
+	switch f := t.(type) {
+	case types.TPointer:
+		if f.NumberOfPointers != 1 {
+			return nil, false
+		}
+		next = f.Next
+	case types.TArray:
+		if !f.IsSlice {
+			return nil, false
+		}
+		next = f.Next
+	case types.TEllipsis:
+		next = f.Next
+	default:
+		return nil, false
+	}
+	kind, bits, ok := jsonScalarKind(next)
+	if !ok || kind == jsonKindUint && bits == 8 && !isPointer(t) {
+		return nil, false
+	}
+	return next, true
+}
+
+// Renders appending of json representation of builtin scalar.
+//
+//		b = strconv.AppendInt(b, int64(R.Count), 10)
+//
+func jsonAppendScalar(kind string, bits int, value *Statement) *Statement {
+	switch kind {
+	case jsonKindString:
+		return Id("b").Op("=").Id("jsonAppendString").Call(Id("b"), value)
+	case jsonKindBool:
+		return Id("b").Op("=").Qual(PackagePathStrconv, "AppendBool").Call(Id("b"), value)
+	case jsonKindInt:
+		return Id("b").Op("=").Qual(PackagePathStrconv, "AppendInt").Call(Id("b"), Int64().Call(value), Lit(10))
+	case jsonKindUint:
+		return Id("b").Op("=").Qual(PackagePathStrconv, "AppendUint").Call(Id("b"), Uint64().Call(value), Lit(10))
+	}
+	return If(
+		List(Id("b"), Err()).Op("=").Id("jsonAppendFloat").Call(Id("b"), Float64().Call(value), Lit(bits)),
+		Err().Op("!=").Nil(),
+	).Block(Return(Nil(), Err()))
+}
+
+// Renders MarshalJSON method of exchange.
+//
+//		func (R CountResponse) MarshalJSON() ([]byte, error) {
+//			var err error
+//			b := make([]byte, 0, 64)
+//			b = append(b, "{\"count\":"...)
+//			b = strconv.AppendInt(b, int64(R.Count), 10)
+//			b = append(b, ",\"positions\":"...)
+//			if R.Positions == nil {
+//				b = append(b, "null"...)
+//			} else {
+//				b = append(b, '[')
+//				for i, elem := range R.Positions {
+//					if i > 0 {
+//						b = append(b, ',')
+//					}
+//					b = strconv.AppendInt(b, int64(elem), 10)
+//				}
+//				b = append(b, ']')
+//			}
+//			b = append(b, ",\"comment\":"...)
+//			if b, err = jsonAppendValue(b, &R.Comment); err != nil {
+//				return nil, err
+//			}
+//			return append(b, '}'), nil
+//		}
+//
+func exchangeMarshalJSON(ctx context.Context, name string, params []types.Variable) *Statement {
+	r := rec(name)
+	var body []Code
+	needErr := false
+	for i, param := range params {
+		key := "{"
+		if i > 0 {
+			key = ","
+		}
+		key += `"` + mstrings.ToSnakeCase(param.Name) + `":`
+		body = append(body, Id("b").Op("=").Append(Id("b"), Lit(key).Op("...")))
+
+		field := Id(r).Dot(mstrings.ToUpperFirst(param.Name))
+		if kind, bits, ok := jsonScalarKind(param.Type); ok {
+			body = append(body, jsonAppendScalar(kind, bits, field))
+			needErr = needErr || kind == jsonKindFloat
+			continue
+		}
+		elem, ok := jsonScalarElem(param.Type)
+		if !ok {
+			body = append(body, If(
+				List(Id("b"), Err()).Op("=").Id("jsonAppendValue").Call(Id("b"), Op("&").Add(field)),
+				Err().Op("!=").Nil(),
+			).Block(Return(Nil(), Err())))
+			needErr = true
+			continue
+		}
+		kind, bits, _ := jsonScalarKind(elem)
+		needErr = needErr || kind == jsonKindFloat
+		var encode *Statement
+		if isPointer(param.Type) {
+			encode = jsonAppendScalar(kind, bits, Op("*").Add(field))
+		} else {
+			encode = Id("b").Op("=").Append(Id("b"), LitRune('[')).Line().
+				For(List(Id("i"), Id("elem")).Op(":=").Range().Add(field)).Block(
+				If(Id("i").Op(">").Lit(0)).Block(Id("b").Op("=").Append(Id("b"), LitRune(','))),
+				jsonAppendScalar(kind, bits, Id("elem")),
+			).Line().
+				Id("b").Op("=").Append(Id("b"), LitRune(']'))
+		}
+		body = append(body, If(field.Clone().Op("==").Nil()).Block(
+			Id("b").Op("=").Append(Id("b"), Lit("null").Op("...")),
+		).Else().Block(encode))
+	}
+	if len(params) == 0 {
+		return Func().Params(Id(r).Id(name)).Id("MarshalJSON").Params().Params(Index().Byte(), Error()).Block(
+			Return(Index().Byte().Call(Lit("{}")), Nil()),
+		)
+	}
+	return Func().Params(Id(r).Id(name)).Id("MarshalJSON").Params().Params(Index().Byte(), Error()).BlockFunc(func(g *Group) {
+		if needErr {
+			g.Var().Err().Error()
+		}
+		g.Id("b").Op(":=").Make(Index().Byte(), Lit(0), Lit(64))
+		for _, stmt := range body {
+			g.Add(stmt)
+		}
+		g.Return(Append(Id("b"), LitRune('}')), Nil())
+	})
+}
+
+// Renders decoding of field without reflection, when field is builtin scalar.
+//
+//		if v, ok := jsonParseInt(value, 0); ok {
+//			R.Count = int(v)
+//			return nil
+//		}
+//		return json.Unmarshal(value, &R.Count)
+//
+func jsonDecodeField(ctx context.Context, r string, param *types.Variable) *Statement {
+	field := Id(r).Dot(mstrings.ToUpperFirst(param.Name))
+	s := &Statement{}
+	if kind, bits, ok := jsonScalarKind(param.Type); ok {
+		var parse *Statement
+		switch kind {
+		case jsonKindString, jsonKindBool:
+			parse = Id("jsonParse" + kind).Call(Id("value"))
+		default:
+			parse = Id("jsonParse"+kind).Call(Id("value"), Lit(bits))
+		}
+		value := Id("v")
+		if name := *types.TypeName(param.Type); !mstrings.IsInStringSlice(name, []string{"string", "bool", "int64", "uint64", "float64"}) {
+			value = fieldType(ctx, param.Type, false).Call(Id("v"))
+		}
+		s.If(List(Id("v"), Id("ok")).Op(":=").Add(parse), Id("ok")).Block(
+			field.Clone().Op("=").Add(value),
+			Return(Nil()),
+		).Line()
+	}
+	return s.Return(Qual(PackagePathJson, "Unmarshal").Call(Id("value"), Op("&").Add(field)))
+}
+
+// Renders UnmarshalJSON method of exchange.
+// Fields are matched by names as encoding/json does: exact name is preferred, case-insensitive match is used otherwise.
+//
+//		func (R *CountRequest) UnmarshalJSON(data []byte) error {
+//			return jsonDecodeObject(data, "CountRequest", func(key []byte, value []byte) error {
+//				switch jsonFieldIndex(key, "text", "symbol") {
+//				case 0:
+//					...
+//				}
+//				return nil
+//			}, func() error {
+//				type plain CountRequest
+//				return json.Unmarshal(data, (*plain)(R))
+//			})
+//		}
+//
+func exchangeUnmarshalJSON(ctx context.Context, name string, params []types.Variable) *Statement {
+	r := rec(name)
+	field := Func().Params(Id("key").Index().Byte(), Id("value").Index().Byte()).Error().BlockFunc(func(g *Group) {
+		if len(params) > 0 {
+			g.Switch(Id("jsonFieldIndex").CallFunc(func(g *Group) {
+				g.Id("key")
+				for _, param := range params {
+					g.Lit(mstrings.ToSnakeCase(param.Name))
+				}
+			})).BlockFunc(func(g *Group) {
+				for i := range params {
+					g.Case(Lit(i)).Block(jsonDecodeField(ctx, r, &params[i]))
+				}
+			})
+		}
+		g.Return(Nil())
+	})
+	fallback := Func().Params().Error().Block(
+		Type().Id("plain").Id(name),
+		Return(Qual(PackagePathJson, "Unmarshal").Call(Id("data"), Parens(Op("*").Id("plain")).Call(Id(r)))),
+	)
+	return Func().Params(Id(r).Op("*").Id(name)).Id("UnmarshalJSON").Params(Id("data").Index().Byte()).Error().Block(
+		Return(Id("jsonDecodeObject").Call(Id("data"), Lit(name), field, fallback)),
+	)
+}
+
+// Renders helpers of json marshalers.
+func jsonHelpers() *Statement {
+	s := &Statement{}
+	s.Add(jsonAppendStringHelper()).Line().Line()
+	s.Add(jsonAppendFloatHelper()).Line().Line()
+	s.Add(jsonAppendValueHelper()).Line().Line()
+	s.Add(jsonFieldIndexHelper()).Line().Line()
+	s.Add(jsonDecodeObjectHelper()).Line().Line()
+	s.Add(jsonSkipSpacesHelper()).Line().Line()
+	s.Add(jsonValueEndHelper()).Line().Line()
+	s.Add(jsonParseHelpers())
+	return s
+}
+
+// Renders helper, which appends json string, escaped as encoding/json does.
+// Strings with control characters and invalid utf-8 are rare, so they are escaped by encoding/json.
+//
+//		func jsonAppendString(b []byte, s string) []byte {
+//			for i := 0; i < len(s); i++ {
+//				if s[i] < 0x20 {
+//					return jsonAppendEncoded(b, s)
+//				}
+//			}
+//			...
+//		}
+//
+func jsonAppendStringHelper() *Statement {
+	return Comment("jsonAppendString appends json string, escaped as encoding/json does.").Line().
+		Func().Id("jsonAppendString").Params(Id("b").Index().Byte(), Id("s").String()).Index().Byte().Block(
+		If(Op("!").Qual(PackagePathUnicodeUTF8, "ValidString").Call(Id("s"))).Block(
+			List(Id("data"), Id("_")).Op(":=").Qual(PackagePathJson, "Marshal").Call(Id("s")),
+			Return(Append(Id("b"), Id("data").Op("..."))),
+		),
+		For(Id("i").Op(":=").Lit(0), Id("i").Op("<").Len(Id("s")), Id("i").Op("++")).Block(
+			If(Id("s").Index(Id("i")).Op("<").Lit(0x20)).Block(
+				List(Id("data"), Id("_")).Op(":=").Qual(PackagePathJson, "Marshal").Call(Id("s")),
+				Return(Append(Id("b"), Id("data").Op("..."))),
+			),
+		),
+		Id("b").Op("=").Append(Id("b"), LitRune('"')),
+		Id("start").Op(":=").Lit(0),
+		For(Id("i").Op(":=").Lit(0), Id("i").Op("<").Len(Id("s")), Id("i").Op("++")).Block(
+			Var().Id("escaped").String(),
+			Switch(Id("s").Index(Id("i"))).Block(
+				Case(LitRune('"')).Block(Id("escaped").Op("=").Lit(`\"`)),
+				Case(LitRune('\\')).Block(Id("escaped").Op("=").Lit(`\\`)),
+				Case(LitRune('<')).Block(Id("escaped").Op("=").Lit(`\u003c`)),
+				Case(LitRune('>')).Block(Id("escaped").Op("=").Lit(`\u003e`)),
+				Case(LitRune('&')).Block(Id("escaped").Op("=").Lit(`\u0026`)),
+				Case(Lit(0xE2)),
+				Comment("U+2028 and U+2029 are escaped as encoding/json does."),
+				If(Id("i").Op("+").Lit(2).Op("<").Len(Id("s")).Op("&&").Id("s").Index(Id("i").Op("+").Lit(1)).Op("==").Lit(0x80)).Block(
+					Switch(Id("s").Index(Id("i").Op("+").Lit(2))).Block(
+						Case(Lit(0xA8)).Block(Id("escaped").Op("=").Lit(`\u2028`)),
+						Case(Lit(0xA9)).Block(Id("escaped").Op("=").Lit(`\u2029`)),
+					),
+				),
+			),
+			If(Id("escaped").Op("==").Lit("")).Block(Continue()),
+			Id("b").Op("=").Append(Id("b"), Id("s").Index(Id("start").Op(":").Id("i")).Op("...")),
+			Id("b").Op("=").Append(Id("b"), Id("escaped").Op("...")),
+			If(Id("s").Index(Id("i")).Op("==").Lit(0xE2)).Block(Id("i").Op("+=").Lit(2)),
+			Id("start").Op("=").Id("i").Op("+").Lit(1),
+		),
+		Id("b").Op("=").Append(Id("b"), Id("s").Index(Id("start").Op(":")).Op("...")),
+		Return(Append(Id("b"), LitRune('"'))),
+	)
+}
+
+// Renders helper, which appends json number, formatted as encoding/json does.
+//
+//		func jsonAppendFloat(b []byte, f float64, bits int) ([]byte, error) {
+//			...
+//			b = strconv.AppendFloat(b, f, format, -1, bits)
+//			...
+//		}
+//
+func jsonAppendFloatHelper() *Statement {
+	return Comment("jsonAppendFloat appends json number, formatted as encoding/json does.").Line().
+		Func().Id("jsonAppendFloat").Params(Id("b").Index().Byte(), Id("f").Float64(), Id("bits").Int()).Params(Index().Byte(), Error()).Block(
+		If(Qual(PackagePathMath, "IsInf").Call(Id("f"), Lit(0)).Op("||").Qual(PackagePathMath, "IsNaN").Call(Id("f"))).Block(
+			Return(Id("jsonAppendValue").Call(Id("b"), Id("f"))),
+		),
+		Id("format").Op(":=").Byte().Call(LitRune('f')),
+		If(Id("abs").Op(":=").Qual(PackagePathMath, "Abs").Call(Id("f")), Id("abs").Op("!=").Lit(0)).Block(
+			If(
+				Id("bits").Op("==").Lit(64).Op("&&").Parens(Id("abs").Op("<").Lit(1e-6).Op("||").Id("abs").Op(">=").Lit(1e21)).Op("||").Line().
+					Id("bits").Op("==").Lit(32).Op("&&").Parens(Float32().Call(Id("abs")).Op("<").Lit(1e-6).Op("||").Float32().Call(Id("abs")).Op(">=").Lit(1e21)),
+			).Block(
+				Id("format").Op("=").LitRune('e'),
+			),
+		),
+		Id("b").Op("=").Qual(PackagePathStrconv, "AppendFloat").Call(Id("b"), Id("f"), Id("format"), Lit(-1), Id("bits")),
+		If(Id("format").Op("==").LitRune('e')).Block(
+			Comment("Exponent e-09 is cleaned up to e-9."),
+			If(Id("n").Op(":=").Len(Id("b")), Id("n").Op(">=").Lit(4).Op("&&").Id("b").Index(Id("n").Op("-").Lit(4)).Op("==").LitRune('e').Op("&&").Id("b").Index(Id("n").Op("-").Lit(3)).Op("==").LitRune('-').Op("&&").Id("b").Index(Id("n").Op("-").Lit(2)).Op("==").LitRune('0')).Block(
+				Id("b").Index(Id("n").Op("-").Lit(2)).Op("=").Id("b").Index(Id("n").Op("-").Lit(1)),
+				Id("b").Op("=").Id("b").Index(Op(":").Id("n").Op("-").Lit(1)),
+			),
+		),
+		Return(Id("b"), Nil()),
+	)
+}
+
+// Renders helper, which appends value, encoded by encoding/json.
+//
+//		func jsonAppendValue(b []byte, v interface{}) ([]byte, error) {
+//			data, err := json.Marshal(v)
+//			if err != nil {
+//				return nil, err
+//			}
+//			return append(b, data...), nil
+//		}
+//
+func jsonAppendValueHelper() *Statement {
+	return Comment("jsonAppendValue appends value, encoded by encoding/json.").Line().
+		Func().Id("jsonAppendValue").Params(Id("b").Index().Byte(), Id("v").Interface()).Params(Index().Byte(), Error()).Block(
+		List(Id("data"), Err()).Op(":=").Qual(PackagePathJson, "Marshal").Call(Id("v")),
+		If(Err().Op("!=").Nil()).Block(Return(Nil(), Err())),
+		Return(Append(Id("b"), Id("data").Op("...")), Nil()),
+	)
+}
+
+// Renders helper, which returns index of field with exact name or, when it is not found, with case-insensitive name.
+//
+//		func jsonFieldIndex(key []byte, names ...string) int {
+//			...
+//		}
+//
+func jsonFieldIndexHelper() *Statement {
+	return Comment("jsonFieldIndex returns index of name, which equals to key, or which equals to key without case, as encoding/json matches fields.").Line().
+		Comment("It returns -1, when key does not match any name.").Line().
+		Func().Id("jsonFieldIndex").Params(Id("key").Index().Byte(), Id("names").Op("...").String()).Int().Block(
+		For(List(Id("i"), Id("name")).Op(":=").Range().Id("names")).Block(
+			If(String().Call(Id("key")).Op("==").Id("name")).Block(Return(Id("i"))),
+		),
+		For(List(Id("i"), Id("name")).Op(":=").Range().Id("names")).Block(
+			If(Qual(PackagePathBytes, "EqualFold").Call(Id("key"), Index().Byte().Call(Id("name")))).Block(Return(Id("i"))),
+		),
+		Return(Lit(-1)),
+	)
+}
+
+// Renders helper, which calls function for each member of json object.
+//
+//		func jsonDecodeObject(data []byte, name string, field func(key, value []byte) error, fallback func() error) error {
+//			...
+//		}
+//
+func jsonDecodeObjectHelper() *Statement {
+	return Comment("jsonDecodeObject calls field for each member of json object. Data, which is not an object, is decoded by fallback.").Line().
+		Comment("Decoding continues after type errors and the first of them is returned with name of exchange and key of member, as encoding/json does.").Line().
+		Func().Id("jsonDecodeObject").Params(
+		Id("data").Index().Byte(),
+		Id("name").String(),
+		Id("field").Func().Params(Id("key").Index().Byte(), Id("value").Index().Byte()).Error(),
+		Id("fallback").Func().Params().Error(),
+	).Error().Block(
+		Id("i").Op(":=").Id("jsonSkipSpaces").Call(Id("data"), Lit(0)),
+		If(Qual(PackagePathBytes, "HasPrefix").Call(Id("data").Index(Id("i").Op(":")), Index().Byte().Call(Lit("null")))).Block(
+			Return(Nil()),
+		),
+		If(Id("i").Op("==").Len(Id("data")).Op("||").Id("data").Index(Id("i")).Op("!=").LitRune('{')).Block(
+			Return(Id("fallback").Call()),
+		),
+		Var().Id("typeErr").Error(),
+		Id("i").Op("=").Id("jsonSkipSpaces").Call(Id("data"), Id("i").Op("+").Lit(1)),
+		For(Id("i").Op("<").Len(Id("data")).Op("&&").Id("data").Index(Id("i")).Op("!=").LitRune('}')).Block(
+			Id("end").Op(":=").Id("jsonValueEnd").Call(Id("data"), Id("i")),
+			If(Id("end").Op("<").Lit(0).Op("||").Id("data").Index(Id("i")).Op("!=").LitRune('"')).Block(
+				Return(Id("fallback").Call()),
+			),
+			Id("key").Op(":=").Id("data").Index(Id("i").Op("+").Lit(1).Op(":").Id("end").Op("-").Lit(1)),
+			If(Qual(PackagePathBytes, "IndexByte").Call(Id("key"), LitRune('\\')).Op(">=").Lit(0)).Block(
+				Var().Id("unescaped").String(),
+				If(Err().Op(":=").Qual(PackagePathJson, "Unmarshal").Call(Id("data").Index(Id("i").Op(":").Id("end")), Op("&").Id("unescaped")), Err().Op("!=").Nil()).Block(
+					Return(Err()),
+				),
+				Id("key").Op("=").Index().Byte().Call(Id("unescaped")),
+			),
+			Id("i").Op("=").Id("jsonSkipSpaces").Call(Id("data"), Id("end")),
+			If(Id("i").Op("==").Len(Id("data")).Op("||").Id("data").Index(Id("i")).Op("!=").LitRune(':')).Block(
+				Return(Id("fallback").Call()),
+			),
+			Id("i").Op("=").Id("jsonSkipSpaces").Call(Id("data"), Id("i").Op("+").Lit(1)),
+			Id("end").Op("=").Id("jsonValueEnd").Call(Id("data"), Id("i")),
+			If(Id("end").Op("<").Lit(0)).Block(
+				Return(Id("fallback").Call()),
+			),
+			If(Err().Op(":=").Id("field").Call(Id("key"), Id("data").Index(Id("i").Op(":").Id("end"))), Err().Op("!=").Nil()).Block(
+				List(Id("e"), Id("ok")).Op(":=").Err().Assert(Op("*").Qual(PackagePathJson, "UnmarshalTypeError")),
+				If(Op("!").Id("ok")).Block(
+					Return(Err()),
+				),
+				Id("e").Dot("Struct").Op("=").Id("name"),
+				Switch().Block(
+					Case(Id("e").Dot("Field").Op("==").Lit("")).Block(
+						Id("e").Dot("Field").Op("=").String().Call(Id("key")),
+					),
+					Case(Id("e").Dot("Field").Index(Lit(0)).Op("==").LitRune('.')).Block(
+						Id("e").Dot("Field").Op("=").String().Call(Id("key")).Op("+").Id("e").Dot("Field"),
+					),
+					Default().Block(
+						Id("e").Dot("Field").Op("=").String().Call(Id("key")).Op("+").Lit(".").Op("+").Id("e").Dot("Field"),
+					),
+				),
+				If(Id("typeErr").Op("==").Nil()).Block(
+					Id("typeErr").Op("=").Id("e"),
+				),
+			),
+			Id("i").Op("=").Id("jsonSkipSpaces").Call(Id("data"), Id("end")),
+			If(Id("i").Op("<").Len(Id("data")).Op("&&").Id("data").Index(Id("i")).Op("==").LitRune(',')).Block(
+				Id("i").Op("=").Id("jsonSkipSpaces").Call(Id("data"), Id("i").Op("+").Lit(1)),
+			),
+		),
+		Return(Id("typeErr")),
+	)
+}
+
+// Renders helper, which skips json whitespaces.
+//
+//		func jsonSkipSpaces(data []byte, i int) int {
+//			...
+//		}
+//
+func jsonSkipSpacesHelper() *Statement {
+	return Comment("jsonSkipSpaces returns index of first not whitespace character, starting from i.").Line().
+		Func().Id("jsonSkipSpaces").Params(Id("data").Index().Byte(), Id("i").Int()).Int().Block(
+		For(Id("i").Op("<").Len(Id("data")).Op("&&").Parens(
+			Id("data").Index(Id("i")).Op("==").LitRune(' ').Op("||").
+				Id("data").Index(Id("i")).Op("==").LitRune('\t').Op("||").
+				Id("data").Index(Id("i")).Op("==").LitRune('\n').Op("||").
+				Id("data").Index(Id("i")).Op("==").LitRune('\r'),
+		)).Block(
+			Id("i").Op("++"),
+		),
+		Return(Id("i")),
+	)
+}
+
+// Renders helper, which finds end of json value.
+//
+//		func jsonValueEnd(data []byte, i int) int {
+//			...
+//		}
+//
+func jsonValueEndHelper() *Statement {
+	return Comment("jsonValueEnd returns index after json value, which starts at i, or -1, when value is not finished.").Line().
+		Func().Id("jsonValueEnd").Params(Id("data").Index().Byte(), Id("i").Int()).Int().Block(
+		If(Id("i").Op(">=").Len(Id("data"))).Block(Return(Lit(-1))),
+		Switch(Id("data").Index(Id("i"))).Block(
+			Case(LitRune('"')),
+			For(Id("j").Op(":=").Id("i").Op("+").Lit(1), Id("j").Op("<").Len(Id("data")), Id("j").Op("++")).Block(
+				Switch(Id("data").Index(Id("j"))).Block(
+					Case(LitRune('\\')).Block(Id("j").Op("++")),
+					Case(LitRune('"')).Block(Return(Id("j").Op("+").Lit(1))),
+				),
+			),
+			Return(Lit(-1)),
+			Case(LitRune('{'), LitRune('[')),
+			Id("depth").Op(":=").Lit(0),
+			For(Id("j").Op(":=").Id("i"), Id("j").Op("<").Len(Id("data")), Id("j").Op("++")).Block(
+				Switch(Id("data").Index(Id("j"))).Block(
+					Case(LitRune('"')),
+					Id("end").Op(":=").Id("jsonValueEnd").Call(Id("data"), Id("j")),
+					If(Id("end").Op("<").Lit(0)).Block(Return(Lit(-1))),
+					Id("j").Op("=").Id("end").Op("-").Lit(1),
+					Case(LitRune('{'), LitRune('[')).Block(Id("depth").Op("++")),
+					Case(LitRune('}'), LitRune(']')),
+					Id("depth").Op("--"),
+					If(Id("depth").Op("==").Lit(0)).Block(Return(Id("j").Op("+").Lit(1))),
+				),
+			),
+			Return(Lit(-1)),
+		),
+		Id("j").Op(":=").Id("i"),
+		For(Id("j").Op("<").Len(Id("data"))).Block(
+			Switch(Id("data").Index(Id("j"))).Block(
+				Case(LitRune(','), LitRune('}'), LitRune(']'), LitRune(' '), LitRune('\t'), LitRune('\n'), LitRune('\r')).Block(
+					Return(Id("j")),
+				),
+			),
+			Id("j").Op("++"),
+		),
+		Return(Id("j")),
+	)
+}
+
+// Renders helpers, which parse json scalars without reflection.
+// Helpers return false, when value should be decoded by encoding/json, e.g. null, escaped string or value of other type.
+//
+//		func jsonParseString(value []byte) (string, bool) {
+//			...
+//		}
+//
+func jsonParseHelpers() *Statement {
+	s := &Statement{}
+	s.Comment("jsonParseString returns string without escaped characters. Other values are decoded by encoding/json.").Line()
+	s.Func().Id("jsonParseString").Params(Id("value").Index().Byte()).Params(String(), Bool()).Block(
+		If(Len(Id("value")).Op("<").Lit(2).Op("||").Id("value").Index(Lit(0)).Op("!=").LitRune('"')).Block(
+			Return(Lit(""), False()),
+		),
+		Id("value").Op("=").Id("value").Index(Lit(1).Op(":").Len(Id("value")).Op("-").Lit(1)),
+		If(Qual(PackagePathBytes, "IndexByte").Call(Id("value"), LitRune('\\')).Op(">=").Lit(0).Op("||").Op("!").Qual(PackagePathUnicodeUTF8, "Valid").Call(Id("value"))).Block(
+			Return(Lit(""), False()),
+		),
+		Return(String().Call(Id("value")), True()),
+	).Line().Line()
+	s.Comment("jsonParseBool returns boolean literal. Other values are decoded by encoding/json.").Line()
+	s.Func().Id("jsonParseBool").Params(Id("value").Index().Byte()).Params(Bool(), Bool()).Block(
+		Switch(String().Call(Id("value"))).Block(
+			Case(Lit("true")).Block(Return(True(), True())),
+			Case(Lit("false")).Block(Return(False(), True())),
+		),
+		Return(False(), False()),
+	).Line().Line()
+	for _, p := range []struct {
+		kind, parse string
+		typ         *Statement
+		args        []Code
+	}{
+		{jsonKindInt, "ParseInt", Int64(), []Code{Lit(10), Id("bits")}},
+		{jsonKindUint, "ParseUint", Uint64(), []Code{Lit(10), Id("bits")}},
+		{jsonKindFloat, "ParseFloat", Float64(), []Code{Id("bits")}},
+	} {
+		s.Comment("jsonParse" + p.kind + " returns number, which fits into bits. Other values are decoded by encoding/json.").Line()
+		s.Func().Id("jsonParse"+p.kind).Params(Id("value").Index().Byte(), Id("bits").Int()).Params(p.typ, Bool()).Block(
+			List(Id("v"), Err()).Op(":=").Qual(PackagePathStrconv, p.parse).Call(append([]Code{String().Call(Id("value"))}, p.args...)...),
+			Return(Id("v"), Err().Op("==").Nil()),
+		).Line().Line()
+	}
+	return s
+}
@@ -3,6 +3,7 @@ package template
 import (
 	"context"
 	"errors"
+	"strings"
 
 	. "github.com/dave/jennifer/jen"
 	"github.com/devimteam/microgen/generator/write_strategy"
@@ -68,11 +69,18 @@ func (t *gRPCClientTemplate) Render(ctx context.Context) write_strategy.Renderer
 					Id("addr").Op("=").Lit(t.info.ProtobufClientAddr),
 				)
 			}
+			if t.hasStreams() {
+				g.Id("client").Op(":=").Qual(t.info.ProtobufPackageImport, "New"+t.info.Iface.Name+"Client").Call(Id("conn"))
+			}
 			g.Return().Qual(t.info.OutputPackageImport+"/transport", EndpointsSetName).Values(DictFunc(func(d Dict) {
 				for _, m := range t.info.Iface.Methods {
 					if !t.info.AllowedMethods[m.Name] {
 						continue
 					}
+					if methodStreams(m).IsStream() {
+						d[Id(endpointsStructFieldName(m.Name))] = grpcClientEndpoint(t.info, m, Id(streamEndpointName(m)).Call(Id("client")))
+						continue
+					}
 					client := &Statement{}
 					client.Qual(PackagePathGoKitTransportGRPC, "NewClient").Call(
 						Line().Id("conn"), Id("addr"), Lit(m.Name),
@@ -81,7 +89,7 @@ func (t *gRPCClientTemplate) Render(ctx context.Context) write_strategy.Renderer
 						Line().Add(t.replyType(m)),
 						Line().Add(t.clientOpts(m)).Op("...").Line(),
 					).Dot("Endpoint").Call()
-					d[Id(endpointsStructFieldName(m.Name))] = client
+					d[Id(endpointsStructFieldName(m.Name))] = grpcClientEndpoint(t.info, m, client)
 				}
 			}))
 		})
@@ -101,13 +109,36 @@ func (t *gRPCClientTemplate) Render(ctx context.Context) write_strategy.Renderer
 		)
 	}
 
+	if Tags(ctx).Has(ServiceDiscoveryTag) {
+		f.Line().Add(t.clientSD(ctx))
+	}
+
+	if t.hasStreams() {
+		for _, m := range t.info.Iface.Methods {
+			if t.info.AllowedMethods[m.Name] && methodStreams(m).IsStream() {
+				f.Line().Add(t.streamEndpoint(ctx, m))
+			}
+		}
+		f.Line().Add(streamErrorHelpers())
+	}
+	f.Line().Add(grpcErrorDecoders(t.info))
+
 	return f
 }
 
+func (t *gRPCClientTemplate) hasStreams() bool {
+	for _, m := range t.info.Iface.Methods {
+		if t.info.AllowedMethods[m.Name] && methodStreams(m).IsStream() {
+			return true
+		}
+	}
+	return false
+}
+
 // Renders reply type argument
 // 		stringsvc.CountResponse{}
 func (t *gRPCClientTemplate) replyType(signature *types.Function) *Statement {
-	results := removeErrorIfLast(signature.Results)
+	results := grpcResponseParams(signature)
 	if len(results) == 0 {
 		return Qual(PackagePathEmptyProtobuf, "Empty").Values()
 	}
@@ -121,21 +152,8 @@ func (t *gRPCClientTemplate) replyType(signature *types.Function) *Statement {
 }
 
 func specialReplyType(p types.Type) *Statement {
-	name := types.TypeName(p)
-	imp := types.TypeImport(p)
-	// *string -> *wrappers.StringValue
-	if name != nil && *name == "string" && imp == nil {
-		ptr, ok := p.(types.TPointer)
-		if ok && ptr.NumberOfPointers == 1 {
-			return (&Statement{}).Qual(GolangProtobufWrappers, "StringValue").Values()
-		}
-	}
-	// *float64 -> *wrappers.DoubleValue
-	if name != nil && *name == "float64" && imp == nil {
-		ptr, ok := p.(types.TPointer)
-		if ok && ptr.NumberOfPointers == 1 {
-			return (&Statement{}).Qual(GolangProtobufWrappers, "DoubleValue").Values()
-		}
+	if m := lookupTypeMapping(p); m != nil && m.isMessage() {
+		return goTypeCode(strings.TrimPrefix(m.ProtoGoType, "*")).Values()
 	}
 	return nil
 }
@@ -145,7 +163,7 @@ func (gRPCClientTemplate) DefaultPath() string {
 }
 
 func (t *gRPCClientTemplate) Prepare(ctx context.Context) error {
-	return nil
+	return prepareGRPCErrors(t.info)
 }
 
 func (t *gRPCClientTemplate) ChooseStrategy(ctx context.Context) (write_strategy.Strategy, error) {
@@ -153,7 +171,9 @@ func (t *gRPCClientTemplate) ChooseStrategy(ctx context.Context) (write_strategy
 }
 
 func (t *gRPCClientTemplate) clientOpts(fn *types.Function) *Statement {
-	s := &Statement{}
-	s.Id("opts")
-	return s
+	opts := grpcMetadataClientOptions(t.info, fn)
+	if len(opts) == 0 {
+		return Id("opts")
+	}
+	return Append(append([]Code{Id("opts")}, opts...)...)
 }
@@ -0,0 +1,200 @@
+package template
+
+import (
+	"context"
+	"fmt"
+
+	. "github.com/dave/jennifer/jen"
+)
+
+// Render grpc client, which uses service discovery, and its options.
+//
+//		// NewGRPCClientSD is a grpc client for StringService and uses service discovery inside.
+//		// Each instance is dialed once and its connection is shared by endpoints of all methods.
+//		// Instancer may be static, e.g. sd.FixedInstancer{"localhost:8081"}.
+//		func NewGRPCClientSD(instancer sd.Instancer, logger log.Logger, opts ...GRPCClientSDOption) transport.EndpointsSet {
+//			o := grpcClientSDOptions{balancer: func(e sd.Endpointer) lb.Balancer { return lb.NewRoundRobin(e) }}
+//			for _, opt := range opts {
+//				opt(&o)
+//			}
+//			pool := &grpcConnPool{options: o, conns: make(map[string]*grpcConn)}
+//			var endpoints transport.EndpointsSet
+//			{
+//				endpointer := sd.NewEndpointer(instancer, pool.factory(func(e transport.EndpointsSet) endpoint.Endpoint {
+//					return e.CountEndpoint
+//				}), logger)
+//				endpoints.CountEndpoint = o.endpoint(endpointer)
+//			}
+//			return endpoints
+//		}
+//
+func (t *gRPCClientTemplate) clientSD(ctx context.Context) *Statement {
+	transportPkg := t.info.OutputPackageImport + "/transport"
+	endpointType := Qual(PackagePathGoKitEndpoint, "Endpoint")
+	balancerFunc := Func().Params(Qual(PackagePathGoKitSD, "Endpointer")).Qual(PackagePathGoKitLB, "Balancer")
+	s := &Statement{}
+
+	s.Comment("GRPCClientSDOption sets options of NewGRPCClientSD.").Line()
+	s.Type().Id("GRPCClientSDOption").Func().Params(Op("*").Id("grpcClientSDOptions")).Line().Line()
+
+	s.Type().Id("grpcClientSDOptions").Struct(
+		Id("dialOptions").Index().Qual(PackagePathGoogleGRPC, "DialOption"),
+		Id("clientOptions").Index().Qual(PackagePathGoKitTransportGRPC, "ClientOption"),
+		Id("balancer").Add(balancerFunc.Clone()),
+		Id("retryMax").Int(),
+		Id("retryTimeout").Qual(PackagePathTime, "Duration"),
+	).Line().Line()
+
+	option := func(name, comment string, params *Statement, body *Statement) {
+		s.Comment(comment).Line()
+		s.Func().Id(name).Add(params).Id("GRPCClientSDOption").Block(
+			Return(Func().Params(Id("o").Op("*").Id("grpcClientSDOptions")).Block(body)),
+		).Line().Line()
+	}
+	option("WithDialOptions", "WithDialOptions adds options, which are used to dial each instance, e.g. grpc.WithInsecure().",
+		Params(Id("opts").Op("...").Qual(PackagePathGoogleGRPC, "DialOption")),
+		Id("o").Dot("dialOptions").Op("=").Append(Id("o").Dot("dialOptions"), Id("opts").Op("...")),
+	)
+	option("WithClientOptions", "WithClientOptions adds options of grpc clients of each instance.",
+		Params(Id("opts").Op("...").Qual(PackagePathGoKitTransportGRPC, "ClientOption")),
+		Id("o").Dot("clientOptions").Op("=").Append(Id("o").Dot("clientOptions"), Id("opts").Op("...")),
+	)
+	option("WithBalancer", "WithBalancer sets constructor of load balancer, which selects instance for each call. Round robin is used by default.",
+		Params(Id("balancer").Add(balancerFunc.Clone())),
+		Id("o").Dot("balancer").Op("=").Id("balancer"),
+	)
+	option("WithRetry", "WithRetry makes failed calls retry on other instances at most max times while timeout is not exceeded.",
+		Params(Id("max").Int(), Id("timeout").Qual(PackagePathTime, "Duration")),
+		Id("o").Dot("retryMax").Op(",").Id("o").Dot("retryTimeout").Op("=").Id("max").Op(",").Id("timeout"),
+	)
+
+	s.Comment("Returns endpoint, which calls instances, selected by balancer.").Line()
+	s.Func().Params(Id("o").Id("grpcClientSDOptions")).Id("endpoint").Params(Id("endpointer").Qual(PackagePathGoKitSD, "Endpointer")).Add(endpointType.Clone()).Block(
+		Id("balancer").Op(":=").Id("o").Dot("balancer").Call(Id("endpointer")),
+		If(Id("o").Dot("retryMax").Op(">").Lit(0)).Block(
+			Return(Qual(PackagePathGoKitLB, "Retry").Call(Id("o").Dot("retryMax"), Id("o").Dot("retryTimeout"), Id("balancer"))),
+		),
+		Return(Func().Params(Id("ctx").Qual(PackagePathContext, "Context"), Id("request").Interface()).Params(Interface(), Error()).Block(
+			List(Id("e"), Err()).Op(":=").Id("balancer").Dot("Endpoint").Call(),
+			If(Err().Op("!=").Nil()).Block(Return(Nil(), Err())),
+			Return(Id("e").Call(Id("ctx"), Id("request"))),
+		)),
+	).Line().Line()
+
+	s.Comment(fmt.Sprintf("NewGRPCClientSD is a grpc client for %s and uses service discovery inside.", t.info.Iface.Name)).Line()
+	s.Comment("Each instance is dialed once and its connection is shared by endpoints of all methods.").Line()
+	s.Comment("Instancer may be static, e.g. sd.FixedInstancer{\"localhost:8081\"}.").Line()
+	s.Func().Id("NewGRPCClientSD").Params(
+		Id("instancer").Qual(PackagePathGoKitSD, "Instancer"),
+		Id(_logger_).Qual(PackagePathGoKitLog, "Logger"),
+		Id("opts").Op("...").Id("GRPCClientSDOption"),
+	).Qual(transportPkg, EndpointsSetName).BlockFunc(func(g *Group) {
+		g.Id("o").Op(":=").Id("grpcClientSDOptions").Values(Dict{
+			Id("balancer"): Func().Params(Id("e").Qual(PackagePathGoKitSD, "Endpointer")).Qual(PackagePathGoKitLB, "Balancer").Block(
+				Return(Qual(PackagePathGoKitLB, "NewRoundRobin").Call(Id("e"))),
+			),
+		})
+		g.For(List(Id("_"), Id("opt")).Op(":=").Range().Id("opts")).Block(
+			Id("opt").Call(Op("&").Id("o")),
+		)
+		g.Id("pool").Op(":=").Op("&").Id("grpcConnPool").Values(Dict{
+			Id("options"): Id("o"),
+			Id("conns"):   Make(Map(String()).Op("*").Id("grpcConn")),
+		})
+		g.Var().Id("endpoints").Qual(transportPkg, EndpointsSetName)
+		for _, fn := range t.info.Iface.Methods {
+			if !t.info.AllowedMethods[fn.Name] {
+				continue
+			}
+			g.Block(
+				Id("endpointer").Op(":=").Qual(PackagePathGoKitSD, "NewEndpointer").Call(
+					Id("instancer"),
+					Id("pool").Dot("factory").Call(Func().Params(Id("e").Qual(transportPkg, EndpointsSetName)).Add(endpointType.Clone()).Block(
+						Return(Id("e").Dot(endpointsStructFieldName(fn.Name))),
+					)),
+					Id(_logger_),
+				),
+				Id("endpoints").Dot(endpointsStructFieldName(fn.Name)).Op("=").Id("o").Dot("endpoint").Call(Id("endpointer")),
+			)
+		}
+		g.Return(Id("endpoints"))
+	}).Line().Line()
+
+	s.Add(t.connPool(ctx))
+	return s
+}
+
+// Render pool of connections to instances.
+//
+//		// grpcConnPool keeps one connection for each instance while endpoints of any method use it.
+//		type grpcConnPool struct {
+//			options grpcClientSDOptions
+//			mu      sync.Mutex
+//			conns   map[string]*grpcConn
+//		}
+//
+//		type grpcConn struct {
+//			conn      *grpc.ClientConn
+//			endpoints transport.EndpointsSet
+//			refs      int
+//		}
+//
+func (t *gRPCClientTemplate) connPool(ctx context.Context) *Statement {
+	transportPkg := t.info.OutputPackageImport + "/transport"
+	s := &Statement{}
+
+	s.Comment("grpcConnPool keeps one connection for each instance while endpoints of any method use it.").Line()
+	s.Type().Id("grpcConnPool").Struct(
+		Id("options").Id("grpcClientSDOptions"),
+		Id("mu").Qual(PackagePathSync, "Mutex"),
+		Id("conns").Map(String()).Op("*").Id("grpcConn"),
+	).Line().Line()
+
+	s.Type().Id("grpcConn").Struct(
+		Id("conn").Op("*").Qual(PackagePathGoogleGRPC, "ClientConn"),
+		Id("endpoints").Qual(transportPkg, EndpointsSetName),
+		Id("refs").Int(),
+	).Line().Line()
+
+	s.Comment("Returns factory, which dials instance on first use and takes endpoint of method from its client.").Line()
+	s.Comment("Returned closer closes connection, when endpoints of all methods of instance are closed.").Line()
+	s.Func().Params(Id("p").Op("*").Id("grpcConnPool")).Id("factory").Params(
+		Id("method").Func().Params(Qual(transportPkg, EndpointsSetName)).Qual(PackagePathGoKitEndpoint, "Endpoint"),
+	).Qual(PackagePathGoKitSD, "Factory").Block(
+		Return(Func().Params(Id("instance").String()).Params(Qual(PackagePathGoKitEndpoint, "Endpoint"), Qual(PackagePathIO, "Closer"), Error()).Block(
+			Id("p").Dot("mu").Dot("Lock").Call(),
+			Defer().Id("p").Dot("mu").Dot("Unlock").Call(),
+			List(Id("c"), Id("ok")).Op(":=").Id("p").Dot("conns").Index(Id("instance")),
+			If(Op("!").Id("ok")).Block(
+				List(Id("conn"), Err()).Op(":=").Qual(PackagePathGoogleGRPC, "Dial").Call(Id("instance"), Id("p").Dot("options").Dot("dialOptions").Op("...")),
+				If(Err().Op("!=").Nil()).Block(Return(Nil(), Nil(), Err())),
+				Id("c").Op("=").Op("&").Id("grpcConn").Values(Dict{
+					Id("conn"):      Id("conn"),
+					Id("endpoints"): Id("NewGRPCClient").Call(Id("conn"), Lit(""), Id("p").Dot("options").Dot("clientOptions").Op("...")),
+				}),
+				Id("p").Dot("conns").Index(Id("instance")).Op("=").Id("c"),
+			),
+			Id("c").Dot("refs").Op("++"),
+			Return(Id("method").Call(Id("c").Dot("endpoints")), Id("grpcConnRelease").Call(Func().Params().Error().Block(
+				Return(Id("p").Dot("release").Call(Id("instance"))),
+			)), Nil()),
+		)),
+	).Line().Line()
+
+	s.Func().Params(Id("p").Op("*").Id("grpcConnPool")).Id("release").Params(Id("instance").String()).Error().Block(
+		Id("p").Dot("mu").Dot("Lock").Call(),
+		Defer().Id("p").Dot("mu").Dot("Unlock").Call(),
+		List(Id("c"), Id("ok")).Op(":=").Id("p").Dot("conns").Index(Id("instance")),
+		If(Op("!").Id("ok")).Block(Return(Nil())),
+		If(Id("c").Dot("refs").Op("--"), Id("c").Dot("refs").Op(">").Lit(0)).Block(Return(Nil())),
+		Delete(Id("p").Dot("conns"), Id("instance")),
+		Return(Id("c").Dot("conn").Dot("Close").Call()),
+	).Line().Line()
+
+	s.Comment("grpcConnRelease is an io.Closer, which releases connection of instance.").Line()
+	s.Type().Id("grpcConnRelease").Func().Params().Error().Line().Line()
+	s.Func().Params(Id("r").Id("grpcConnRelease")).Id("Close").Params().Error().Block(
+		Return(Id("r").Call()),
+	)
+	return s
+}
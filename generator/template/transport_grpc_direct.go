@@ -0,0 +1,243 @@
+package template
+
+import (
+	"context"
+
+	. "github.com/dave/jennifer/jen"
+	mstrings "github.com/devimteam/microgen/generator/strings"
+	"github.com/vetcher/go-astra/types"
+)
+
+// IsGRPCDirect returns true, when endpoints read and write protobuf messages directly,
+// without request and response structures of transport package. Declared by grpc-direct tag:
+//
+//		// @microgen grpc, grpc-direct
+//
+func IsGRPCDirect(iface *types.Interface) bool {
+	return mstrings.ContainTag(mstrings.FetchTags(iface.Docs, TagMark+MicrogenMainTag), GrpcDirectTag)
+}
+
+// Returns type of protobuf message, which is transferred as request of method.
+//
+//		*pb.CountRequest
+//
+func directRequestType(info *GenerationInfo, fn *types.Function) *Statement {
+	return (&gRPCServerTemplate{info: info}).grpcServerReqStruct(fn)
+}
+
+// Returns type of protobuf message, which is transferred as response of method.
+//
+//		*pb.CountResponse
+//
+func directResponseType(info *GenerationInfo, fn *types.Function) *Statement {
+	return (&gRPCServerTemplate{info: info}).grpcServerRespStruct(fn)
+}
+
+// Returns true, when single parameter is transferred as well-known message instead of request or response message.
+func isDirectSpecialMessage(params []types.Variable) bool {
+	if len(params) != 1 {
+		return false
+	}
+	m := lookupTypeMapping(params[0].Type)
+	return m != nil && m.isMessage()
+}
+
+// Renders conversion of protobuf value to go value and returns expression of converted value.
+//
+//		reqPositions, err := ProtoToIntList(req.Positions)
+//		if err != nil {
+//			return nil, err
+//		}
+//
+func directProtoTo(ctx context.Context, g *Group, field *types.Variable, value *Statement, prefix string, errName string, onErr *Statement) *Statement {
+	if s, ok := protoValueToGolang(ctx, field, value); ok {
+		return s
+	}
+	name := prefix + mstrings.ToUpperFirst(field.Name)
+	g.List(Id(name), Id(errName)).Op(":=").Id(protoToType(field.Type, 0)).Call(value)
+	g.If(Id(errName).Op("!=").Nil()).Block(onErr)
+	return Id(name)
+}
+
+// Renders conversion of go value to protobuf value and returns expression of converted value.
+//
+//		respPositions, err := IntListToProto(res1)
+//		if err != nil {
+//			return nil, err
+//		}
+//
+func directToProto(g *Group, field *types.Variable, value *Statement, prefix string, errName string, onErr *Statement) *Statement {
+	if s, ok := golangValueToProto(field, value); ok {
+		return s
+	}
+	name := prefix + mstrings.ToUpperFirst(field.Name)
+	g.List(Id(name), Id(errName)).Op(":=").Id(typeToProto(field.Type, 0)).Call(value)
+	g.If(Id(errName).Op("!=").Nil()).Block(onErr)
+	return Id(name)
+}
+
+// Render new Endpoint body, which reads and writes protobuf messages directly.
+//
+//		return func(arg0 context.Context, request interface{}) (interface{}, error) {
+//			req := request.(*pb.CountRequest)
+//			res0, res1, res2 := svc.Count(arg0, req.Text, req.Symbol)
+//			if res2 != nil {
+//				return nil, res2
+//			}
+//			respPositions, err := IntListToProto(res1)
+//			if err != nil {
+//				return nil, err
+//			}
+//			return &pb.CountResponse{
+//				Count:     int64(res0),
+//				Positions: respPositions,
+//			}, nil
+//		}
+//
+func directEndpointBody(ctx context.Context, info *GenerationInfo, signature *normalizedFunction) *Statement {
+	ctxName := "_"
+	if IsContextFirst(signature.parent.Args) {
+		ctxName = firstArgName(&signature.Function)
+	}
+	fn := transportFunction(info, signature.parent)
+	onErr := Return(Nil(), Err())
+	return Return(Func().Params(
+		Id(ctxName).Qual(PackagePathContext, "Context"),
+		Id("request").Interface(),
+	).Params(
+		Interface(),
+		Error(),
+	).BlockFunc(func(g *Group) {
+		methodParams := RemoveContextIfFirst(fn.Args)
+		var args []Code
+		switch {
+		case isDirectSpecialMessage(methodParams):
+			g.Id("req").Op(":=").Id("request").Assert(directRequestType(info, fn))
+			args = append(args, directProtoTo(ctx, g, &methodParams[0], Id("req"), "req", "err", onErr))
+		case len(transferredParams(methodParams)) > 0:
+			g.Id("req").Op(":=").Id("request").Assert(directRequestType(info, fn))
+			fallthrough
+		default:
+			for i := range methodParams {
+				if isChanType(methodParams[i].Type) {
+					args = append(args, Nil())
+					continue
+				}
+				args = append(args, directProtoTo(ctx, g, &methodParams[i], Id("req").Dot(mstrings.ToUpperFirst(methodParams[i].Name)), "req", "err", onErr))
+			}
+		}
+		if len(args) > 0 && types.IsEllipsis(methodParams[len(methodParams)-1].Type) {
+			args[len(args)-1] = Add(args[len(args)-1]).Op("...")
+		}
+
+		call := Id("svc").Dot(signature.Name).CallFunc(func(g *Group) {
+			if IsContextFirst(signature.parent.Args) {
+				g.Id(ctxName)
+			}
+			for _, arg := range args {
+				g.Add(arg)
+			}
+		})
+		if len(signature.Results) > 0 {
+			g.Add(paramNames(signature.Results).Op(":=").Add(call))
+		} else {
+			g.Add(call)
+		}
+		if IsErrorLast(signature.parent.Results) {
+			errName := nameOfLastResultError(&signature.Function)
+			g.If(Id(errName).Op("!=").Nil()).Block(Return(Nil(), Id(errName)))
+		}
+
+		results := removeErrorIfLast(fn.Results)
+		normals := removeErrorIfLast(signature.Results)
+		switch {
+		case len(results) == 0:
+			g.Return(Op("&").Qual(PackagePathEmptyProtobuf, "Empty").Values(), Nil())
+		case isDirectSpecialMessage(results):
+			g.Return(Id(typeToProto(results[0].Type, 0)).Call(Id(normals[0].Name)))
+		default:
+			dict := Dict{}
+			for i := range results {
+				if isChanType(results[i].Type) {
+					continue
+				}
+				dict[structFieldName(&results[i])] = directToProto(g, &results[i], Id(normals[i].Name), "resp", "err", onErr)
+			}
+			g.Return(Op("&").Qual(info.ProtobufPackageImport, responseStructName(fn)).Values(dict), Nil())
+		}
+	}))
+}
+
+// Render interface method body, which sends and receives protobuf messages directly.
+//
+//		request := &pb.CountRequest{
+//			Symbol: arg2,
+//			Text:   arg1,
+//		}
+//		response, res2 := E.CountEndpoint(arg0, request)
+//		if res2 != nil {
+//			return
+//		}
+//		resp := response.(*pb.CountResponse)
+//		respPositions, res2 := ProtoToIntList(resp.Positions)
+//		if res2 != nil {
+//			return
+//		}
+//		return int(resp.Count), respPositions, res2
+//
+func directClientMethodBody(ctx context.Context, info *GenerationInfo, fn *types.Function, normal *types.Function) func(g *Group) {
+	return func(g *Group) {
+		if !info.AllowedMethods[fn.Name] {
+			g.Return()
+			return
+		}
+		tfn := transportFunction(info, fn)
+		errName := nameOfLastResultError(normal)
+		onErr := Return()
+
+		params := RemoveContextIfFirst(tfn.Args)
+		normals := RemoveContextIfFirst(normal.Args)
+		switch {
+		case len(params) == 0:
+			g.Id("request").Op(":=").Op("&").Qual(PackagePathEmptyProtobuf, "Empty").Values()
+		case isDirectSpecialMessage(params):
+			g.List(Id("request"), Id(errName)).Op(":=").Id(typeToProto(params[0].Type, 0)).Call(Id(normals[0].Name))
+			g.If(Id(errName).Op("!=").Nil()).Block(onErr)
+		default:
+			dict := Dict{}
+			for i := range params {
+				if isChanType(params[i].Type) {
+					continue
+				}
+				dict[structFieldName(&params[i])] = directToProto(g, &params[i], Id(normals[i].Name), "req", errName, onErr)
+			}
+			g.Id("request").Op(":=").Op("&").Qual(info.ProtobufPackageImport, requestStructName(tfn)).Values(dict)
+		}
+
+		g.Add(endpointResponse("response", normal)).Id(mstrings.LastWordFromName(EndpointsSetName)).Dot(endpointsStructFieldName(fn.Name)).Call(Id(firstArgName(normal)), Id("request"))
+		g.If(Id(errName).Op("!=").Nil().BlockFunc(func(ifg *Group) {
+			ifg.Add(checkGRPCError(normal))
+			ifg.Return()
+		}))
+
+		results := removeErrorIfLast(tfn.Results)
+		if len(results) == 0 {
+			g.Return(Id(errName))
+			return
+		}
+		var values []Code
+		if isDirectSpecialMessage(results) {
+			values = append(values, directProtoTo(ctx, g, &results[0], Id("response").Assert(directResponseType(info, tfn)), "resp", errName, onErr))
+		} else {
+			g.Id("resp").Op(":=").Id("response").Assert(directResponseType(info, tfn))
+			for i := range results {
+				if isChanType(results[i].Type) {
+					values = append(values, Nil())
+					continue
+				}
+				values = append(values, directProtoTo(ctx, g, &results[i], Id("resp").Dot(mstrings.ToUpperFirst(results[i].Name)), "resp", errName, onErr))
+			}
+		}
+		g.Return(append(values, Id(errName))...)
+	}
+}
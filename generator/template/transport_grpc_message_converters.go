@@ -0,0 +1,115 @@
+package template
+
+import (
+	"context"
+	"strings"
+
+	. "github.com/dave/jennifer/jen"
+	"github.com/devimteam/microgen/generator/write_strategy"
+	"github.com/vetcher/go-astra/types"
+)
+
+// Name of exported converters of method, which let other transports use protobuf messages of grpc transport.
+func messageConvertersName(fn *types.Function) string {
+	return fn.Name + "MessageConverters"
+}
+
+type gRPCMessageConvertersTemplate struct {
+	info *GenerationInfo
+}
+
+// NewGRPCMessageConvertersTemplate returns template of exported converters of exchanges to protobuf messages.
+// They are generated, when http transport sends the same messages as grpc transport.
+func NewGRPCMessageConvertersTemplate(info *GenerationInfo) Template {
+	return &gRPCMessageConvertersTemplate{
+		info: info,
+	}
+}
+
+func (t *gRPCMessageConvertersTemplate) DefaultPath() string {
+	return filenameBuilder(PathTransport, "grpc", "message_converters")
+}
+
+func (t *gRPCMessageConvertersTemplate) Prepare(ctx context.Context) error {
+	return nil
+}
+
+func (t *gRPCMessageConvertersTemplate) ChooseStrategy(ctx context.Context) (write_strategy.Strategy, error) {
+	if !hasHttpProtobuf(ctx, t.info) {
+		return write_strategy.NewNopStrategy("", ""), nil
+	}
+	return write_strategy.NewCreateFileStrategy(t.info.OutputFilePath, t.DefaultPath()), nil
+}
+
+// Render converters of methods, which refer to endpoint converters of grpc transport.
+//
+//		// MessageConverters convert request and response of method to protobuf messages of grpc transport and back.
+//		type MessageConverters struct {
+//			NewRequest     func() proto.Message
+//			NewResponse    func() proto.Message
+//			EncodeRequest  grpckit.EncodeRequestFunc
+//			DecodeRequest  grpckit.DecodeRequestFunc
+//			EncodeResponse grpckit.EncodeResponseFunc
+//			DecodeResponse grpckit.DecodeResponseFunc
+//		}
+//
+//		var CountMessageConverters = MessageConverters{
+//			NewRequest:     func() proto.Message { return &pb.CountRequest{} },
+//			NewResponse:    func() proto.Message { return &pb.CountResponse{} },
+//			EncodeRequest:  _Encode_Count_Request,
+//			DecodeRequest:  _Decode_Count_Request,
+//			EncodeResponse: _Encode_Count_Response,
+//			DecodeResponse: _Decode_Count_Response,
+//		}
+//
+func (t *gRPCMessageConvertersTemplate) Render(ctx context.Context) write_strategy.Renderer {
+	f := NewFile("transportgrpc")
+	f.HeaderComment(t.info.FileHeader)
+	f.ImportAlias(t.info.ProtobufPackageImport, "pb")
+	f.ImportAlias(PackagePathGoKitTransportGRPC, "grpckit")
+
+	f.Comment("MessageConverters convert request and response of method to protobuf messages of grpc transport and back.")
+	f.Comment("They let http transport send the same messages in application/x-protobuf bodies.")
+	f.Type().Id("MessageConverters").Struct(
+		Id("NewRequest").Func().Params().Qual(PackagePathGolangProtobufProto, "Message"),
+		Id("NewResponse").Func().Params().Qual(PackagePathGolangProtobufProto, "Message"),
+		Id("EncodeRequest").Qual(PackagePathGoKitTransportGRPC, "EncodeRequestFunc"),
+		Id("DecodeRequest").Qual(PackagePathGoKitTransportGRPC, "DecodeRequestFunc"),
+		Id("EncodeResponse").Qual(PackagePathGoKitTransportGRPC, "EncodeResponseFunc"),
+		Id("DecodeResponse").Qual(PackagePathGoKitTransportGRPC, "DecodeResponseFunc"),
+	)
+	f.Line().Var().DefsFunc(func(g *Group) {
+		for _, fn := range t.info.Iface.Methods {
+			if !t.info.AllowedMethods[fn.Name] {
+				continue
+			}
+			fn = transportFunction(t.info, fn)
+			g.Id(messageConvertersName(fn)).Op("=").Id("MessageConverters").Values(
+				Line().Id("NewRequest").Op(":").Add(newProtoMessage(t.info, grpcRequestParams(fn), requestStructName(fn))),
+				Line().Id("NewResponse").Op(":").Add(newProtoMessage(t.info, grpcResponseParams(fn), responseStructName(fn))),
+				Line().Id("EncodeRequest").Op(":").Id(encodeRequestName(fn)),
+				Line().Id("DecodeRequest").Op(":").Id(decodeRequestName(fn)),
+				Line().Id("EncodeResponse").Op(":").Id(encodeResponseName(fn)),
+				Line().Id("DecodeResponse").Op(":").Id(decodeResponseName(fn)),
+				Line(),
+			)
+		}
+	})
+	return f
+}
+
+// Returns constructor of empty message, which is transferred as request or response of method.
+//
+//		func() proto.Message { return &pb.CountRequest{} }
+//
+func newProtoMessage(info *GenerationInfo, params []types.Variable, name string) *Statement {
+	message := Op("&").Qual(info.ProtobufPackageImport, name).Values()
+	switch {
+	case len(params) == 0:
+		message = Op("&").Qual(PackagePathEmptyProtobuf, "Empty").Values()
+	case isDirectSpecialMessage(params):
+		m := lookupTypeMapping(params[0].Type)
+		message = Op("&").Add(goTypeCode(strings.TrimPrefix(m.ProtoGoType, "*"))).Values()
+	}
+	return Func().Params().Qual(PackagePathGolangProtobufProto, "Message").Block(Return(message))
+}
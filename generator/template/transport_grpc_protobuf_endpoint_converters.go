@@ -13,10 +13,6 @@ import (
 
 var (
 	defaultProtoTypes = []string{"string", "bool", "byte", "int64", "uint64", "float64", "int32", "uint32", "float32"}
-	goToProtoTypesMap = map[string]string{
-		"uint": "uint64",
-		"int":  "int64",
-	}
 	defaultGolangTypes = []string{"string", "bool", "int", "uint", "byte", "int64", "uint64", "float64", "int32", "uint32", "float32"}
 )
 
@@ -26,7 +22,9 @@ type gRPCEndpointConverterTemplate struct {
 	requestDecoders  []*types.Function
 	responseEncoders []*types.Function
 	responseDecoders []*types.Function
-	state            WriteStrategyState
+	// Methods of direct grpc transport, which messages are passed to endpoints as is.
+	passThrough []*types.Function
+	state       WriteStrategyState
 }
 
 func NewGRPCEndpointConverterTemplate(info *GenerationInfo) Template {
@@ -106,6 +104,9 @@ func encodeResponseName(f *types.Function) string {
 func (t *gRPCEndpointConverterTemplate) Render(ctx context.Context) write_strategy.Renderer {
 	f := &Statement{}
 
+	if IsGRPCDirect(t.info.Iface) {
+		f.Add(t.passThroughConverters())
+	}
 	for _, signature := range t.requestEncoders {
 		f.Line().Add(t.encodeRequest(ctx, signature))
 	}
@@ -249,6 +250,11 @@ func (t *gRPCEndpointConverterTemplate) Prepare(ctx context.Context) error {
 		if !t.info.AllowedMethods[fn.Name] {
 			continue
 		}
+		fn = transportFunction(t.info, fn)
+		if IsGRPCDirect(t.info.Iface) {
+			t.passThrough = append(t.passThrough, fn)
+			continue
+		}
 		t.requestDecoders = append(t.requestDecoders, fn)
 		t.requestEncoders = append(t.requestEncoders, fn)
 		t.responseDecoders = append(t.responseDecoders, fn)
@@ -271,6 +277,7 @@ func (t *gRPCEndpointConverterTemplate) ChooseStrategy(ctx context.Context) (wri
 	removeAlreadyExistingFunctions(file.Functions, &t.requestDecoders, decodeRequestName)
 	removeAlreadyExistingFunctions(file.Functions, &t.responseEncoders, encodeResponseName)
 	removeAlreadyExistingFunctions(file.Functions, &t.responseDecoders, decodeResponseName)
+	removeAlreadyExistingFunctions(file.Functions, &t.passThrough, decodeRequestName)
 
 	t.state = AppendStrat
 	return write_strategy.NewAppendToFileStrategy(t.info.OutputFilePath, t.DefaultPath()), nil
@@ -290,29 +297,26 @@ func isPointer(p types.Type) bool {
 // based on field type
 // Second result means can field converts to default protobuf type.
 func golangTypeToProto(ctx context.Context, structName string, field *types.Variable) (*Statement, bool) {
+	if s, ok := golangValueToProto(field, Id(structName).Dot(mstrings.ToUpperFirst(field.Name))); ok {
+		return s, true
+	}
+	return Id(structName + mstrings.ToUpperFirst(field.Name)), false
+}
+
+// Renders conversion of value to default protobuf type, when converter function is not needed.
+//		value
+// or
+//		int64(value)
+func golangValueToProto(field *types.Variable, value *Statement) (*Statement, bool) {
 	if types.IsArray(field.Type) || isPointer(field.Type) {
-		return Id(structName + mstrings.ToUpperFirst(field.Name)), false
+		return nil, false
 	} else if isDefaultProtoField(field) {
-		return Id(structName).Dot(mstrings.ToUpperFirst(field.Name)), true
-	}
-	name := types.TypeName(field.Type)
-	if name == nil {
-		return Id(structName + mstrings.ToUpperFirst(field.Name)), false
+		return value, true
 	}
-	if newType, ok := goToProtoTypesMap[*name]; ok {
-		var newField types.Type
-		newField = types.TName{
-			TypeName: newType,
-		}
-		if imp := types.TypeImport(field.Type); imp != nil {
-			newField = types.TImport{
-				Next:   newField,
-				Import: imp,
-			}
-		}
-		return fieldType(ctx, newField, false).Call(Id(structName).Dot(mstrings.ToUpperFirst(field.Name))), true
+	if m := lookupTypeMapping(field.Type); m != nil && m.isCast() {
+		return Id(m.ProtoGoType).Call(value), true
 	}
-	return Id(structName + mstrings.ToUpperFirst(field.Name)), false
+	return nil, false
 }
 
 // Renders type conversion to default golang types.
@@ -322,12 +326,25 @@ func golangTypeToProto(ctx context.Context, structName string, field *types.Vari
 // based on field type
 // Second result means can field converts to golang type.
 func protoTypeToGolang(ctx context.Context, structName string, field *types.Variable) (*Statement, bool) {
+	if s, ok := protoValueToGolang(ctx, field, Id(structName).Dot(mstrings.ToUpperFirst(field.Name))); ok {
+		return s, true
+	}
+	return Id(structName + mstrings.ToUpperFirst(field.Name)), false
+}
+
+// Renders conversion of protobuf value to default golang type, when converter function is not needed.
+//		int(value)
+//		int8(value)
+func protoValueToGolang(ctx context.Context, field *types.Variable, value *Statement) (*Statement, bool) {
 	if types.IsArray(field.Type) || isPointer(field.Type) {
-		return Id(structName + mstrings.ToUpperFirst(field.Name)), false
+		return nil, false
 	} else if isDefaultGolangField(field) {
-		return fieldType(ctx, field.Type, false).Call(Id(structName).Dot(mstrings.ToUpperFirst(field.Name))), true
+		return fieldType(ctx, field.Type, false).Call(value), true
 	}
-	return Id(structName + mstrings.ToUpperFirst(field.Name)), false
+	if m := lookupTypeMapping(field.Type); m != nil && m.isCast() {
+		return fieldType(ctx, field.Type, false).Call(value), true
+	}
+	return nil, false
 }
 
 func isDefaultProtoField(field *types.Variable) bool {
@@ -371,14 +388,14 @@ func convertCustomType(structName, converterName string, field *types.Variable)
 //		}
 //
 func (t *gRPCEndpointConverterTemplate) encodeRequest(ctx context.Context, signature *types.Function) *Statement {
-	methodParams := RemoveContextIfFirst(signature.Args)
+	methodParams := grpcRequestParams(signature)
 	fullName := "request"
 	shortName := "req"
 	return Line().Func().Id(encodeRequestName(signature)).Params(ctx_contextContext, Id(fullName).Interface()).
 		Params(Interface(), Error()).BlockFunc(
 		func(group *Group) {
 			if len(methodParams) == 1 {
-				sp := specialEndpointConverterToProto(methodParams[0], signature, requestStructName, t.info.SourcePackageImport, fullName, shortName)
+				sp := specialEndpointConverterToProto(methodParams[0], signature, requestStructName, t.info.OutputPackageImport+"/transport", fullName, shortName)
 				if sp != nil {
 					group.Add(sp)
 					return
@@ -388,9 +405,13 @@ func (t *gRPCEndpointConverterTemplate) encodeRequest(ctx context.Context, signa
 				group.If(Id(fullName).Op("==").Nil()).Block(
 					Return(Nil(), Qual(PackagePathErrors, "New").Call(Lit("nil "+requestStructName(signature)))),
 				)
+				if len(transferredParams(methodParams)) == 0 {
+					group.Return(Op("&").Qual(t.info.ProtobufPackageImport, requestStructName(signature)).Values(), Nil())
+					return
+				}
 				group.Id(shortName).Op(":=").Id(fullName).Assert(Op("*").Qual(t.info.OutputPackageImport+"/transport", requestStructName(signature)))
 				for _, field := range methodParams {
-					if _, ok := golangTypeToProto(ctx, "", &field); !ok {
+					if _, ok := golangTypeToProto(ctx, "", &field); !ok && !isChanType(field.Type) {
 						group.Add(convertCustomType(shortName, typeToProto(field.Type, 0), &field))
 					}
 				}
@@ -415,6 +436,9 @@ func (t *gRPCEndpointConverterTemplate) grpcEndpointConvReturn(
 	}
 	return Op("&").Qual(pkg, strNameFn(fn)).Values(DictFunc(func(dict Dict) {
 		for _, field := range methodParams {
+			if isChanType(field.Type) {
+				continue
+			}
 			req, _ := typeToProtoFn(ctx, rec, &field)
 			dict[structFieldName(&field)] = Line().Add(req)
 		}
@@ -436,13 +460,13 @@ func (t *gRPCEndpointConverterTemplate) grpcEndpointConvReturn(
 //		}
 //
 func (t *gRPCEndpointConverterTemplate) encodeResponse(ctx context.Context, signature *types.Function) *Statement {
-	methodResults := removeErrorIfLast(signature.Results)
+	methodResults := grpcResponseParams(signature)
 	fullName := "response"
 	shortName := "resp"
 	return Line().Func().Id(encodeResponseName(signature)).Call(ctx_contextContext, Id(fullName).Interface()).Params(Interface(), Error()).BlockFunc(
 		func(group *Group) {
 			if len(methodResults) == 1 {
-				sp := specialEndpointConverterToProto(methodResults[0], signature, responseStructName, t.info.SourcePackageImport+"/transport", fullName, shortName)
+				sp := specialEndpointConverterToProto(methodResults[0], signature, responseStructName, t.info.OutputPackageImport+"/transport", fullName, shortName)
 				if sp != nil {
 					group.Add(sp)
 					return
@@ -452,9 +476,13 @@ func (t *gRPCEndpointConverterTemplate) encodeResponse(ctx context.Context, sign
 				group.If(Id(fullName).Op("==").Nil()).Block(
 					Return(Nil(), Qual(PackagePathErrors, "New").Call(Lit("nil "+responseStructName(signature)))),
 				)
+				if len(transferredParams(methodResults)) == 0 {
+					group.Return(Op("&").Qual(t.info.ProtobufPackageImport, responseStructName(signature)).Values(), Nil())
+					return
+				}
 				group.Id(shortName).Op(":=").Id(fullName).Assert(Op("*").Qual(t.info.OutputPackageImport+"/transport", responseStructName(signature)))
 				for _, field := range methodResults {
-					if _, ok := golangTypeToProto(ctx, "", &field); !ok {
+					if _, ok := golangTypeToProto(ctx, "", &field); !ok && !isChanType(field.Type) {
 						group.Add(convertCustomType(shortName, typeToProto(field.Type, 0), &field))
 					}
 				}
@@ -475,13 +503,13 @@ func (t *gRPCEndpointConverterTemplate) encodeResponse(ctx context.Context, sign
 //		}
 //
 func (t *gRPCEndpointConverterTemplate) decodeRequest(ctx context.Context, signature *types.Function) *Statement {
-	methodParams := RemoveContextIfFirst(signature.Args)
+	methodParams := grpcRequestParams(signature)
 	fullName := "request"
 	shortName := "req"
 	return Line().Func().Id(decodeRequestName(signature)).Call(ctx_contextContext, Id(fullName).Interface()).Params(Interface(), Error()).BlockFunc(
 		func(group *Group) {
 			if len(methodParams) == 1 {
-				sp := specialEndpointConverterFromProto(methodParams[0], signature, requestStructName, t.info.SourcePackageImport, fullName, shortName)
+				sp := specialEndpointConverterFromProto(methodParams[0], signature, requestStructName, t.info.OutputPackageImport+"/transport", fullName, shortName)
 				if sp != nil {
 					group.Add(sp)
 					return
@@ -491,9 +519,13 @@ func (t *gRPCEndpointConverterTemplate) decodeRequest(ctx context.Context, signa
 				group.If(Id(fullName).Op("==").Nil()).Block(
 					Return(Nil(), Qual(PackagePathErrors, "New").Call(Lit("nil "+requestStructName(signature)))),
 				)
+				if len(transferredParams(methodParams)) == 0 {
+					group.Return(Op("&").Qual(t.info.OutputPackageImport+"/transport", requestStructName(signature)).Values(), Nil())
+					return
+				}
 				group.Id(shortName).Op(":=").Id(fullName).Assert(Op("*").Qual(t.info.ProtobufPackageImport, requestStructName(signature)))
 				for _, field := range methodParams {
-					if _, ok := protoTypeToGolang(ctx, "", &field); !ok {
+					if _, ok := protoTypeToGolang(ctx, "", &field); !ok && !isChanType(field.Type) {
 						group.Add(convertCustomType(shortName, protoToType(field.Type, 0), &field))
 					}
 				}
@@ -518,13 +550,13 @@ func (t *gRPCEndpointConverterTemplate) decodeRequest(ctx context.Context, signa
 //		}
 //
 func (t *gRPCEndpointConverterTemplate) decodeResponse(ctx context.Context, signature *types.Function) *Statement {
-	methodResults := removeErrorIfLast(signature.Results)
+	methodResults := grpcResponseParams(signature)
 	fullName := "response"
 	shortName := "resp"
 	return Line().Func().Id(decodeResponseName(signature)).Call(ctx_contextContext, Id(fullName).Interface()).Params(Interface(), Error()).BlockFunc(
 		func(group *Group) {
 			if len(methodResults) == 1 {
-				sp := specialEndpointConverterFromProto(methodResults[0], signature, responseStructName, t.info.SourcePackageImport+"/transport", fullName, shortName)
+				sp := specialEndpointConverterFromProto(methodResults[0], signature, responseStructName, t.info.OutputPackageImport+"/transport", fullName, shortName)
 				if sp != nil {
 					group.Add(sp)
 					return
@@ -534,9 +566,13 @@ func (t *gRPCEndpointConverterTemplate) decodeResponse(ctx context.Context, sign
 				group.If(Id(fullName).Op("==").Nil()).Block(
 					Return(Nil(), Qual(PackagePathErrors, "New").Call(Lit("nil "+responseStructName(signature)))),
 				)
+				if len(transferredParams(methodResults)) == 0 {
+					group.Return(Op("&").Qual(t.info.OutputPackageImport+"/transport", responseStructName(signature)).Values(), Nil())
+					return
+				}
 				group.Id(shortName).Op(":=").Id(fullName).Assert(Op("*").Qual(t.info.ProtobufPackageImport, responseStructName(signature)))
 				for _, field := range methodResults {
-					if _, ok := protoTypeToGolang(ctx, "", &field); !ok {
+					if _, ok := protoTypeToGolang(ctx, "", &field); !ok && !isChanType(field.Type) {
 						group.Add(convertCustomType(shortName, protoToType(field.Type, 0), &field))
 					}
 				}
@@ -546,6 +582,14 @@ func (t *gRPCEndpointConverterTemplate) decodeResponse(ctx context.Context, sign
 	).Line()
 }
 
+// Renders converter of request or response with single parameter, which is transferred as well-known message.
+//
+//		if request == nil {
+//			return nil, nil
+//		}
+//		req := request.(*transport.CountRequest)
+//		return PtrStringToProto(req.Text)
+//
 func specialEndpointConverterToProto(
 	v types.Variable,
 	fn *types.Function,
@@ -554,24 +598,29 @@ func specialEndpointConverterToProto(
 	fullName string,
 	shortName string,
 ) *Statement {
-	name := types.TypeName(v.Type)
-	imp := types.TypeImport(v.Type)
-	// *string -> *wrappers.StringValue
-	if name != nil && *name == "string" && imp == nil {
-		_, ok := v.Type.(types.TPointer)
-		if ok {
-			s := If(Id(fullName).Op("==").Nil()).Block(
-				Return(Nil(), Nil()),
-			)
-			sp := Op("*").Qual(pkg, strNameFn(fn))
-			s.Line().Id(shortName).Op(":=").Id(fullName).Assert(sp)
-			s.Line().Return(Op("&").Qual(GolangProtobufWrappers, "StringValue").Values(Dict{Id("Value"): Op("*").Id(shortName).Op(".").Add(structFieldName(&v))}), Nil())
-			return s
-		}
+	if m := lookupTypeMapping(v.Type); m == nil || !m.isMessage() {
+		return nil
 	}
-	return nil
+	s := If(Id(fullName).Op("==").Nil()).Block(
+		Return(Nil(), Nil()),
+	)
+	s.Line().Id(shortName).Op(":=").Id(fullName).Assert(Op("*").Qual(pkg, strNameFn(fn)))
+	s.Line().Return(Id(typeToProto(v.Type, 0)).Call(Id(shortName).Dot(mstrings.ToUpperFirst(v.Name))))
+	return s
 }
 
+// Renders converter of well-known message to request or response with single parameter.
+//
+//		if request == nil {
+//			return nil, nil
+//		}
+//		req := request.(*wrappers.StringValue)
+//		reqText, err := ProtoToPtrString(req)
+//		if err != nil {
+//			return nil, err
+//		}
+//		return &transport.CountRequest{Text: reqText}, nil
+//
 func specialEndpointConverterFromProto(
 	v types.Variable,
 	fn *types.Function,
@@ -580,20 +629,41 @@ func specialEndpointConverterFromProto(
 	fullName string,
 	shortName string,
 ) *Statement {
-	name := types.TypeName(v.Type)
-	imp := types.TypeImport(v.Type)
-	// *string <- *wrappers.StringValue
-	if name != nil && *name == "string" && imp == nil {
-		_, ok := v.Type.(types.TPointer)
-		if ok {
-			s := If(Id(fullName).Op("==").Nil()).Block(
-				Return(Nil(), Nil()),
-			)
-			sp := Op("*").Qual(GolangProtobufWrappers, "StringValue")
-			s.Line().Id(shortName).Op(":=").Id(fullName).Assert(sp)
-			s.Line().Return(Op("&").Qual(pkg, strNameFn(fn)).Values(Dict{structFieldName(&v): Op("&").Id(shortName).Dot("Value")}), Nil())
-			return s
+	m := lookupTypeMapping(v.Type)
+	if m == nil || !m.isMessage() {
+		return nil
+	}
+	s := If(Id(fullName).Op("==").Nil()).Block(
+		Return(Nil(), Nil()),
+	)
+	s.Line().Id(shortName).Op(":=").Id(fullName).Assert(m.protoGoType())
+	s.Line().List(Id(shortName+mstrings.ToUpperFirst(v.Name)), Err()).Op(":=").Id(protoToType(v.Type, 0)).Call(Id(shortName))
+	s.Line().If(Err().Op("!=").Nil()).Block(
+		Return(Nil(), Err()),
+	)
+	s.Line().Return(Op("&").Qual(pkg, strNameFn(fn)).Values(Dict{structFieldName(&v): Id(shortName + mstrings.ToUpperFirst(v.Name))}), Nil())
+	return s
+}
+
+// Renders converters of direct grpc transport, which pass messages as is, because endpoints read and write them.
+//
+//		func _Encode_Count_Request(ctx context.Context, request interface{}) (interface{}, error) {
+//			return request, nil
+//		}
+//
+func (t *gRPCEndpointConverterTemplate) passThroughConverters() *Statement {
+	s := &Statement{}
+	for _, signature := range t.passThrough {
+		for _, c := range []struct{ name, arg string }{
+			{encodeRequestName(signature), "request"},
+			{encodeResponseName(signature), "response"},
+			{decodeRequestName(signature), "request"},
+			{decodeResponseName(signature), "response"},
+		} {
+			s.Line().Func().Id(c.name).Params(ctx_contextContext, Id(c.arg).Interface()).Params(Interface(), Error()).Block(
+				Return(Id(c.arg), Nil()),
+			).Line()
 		}
 	}
-	return nil
+	return s
 }
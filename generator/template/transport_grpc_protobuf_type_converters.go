@@ -8,6 +8,7 @@ import (
 	"strings"
 
 	. "github.com/dave/jennifer/jen"
+	"github.com/devimteam/microgen/generator/proto"
 	mstrings "github.com/devimteam/microgen/generator/strings"
 	"github.com/devimteam/microgen/generator/write_strategy"
 	"github.com/devimteam/microgen/logger"
@@ -16,15 +17,20 @@ import (
 
 const (
 	GolangProtobufPtypesTimestamp = "github.com/golang/protobuf/ptypes/timestamp"
+	GolangProtobufPtypesDuration  = "github.com/golang/protobuf/ptypes/duration"
 	JsonbPackage                  = "github.com/sas1024/gorm-jsonb/jsonb"
 	GolangProtobufPtypes          = "github.com/golang/protobuf/ptypes"
 	GolangProtobufWrappers        = "github.com/golang/protobuf/ptypes/wrappers"
+	GolangProtobufPtypesStruct    = "github.com/golang/protobuf/ptypes/struct"
+	GolangProtobufPtypesAny       = "github.com/golang/protobuf/ptypes/any"
 )
 
 type stubGRPCTypeConverterTemplate struct {
 	info                      *GenerationInfo
 	alreadyRenderedConverters []string
 	state                     WriteStrategyState
+	// Types, which converters are used by filled converters.
+	nested []types.Variable
 }
 
 func NewStubGRPCTypeConverterTemplate(info *GenerationInfo) Template {
@@ -33,42 +39,37 @@ func NewStubGRPCTypeConverterTemplate(info *GenerationInfo) Template {
 	}
 }
 
+// Returns go type of protobuf field for types from registry of type mappings,
+// which are not converted by cast.
+//
+//		*timestamp.Timestamp
+//
 func specialTypeConverter(p types.Type) *Statement {
-	name := types.TypeName(p)
-	imp := types.TypeImport(p)
-	// error -> string
-	if name != nil && *name == "error" && imp == nil {
-		return Id("string")
-	}
-	// time.Time -> timestamp.Timestamp
-	if name != nil && *name == "Time" && imp != nil && imp.Package == "time" {
-		if types.TypeArray(p) == nil { // ignore []time.Time case
-			return Op("*").Qual(GolangProtobufPtypesTimestamp, "Timestamp")
-		}
-	}
-	// jsonb.JSONB -> string
-	if name != nil && *name == "JSONB" && imp != nil && imp.Package == JsonbPackage {
-		return Id("string")
+	m := lookupTypeMapping(p)
+	if m == nil || m.ProtoGoType == "" || m.isCast() {
+		return nil
 	}
-	// *string -> *wrappers.StringValue
-	if name != nil && *name == "string" && imp == nil {
-		_, ok := p.(types.TPointer)
-		if ok {
-			return Op("*").Qual(GolangProtobufWrappers, "StringValue")
-		}
-	}
-	// *float64 -> *wrappers.DoubleValue
-	if name != nil && *name == "float64" && imp == nil {
-		ptr, ok := p.(types.TPointer)
-		if ok && ptr.NumberOfPointers == 1 {
-			return Op("*").Qual(GolangProtobufWrappers, "DoubleValue")
-		}
-	}
-	return nil
+	return m.protoGoType()
 }
 
 func converterToProtoBody(field *types.Variable) Code {
 	s := &Statement{}
+	if isWrapperTypeMapping(field.Type) {
+		// if value == nil {
+		//	return nil, nil
+		// }
+		// return &wrappers.StringValue{Value: *value}, nil
+		m := lookupTypeMapping(field.Type)
+		s.If(Id(mstrings.ToLowerFirst(field.Name)).Op("==").Nil()).Block(
+			Return().List(Nil(), Nil()),
+		)
+		value := Op("*").Id(mstrings.ToLowerFirst(field.Name))
+		if valueType, cast := wrapperValueType(m); cast {
+			value = Id(valueType).Call(value)
+		}
+		s.Line().Return().List(Op("&").Add(goTypeCode(strings.TrimPrefix(m.ProtoGoType, "*"))).Values(Dict{Id("Value"): value}), Nil())
+		return s
+	}
 	switch typeToProto(field.Type, 0) {
 	case "ErrorToProto":
 		s.If(Id(mstrings.ToLowerFirst(field.Name))).Op("==").Nil().Block(
@@ -77,19 +78,15 @@ func converterToProtoBody(field *types.Variable) Code {
 		s.Return().List(Id(mstrings.ToLowerFirst(field.Name)).Dot("Error").Call(), Nil())
 	case "ByteListToProto", "ListByteToProto":
 		s.Return().List(Id(mstrings.ToLowerFirst(field.Name)), Nil())
-	case "TimeTimeToProto":
-		s.Return().Qual(GolangProtobufPtypes, "TimestampProto").Call(Id(field.Name))
+	case "TimeDurationToProto":
+		s.Return().List(Qual(GolangProtobufPtypes, "DurationProto").Call(Id(mstrings.ToLowerFirst(field.Name))), Nil())
 	case "ListStringToProto", "SliceStringToProto":
 		s.Return().List(Id(mstrings.ToLowerFirst(field.Name)), Nil())
-	//	if str == "" {
-	//		return nil
-	//	}
-	//	return &wrappers.StringValue{Value: str}
-	case "PtrStringToProto":
+	case "PtrTimeTimeToProto":
 		s.If(Id(mstrings.ToLowerFirst(field.Name)).Op("==").Nil()).Block(
 			Return().List(Nil(), Nil()),
 		)
-		s.Line().Return().List(Op("&").Qual(GolangProtobufWrappers, "StringValue").Block(Dict{Id("Value"): Op("*").Id(mstrings.ToLowerFirst(field.Name)).Op(",")}), Nil())
+		s.Line().Return().Qual(GolangProtobufPtypes, "TimestampProto").Call(Op("*").Id(mstrings.ToLowerFirst(field.Name)))
 	default:
 		s.Panic(Lit("function not provided")).Comment("// TODO: provide converter")
 	}
@@ -98,6 +95,21 @@ func converterToProtoBody(field *types.Variable) Code {
 
 func converterProtoToBody(field *types.Variable) Code {
 	s := &Statement{}
+	if isWrapperTypeMapping(field.Type) {
+		s.If(Id("proto" + mstrings.ToUpperFirst(field.Name)).Op("==").Nil()).Block(
+			Return().List(Nil(), Nil()),
+		)
+		m := lookupTypeMapping(field.Type)
+		if _, cast := wrapperValueType(m); cast {
+			// value := int(protoValue.Value)
+			// return &value, nil
+			s.Line().Id("value").Op(":=").Id(strings.TrimPrefix(m.GoType, "*")).Call(Id("proto" + mstrings.ToUpperFirst(field.Name)).Dot("Value"))
+			s.Line().Return().List(Op("&").Id("value"), Nil())
+			return s
+		}
+		s.Line().Return().List(Op("&").Id("proto"+mstrings.ToUpperFirst(field.Name)).Dot("Value"), Nil())
+		return s
+	}
 	switch protoToType(field.Type, 0) {
 	case "ProtoToError":
 		s.If().Id("proto" + mstrings.ToUpperFirst(field.Name)).Op("==").Lit("").Block(
@@ -106,21 +118,33 @@ func converterProtoToBody(field *types.Variable) Code {
 		s.Return().List(Qual("errors", "New").Call(Id("proto"+mstrings.ToUpperFirst(field.Name))), Nil())
 	case "ProtoToByteList", "ProtoToListByte":
 		s.Return().List(Id("proto"+mstrings.ToUpperFirst(field.Name)), Nil())
-	case "ProtoToTimeTime":
-		s.Return().Qual(GolangProtobufPtypes, "Timestamp").Call(Id("proto" + mstrings.ToUpperFirst(field.Name)))
 	case "ProtoToListString", "ProtoToSliceString":
 		s.Return().List(Id("proto"+mstrings.ToUpperFirst(field.Name)), Nil())
-	case "ProtoToPtrString":
+	case "ProtoToPtrTimeTime":
 		s.If(Id("proto" + mstrings.ToUpperFirst(field.Name)).Op("==").Nil()).Block(
 			Return().List(Nil(), Nil()),
 		)
-		s.Line().Return().List(Op("&").Id("proto"+mstrings.ToUpperFirst(field.Name)).Dot("Value"), Nil())
+		s.Line().List(Id("t"), Err()).Op(":=").Qual(GolangProtobufPtypes, "Timestamp").Call(Id("proto" + mstrings.ToUpperFirst(field.Name)))
+		s.Line().Return().List(Op("&").Id("t"), Err())
 	default:
 		s.Panic(Lit("function not provided")).Comment("// TODO: provide converter")
 	}
 	return s
 }
 
+// Returns go type of Value field of wrapper and true, when it differs from go type of mapping and value is converted by cast.
+func wrapperValueType(m *TypeMapping) (string, bool) {
+	_, _, wrapper := splitGoType(m.ProtoGoType)
+	valueType := wrapperValueTypes[wrapper]
+	return valueType, valueType != "" && valueType != strings.TrimPrefix(m.GoType, "*")
+}
+
+// Pointer to scalar type, which is transferred as wrapper from registry of type mappings.
+func isWrapperTypeMapping(p types.Type) bool {
+	m := lookupTypeMapping(p)
+	return m != nil && isPointer(p) && strings.HasPrefix(m.ProtoGoType, "*"+GolangProtobufWrappers+".")
+}
+
 // Render whole file with protobuf converters.
 //
 //		// This file was automatically generated by "microgen" utility.
@@ -141,8 +165,12 @@ func (t *stubGRPCTypeConverterTemplate) Render(ctx context.Context) write_strate
 		if !t.info.AllowedMethods[signature.Name] {
 			continue
 		}
+		signature = transportFunction(t.info, signature)
 		args := append(RemoveContextIfFirst(signature.Args), removeErrorIfLast(signature.Results)...)
 		for _, field := range args {
+			if isChanType(field.Type) {
+				field = streamElem(&field)
+			}
 			if _, ok := golangTypeToProto(ctx, "", &field); !ok && !mstrings.IsInStringSlice(typeToProto(field.Type, 0), t.alreadyRenderedConverters) {
 				f.Line().Add(t.stubConverterToProto(ctx, &field)).Line()
 				t.alreadyRenderedConverters = append(t.alreadyRenderedConverters, typeToProto(field.Type, 0))
@@ -153,14 +181,30 @@ func (t *stubGRPCTypeConverterTemplate) Render(ctx context.Context) write_strate
 			}
 		}
 	}
+	// Filled converters may use converters for fields of structures, slices elements and maps values.
+	for i := 0; i < len(t.nested); i++ {
+		field := t.nested[i]
+		if !mstrings.IsInStringSlice(typeToProto(field.Type, 0), t.alreadyRenderedConverters) {
+			f.Line().Add(t.stubConverterToProto(ctx, &field)).Line()
+			t.alreadyRenderedConverters = append(t.alreadyRenderedConverters, typeToProto(field.Type, 0))
+		}
+		if !mstrings.IsInStringSlice(protoToType(field.Type, 0), t.alreadyRenderedConverters) {
+			f.Line().Add(t.stubConverterProtoTo(ctx, &field)).Line()
+			t.alreadyRenderedConverters = append(t.alreadyRenderedConverters, protoToType(field.Type, 0))
+		}
+	}
 
 	if t.state == AppendStrat {
 		return f
 	}
 
 	file := NewFile("transportgrpc")
+	if IsGRPCDirect(t.info.Iface) {
+		file = NewFilePathName(t.info.OutputPackageImport+"/"+PathTransport, "transport")
+	}
 	file.ImportAlias(t.info.ProtobufPackageImport, "pb")
 	file.ImportAlias(t.info.SourcePackageImport, serviceAlias)
+	file.ImportAlias(GolangProtobufPtypesStruct, "structpb")
 	file.HeaderComment(t.info.FileHeader)
 	file.PackageComment(`It is better for you if you do not change functions names!`)
 	file.PackageComment(`This file will never be overwritten.`)
@@ -169,7 +213,11 @@ func (t *stubGRPCTypeConverterTemplate) Render(ctx context.Context) write_strate
 	return file
 }
 
-func (stubGRPCTypeConverterTemplate) DefaultPath() string {
+// Endpoints of direct grpc transport convert types by themselves, so converters are placed to transport package.
+func (t stubGRPCTypeConverterTemplate) DefaultPath() string {
+	if IsGRPCDirect(t.info.Iface) {
+		return filenameBuilder(PathTransport, "protobuf_type_converters")
+	}
 	return filenameBuilder(PathTransport, "grpc", "protobuf_type_converters")
 }
 
@@ -209,7 +257,13 @@ func (t *stubGRPCTypeConverterTemplate) stubConverterToProto(ctx context.Context
 	return Func().Id(typeToProto(field.Type, 0)).
 		Params(Id(mstrings.ToLowerFirst(field.Name)).Add(fieldType(ctx, field.Type, false))).
 		Params(Add(t.protoFieldType(ctx, field.Type)), Error()).
-		Block(converterToProtoBody(field))
+		BlockFunc(func(g *Group) {
+			if body := t.filledConverterToProto(ctx, field); body != nil {
+				g.Add(body)
+				return
+			}
+			g.Add(converterToProtoBody(field))
+		})
 }
 
 // Render stub method for protobuf to golang converter.
@@ -222,61 +276,453 @@ func (t *stubGRPCTypeConverterTemplate) stubConverterProtoTo(ctx context.Context
 	return Func().Id(protoToType(field.Type, 0)).
 		Params(Id("proto"+mstrings.ToUpperFirst(field.Name)).Add(t.protoFieldType(ctx, field.Type))).
 		Params(Add(fieldType(ctx, field.Type, false)), Error()).
-		Block(converterProtoToBody(field))
+		BlockFunc(func(g *Group) {
+			if body := t.filledConverterProtoTo(ctx, field); body != nil {
+				g.Add(body)
+				return
+			}
+			g.Add(converterProtoToBody(field))
+		})
 }
 
 // Render protobuf field type for given func field.
+// Structures become pointers to messages, enums are named types of protobuf package
+// and other named types are replaced by their underlying types, the same as in service.proto.
 //
-//  	*repository.Visit
+//  	*pb.Visit
 //
 func (t *stubGRPCTypeConverterTemplate) protoFieldType(ctx context.Context, field types.Type) *Statement {
-	c := &Statement{}
 	if code := specialTypeConverter(field); code != nil {
-		return c.Add(code)
-	}
-	custom := false
-	for field != nil {
-		switch f := field.(type) {
-		case types.TImport:
-			if f.Import != nil {
-				c.Qual(t.info.ProtobufPackageImport, "")
-				custom = true
-			}
-			field = f.Next
-		case types.TName:
-			protoType := f.TypeName
-			if tmp, ok := goToProtoTypesMap[f.TypeName]; ok {
-				protoType = tmp
-				custom = true
-			}
-			if !custom && !types.IsBuiltin(f) {
-				c.Qual(t.info.ProtobufPackageImport, protoType)
-			} else {
-				c.Id(protoType)
-			}
-			field = nil
-		case types.TArray:
-			if f.IsSlice {
-				c.Index()
-			} else if f.ArrayLen > 0 {
-				c.Index(Lit(f.ArrayLen))
-			}
-			field = f.Next
-		case types.TMap:
-			return c.Map(t.protoFieldType(ctx, f.Key)).Add(t.protoFieldType(ctx, f.Value))
-		case types.TPointer:
-			c.Op(strings.Repeat("*", f.NumberOfPointers))
-			field = f.Next
-		case types.TInterface:
-			mhds := interfaceType(ctx, f.Interface)
-			return c.Interface(mhds...)
-		case types.TEllipsis:
-			c.Index()
-			field = f.Next
-		default:
-			return c
-		}
-	}
-
-	return c
+		return code
+	}
+	switch f := field.(type) {
+	case types.TName:
+		if !types.IsBuiltin(f) {
+			return t.namedProtoFieldType(ctx, f)
+		}
+		if m := lookupTypeMapping(f); m != nil && m.isCast() {
+			return Id(m.ProtoGoType)
+		}
+		return Id(f.TypeName)
+	case types.TImport:
+		return t.namedProtoFieldType(ctx, f)
+	case types.TPointer:
+		// Messages are already pointers.
+		if _, ok := t.messageFields(f.Next); ok && f.NumberOfPointers == 1 {
+			return t.protoFieldType(ctx, f.Next)
+		}
+		return Op(strings.Repeat("*", f.NumberOfPointers)).Add(t.protoFieldType(ctx, f.Next))
+	case types.TArray:
+		// Bytes are scalar in protobuf, so mapping of elements is not used.
+		if isBytesType(f) {
+			return Index().Byte()
+		}
+		if !f.IsSlice && f.ArrayLen > 0 {
+			return Index(Lit(f.ArrayLen)).Add(t.protoFieldType(ctx, f.Next))
+		}
+		return Index().Add(t.protoFieldType(ctx, f.Next))
+	case types.TEllipsis:
+		return Index().Add(t.protoFieldType(ctx, f.Next))
+	case types.TMap:
+		return Map(t.protoFieldType(ctx, enumMapKey(t.info, f.Key))).Add(t.protoFieldType(ctx, f.Value))
+	case types.TInterface:
+		return Interface(interfaceType(ctx, f.Interface)...)
+	}
+	return &Statement{}
+}
+
+func (t *stubGRPCTypeConverterTemplate) namedProtoFieldType(ctx context.Context, p types.Type) *Statement {
+	name := types.TypeName(p)
+	if name == nil {
+		return &Statement{}
+	}
+	if _, ok := t.messageFields(p); ok {
+		return Op("*").Qual(t.info.ProtobufPackageImport, *name)
+	}
+	if _, named := sourceDeclaration(t.info, p); named != nil && !isEnumType(t.info, p) {
+		return t.protoFieldType(ctx, qualifiedType(p, named.Type))
+	}
+	return Qual(t.info.ProtobufPackageImport, *name)
+}
+
+// Returns fields of structure, which is transferred as protobuf message with the same name.
+// Second result is false, when type is not a structure.
+// Types of fields of imported structures are qualified with import of their package.
+func (t *stubGRPCTypeConverterTemplate) messageFields(p types.Type) ([]types.StructField, bool) {
+	if n := findNamedType(t.info, p); n != nil {
+		strct, ok := n.Inline.(types.Struct)
+		return strct.Fields, ok
+	}
+	if specialTypeConverter(p) != nil {
+		return nil, false
+	}
+	if _, ok := p.(types.TName); !ok {
+		if _, ok := p.(types.TImport); !ok {
+			return nil, false
+		}
+	}
+	strct, _ := sourceDeclaration(t.info, p)
+	if strct == nil {
+		return nil, false
+	}
+	fields := make([]types.StructField, 0, len(strct.Fields))
+	for _, field := range strct.Fields {
+		if untransferableField(field) != "" {
+			continue
+		}
+		field.Type = qualifiedType(p, field.Type)
+		fields = append(fields, field)
+	}
+	return fields, true
+}
+
+// Returns true, when go type is the same as type of protobuf field, e.g. string, []int64 or map[string]bool.
+func isProtoIdentical(p types.Type) bool {
+	switch f := p.(type) {
+	case types.TName:
+		return mstrings.IsInStringSlice(f.TypeName, defaultProtoTypes)
+	case types.TArray:
+		return isBytesType(f) || f.IsSlice && isProtoIdentical(f.Next)
+	case types.TMap:
+		return isProtoIdentical(f.Key) && isProtoIdentical(f.Value)
+	}
+	return false
+}
+
+// Returns true, when type is optional pointer to scalar, which is the same as type of protobuf field, e.g. *string.
+func (t *stubGRPCTypeConverterTemplate) isIdenticalOptional(p types.Type) bool {
+	ptr, ok := p.(types.TPointer)
+	return ok && isOptionalPointer(t.info, p) && isProtoIdentical(ptr.Next)
+}
+
+// Returns name of field of message, generated by protoc for field of structure.
+//
+//		CreatedAt -> CreatedAt
+//		ID -> Id
+//
+func protoGoFieldName(name string) string {
+	return proto.CamelCase(mstrings.ToSnakeCase(name))
+}
+
+// Returns expression, which converts go value to protobuf value without converter function, or false.
+//
+//		int64(value)
+//		pb.Kind(value)
+//		string(value)
+//
+func (t *stubGRPCTypeConverterTemplate) inlineToProto(ctx context.Context, p types.Type, value *Statement) (*Statement, bool) {
+	if isProtoIdentical(p) || t.isIdenticalOptional(p) {
+		return value, true
+	}
+	if m := lookupTypeMapping(p); m != nil {
+		if m.isCast() {
+			return Id(m.ProtoGoType).Call(value), true
+		}
+		return nil, false
+	}
+	if name, ok := p.(types.TName); ok && types.IsBuiltin(name) {
+		return nil, false
+	}
+	if _, ok := t.messageFields(p); ok {
+		return nil, false
+	}
+	if isIntegerEnumType(t.info, p) {
+		return Qual(t.info.ProtobufPackageImport, *types.TypeName(p)).Call(value), true
+	} else if isEnumType(t.info, p) {
+		return nil, false
+	}
+	_, named := sourceDeclaration(t.info, p)
+	if named == nil {
+		return nil, false
+	}
+	underlying := qualifiedType(p, named.Type)
+	return t.inlineToProto(ctx, underlying, fieldType(ctx, underlying, false).Call(value))
+}
+
+// Returns expression, which converts protobuf value to go value without converter function, or false.
+//
+//		int(protoValue)
+//		service.Kind(protoValue)
+//
+func (t *stubGRPCTypeConverterTemplate) inlineProtoTo(ctx context.Context, p types.Type, value *Statement) (*Statement, bool) {
+	if isProtoIdentical(p) || t.isIdenticalOptional(p) {
+		return value, true
+	}
+	if m := lookupTypeMapping(p); m != nil {
+		if m.isCast() {
+			return goTypeCode(m.GoType).Call(value), true
+		}
+		return nil, false
+	}
+	if name, ok := p.(types.TName); ok && types.IsBuiltin(name) {
+		return nil, false
+	}
+	if _, ok := t.messageFields(p); ok {
+		return nil, false
+	}
+	if isIntegerEnumType(t.info, p) {
+		return fieldType(ctx, p, false).Call(value), true
+	} else if isEnumType(t.info, p) {
+		return nil, false
+	}
+	_, named := sourceDeclaration(t.info, p)
+	if named == nil {
+		return nil, false
+	}
+	if conv, ok := t.inlineProtoTo(ctx, qualifiedType(p, named.Type), value); ok {
+		return fieldType(ctx, p, false).Call(conv), true
+	}
+	return nil, false
+}
+
+// Renders conversion of go value to protobuf value. When converter function is required,
+// its result is assigned to variable with provided name.
+//
+//		convCreatedAt, err := TimeTimeToProto(comment.CreatedAt)
+//		if err != nil {
+//			return nil, err
+//		}
+//
+func (t *stubGRPCTypeConverterTemplate) convertToProto(ctx context.Context, s *Statement, p types.Type, value *Statement, conv string, zero Code) *Statement {
+	if c, ok := t.inlineToProto(ctx, p, value); ok {
+		return c
+	}
+	s.List(Id(conv), Err()).Op(":=").Id(t.nestedToProto(p)).Call(value).Line()
+	s.If(Err().Op("!=").Nil()).Block(Return(zero, Err())).Line()
+	return Id(conv)
+}
+
+// Renders conversion of protobuf value to go value, the same as convertToProto.
+func (t *stubGRPCTypeConverterTemplate) convertProtoTo(ctx context.Context, s *Statement, p types.Type, value *Statement, conv string, zero Code) *Statement {
+	if c, ok := t.inlineProtoTo(ctx, p, value); ok {
+		return c
+	}
+	s.List(Id(conv), Err()).Op(":=").Id(t.nestedProtoTo(p)).Call(value).Line()
+	s.If(Err().Op("!=").Nil()).Block(Return(zero, Err())).Line()
+	return Id(conv)
+}
+
+// Adds type to queue of converters to render and returns name of converter from golang to protobuf.
+func (t *stubGRPCTypeConverterTemplate) nestedToProto(p types.Type) string {
+	t.nested = append(t.nested, types.Variable{Base: types.Base{Name: "value"}, Type: p})
+	return typeToProto(p, 0)
+}
+
+// Adds type to queue of converters to render and returns name of converter from protobuf to golang.
+func (t *stubGRPCTypeConverterTemplate) nestedProtoTo(p types.Type) string {
+	t.nested = append(t.nested, types.Variable{Base: types.Base{Name: "value"}, Type: p})
+	return protoToType(p, 0)
+}
+
+// Renders body of converter from golang to protobuf type, when mapping between types is known.
+// Returns nil when body can not be generated.
+//
+//		func PtrCommentToProto(comment *service.Comment) (*pb.Comment, error) {
+//			if comment == nil {
+//				return nil, nil
+//			}
+//			convCreatedAt, err := TimeTimeToProto(comment.CreatedAt)
+//			if err != nil {
+//				return nil, err
+//			}
+//			return &pb.Comment{
+//				CreatedAt: convCreatedAt,
+//				Text:      comment.Text,
+//			}, nil
+//		}
+//
+func (t *stubGRPCTypeConverterTemplate) filledConverterToProto(ctx context.Context, field *types.Variable) *Statement {
+	name := mstrings.ToLowerFirst(field.Name)
+	if m := lookupTypeMapping(field.Type); m != nil && m.ToProto != "" {
+		return Return(converterCall(m.ToProto, Id(name)))
+	}
+	if body := t.dynamicToProto(ctx, field.Type, name); body != nil {
+		return body
+	}
+	if specialTypeConverter(field.Type) != nil {
+		return nil
+	}
+	if conv, ok := t.inlineToProto(ctx, field.Type, Id(name)); ok {
+		return Return(conv, Nil())
+	}
+	switch f := field.Type.(type) {
+	case types.TName, types.TImport:
+		if fields, ok := t.messageFields(f); ok {
+			return t.messageToProto(ctx, &Statement{}, f, name, fields)
+		}
+		if values := enumValues(t.info, f); len(values) > 0 {
+			return t.enumToProto(ctx, f, name, values)
+		}
+		// Named slices and maps are converted as their underlying types.
+		_, named := sourceDeclaration(t.info, f)
+		if named == nil {
+			return nil
+		}
+		underlying := qualifiedType(f, named.Type)
+		if !types.IsArray(underlying) && !types.IsMap(underlying) {
+			return nil
+		}
+		return Return(Id(t.nestedToProto(underlying)).Call(fieldType(ctx, underlying, false).Call(Id(name))))
+	case types.TPointer:
+		if isOptionalPointer(t.info, f) {
+			s := If(Id(name).Op("==").Nil()).Block(Return(Nil(), Nil())).Line()
+			conv := t.convertToProto(ctx, s, f.Next, Op("*").Id(name), "conv", Nil())
+			return s.Id("value").Op(":=").Add(conv).Line().Return(Op("&").Id("value"), Nil())
+		}
+		fields, ok := t.messageFields(f.Next)
+		if !ok || f.NumberOfPointers != 1 {
+			return nil
+		}
+		s := If(Id(name).Op("==").Nil()).Block(Return(Nil(), Nil())).Line()
+		return t.messageToProto(ctx, s, f.Next, name, fields)
+	case types.TArray:
+		if !f.IsSlice {
+			break
+		}
+		s := nilSliceOrMap(name).Id("converted").Op(":=").Make(t.protoFieldType(ctx, f), Lit(0), Len(Id(name))).Line()
+		body := &Statement{}
+		conv := t.convertToProto(ctx, body, f.Next, Id("elem"), "conv", Nil())
+		s.For(List(Id("_"), Id("elem")).Op(":=").Range().Id(name)).Block(
+			body.Id("converted").Op("=").Append(Id("converted"), conv),
+		).Line()
+		return s.Return(Id("converted"), Nil())
+	case types.TMap:
+		key, ok := t.inlineToProto(ctx, enumMapKey(t.info, f.Key), enumMapKeyValue(ctx, t.info, f.Key, Id("key")))
+		if !ok {
+			break
+		}
+		s := nilSliceOrMap(name).Id("converted").Op(":=").Make(t.protoFieldType(ctx, f), Len(Id(name))).Line()
+		body := &Statement{}
+		conv := t.convertToProto(ctx, body, f.Value, Id("elem"), "conv", Nil())
+		s.For(List(Id("key"), Id("elem")).Op(":=").Range().Id(name)).Block(
+			body.Id("converted").Index(key).Op("=").Add(conv),
+		).Line()
+		return s.Return(Id("converted"), Nil())
+	}
+	return nil
+}
+
+// Nil slices and maps are converted to nil, as values, which are transferred as is. Empty ones are converted to empty,
+// but protobuf does not distinguish empty and nil repeated and map fields, so they are received as nil.
+//
+//		if value == nil {
+//			return nil, nil
+//		}
+//
+func nilSliceOrMap(name string) *Statement {
+	return If(Id(name).Op("==").Nil()).Block(Return(Nil(), Nil())).Line()
+}
+
+func (t *stubGRPCTypeConverterTemplate) messageToProto(ctx context.Context, s *Statement, p types.Type, name string, fields []types.StructField) *Statement {
+	dict := Dict{}
+	for _, sf := range fields {
+		dict[Id(protoGoFieldName(sf.Name))] = t.convertToProto(ctx, s, sf.Type, Id(name).Dot(sf.Name), "conv"+sf.Name, Nil())
+	}
+	return s.Return(Op("&").Qual(t.info.ProtobufPackageImport, *types.TypeName(p)).Values(dict), Nil())
+}
+
+// Renders body of converter from protobuf to golang type, when mapping between types is known.
+// Returns nil when body can not be generated.
+//
+//		func ProtoToPtrComment(protoComment *pb.Comment) (*service.Comment, error) {
+//			if protoComment == nil {
+//				return nil, nil
+//			}
+//			convCreatedAt, err := ProtoToTimeTime(protoComment.CreatedAt)
+//			if err != nil {
+//				return nil, err
+//			}
+//			return &service.Comment{
+//				CreatedAt: convCreatedAt,
+//				Text:      protoComment.Text,
+//			}, nil
+//		}
+//
+func (t *stubGRPCTypeConverterTemplate) filledConverterProtoTo(ctx context.Context, field *types.Variable) *Statement {
+	name := "proto" + mstrings.ToUpperFirst(field.Name)
+	if m := lookupTypeMapping(field.Type); m != nil && m.FromProto != "" {
+		return Return(converterCall(m.FromProto, Id(name)))
+	}
+	if body := t.dynamicProtoTo(ctx, field.Type, name); body != nil {
+		return body
+	}
+	if specialTypeConverter(field.Type) != nil {
+		return nil
+	}
+	if conv, ok := t.inlineProtoTo(ctx, field.Type, Id(name)); ok {
+		return Return(conv, Nil())
+	}
+	switch f := field.Type.(type) {
+	case types.TName, types.TImport:
+		if fields, ok := t.messageFields(f); ok {
+			zero := fieldType(ctx, f, false).Values()
+			s := If(Id(name).Op("==").Nil()).Block(Return(zero, Nil())).Line()
+			return t.messageProtoTo(ctx, s, f, name, fields, zero)
+		}
+		if values := enumValues(t.info, f); len(values) > 0 {
+			return t.enumProtoTo(ctx, f, name, values)
+		}
+		_, named := sourceDeclaration(t.info, f)
+		if named == nil {
+			return nil
+		}
+		underlying := qualifiedType(f, named.Type)
+		if !types.IsArray(underlying) && !types.IsMap(underlying) {
+			return nil
+		}
+		s := List(Id("conv"), Err()).Op(":=").Id(t.nestedProtoTo(underlying)).Call(Id(name)).Line()
+		s.If(Err().Op("!=").Nil()).Block(Return(Nil(), Err())).Line()
+		return s.Return(fieldType(ctx, f, false).Call(Id("conv")), Nil())
+	case types.TPointer:
+		if isOptionalPointer(t.info, f) {
+			s := If(Id(name).Op("==").Nil()).Block(Return(Nil(), Nil())).Line()
+			conv := t.convertProtoTo(ctx, s, f.Next, Op("*").Id(name), "conv", Nil())
+			return s.Id("value").Op(":=").Add(conv).Line().Return(Op("&").Id("value"), Nil())
+		}
+		fields, ok := t.messageFields(f.Next)
+		if !ok || f.NumberOfPointers != 1 {
+			return nil
+		}
+		s := If(Id(name).Op("==").Nil()).Block(Return(Nil(), Nil())).Line()
+		return t.messageProtoTo(ctx, s, f, name, fields, Nil())
+	case types.TArray:
+		if !f.IsSlice {
+			break
+		}
+		s := nilSliceOrMap(name).Id("converted").Op(":=").Make(fieldType(ctx, f, false), Lit(0), Len(Id(name))).Line()
+		body := &Statement{}
+		conv := t.convertProtoTo(ctx, body, f.Next, Id("elem"), "conv", Nil())
+		s.For(List(Id("_"), Id("elem")).Op(":=").Range().Id(name)).Block(
+			body.Id("converted").Op("=").Append(Id("converted"), conv),
+		).Line()
+		return s.Return(Id("converted"), Nil())
+	case types.TMap:
+		key, ok := t.inlineProtoTo(ctx, enumMapKey(t.info, f.Key), Id("key"))
+		if !ok {
+			break
+		}
+		if isEnumType(t.info, f.Key) {
+			key = fieldType(ctx, f.Key, false).Call(key)
+		}
+		s := nilSliceOrMap(name).Id("converted").Op(":=").Make(fieldType(ctx, f, false), Len(Id(name))).Line()
+		body := &Statement{}
+		conv := t.convertProtoTo(ctx, body, f.Value, Id("elem"), "conv", Nil())
+		s.For(List(Id("key"), Id("elem")).Op(":=").Range().Id(name)).Block(
+			body.Id("converted").Index(key).Op("=").Add(conv),
+		).Line()
+		return s.Return(Id("converted"), Nil())
+	}
+	return nil
+}
+
+// Renders structure from fields of message, p is type of structure or pointer to structure.
+func (t *stubGRPCTypeConverterTemplate) messageProtoTo(ctx context.Context, s *Statement, p types.Type, name string, fields []types.StructField, zero Code) *Statement {
+	dict := Dict{}
+	for _, sf := range fields {
+		dict[Id(sf.Name)] = t.convertProtoTo(ctx, s, sf.Type, Id(name).Dot(protoGoFieldName(sf.Name)), "conv"+sf.Name, zero)
+	}
+	if ptr, ok := p.(types.TPointer); ok {
+		return s.Return(Op("&").Add(fieldType(ctx, ptr.Next, false)).Values(dict), Nil())
+	}
+	return s.Return(fieldType(ctx, p, false).Values(dict), Nil())
 }
@@ -74,6 +74,10 @@ func (t *gRPCServerTemplate) Render(ctx context.Context) write_strategy.Renderer
 			if !t.info.AllowedMethods[method.Name] {
 				continue
 			}
+			if methodStreams(method).IsStream() {
+				g.Id(mstrings.ToLowerFirst(method.Name)).Qual(PackagePathGoKitEndpoint, "Endpoint")
+				continue
+			}
 			g.Id(mstrings.ToLowerFirst(method.Name)).Qual(PackagePathGoKitTransportGRPC, "Handler")
 		}
 	}).Line()
@@ -97,9 +101,13 @@ func (t *gRPCServerTemplate) Render(ctx context.Context) write_strategy.Renderer
 					if !t.info.AllowedMethods[m.Name] {
 						continue
 					}
+					if methodStreams(m).IsStream() {
+						g[(&Statement{}).Id(mstrings.ToLowerFirst(m.Name))] = grpcServerEndpoint(t.info, m)
+						continue
+					}
 					g[(&Statement{}).Id(mstrings.ToLowerFirst(m.Name))] = Qual(PackagePathGoKitTransportGRPC, "NewServer").
 						Call(
-							Line().Id("endpoints").Dot(endpointsStructFieldName(m.Name)),
+							Line().Add(grpcServerEndpoint(t.info, m)),
 							Line().Id(decodeRequestName(m)),
 							Line().Id(encodeResponseName(m)),
 							Line().Add(t.serverOpts(ctx, m)).Op("...").Line(),
@@ -114,8 +122,13 @@ func (t *gRPCServerTemplate) Render(ctx context.Context) write_strategy.Renderer
 		if !t.info.AllowedMethods[signature.Name] {
 			continue
 		}
+		if methodStreams(signature).IsStream() {
+			f.Add(t.grpcServerStreamFunc(ctx, signature, t.info.Iface)).Line()
+			continue
+		}
 		f.Add(t.grpcServerFunc(signature, t.info.Iface)).Line()
 	}
+	f.Add(grpcErrorEncoders(t.info))
 
 	return f
 }
@@ -128,7 +141,7 @@ func (t *gRPCServerTemplate) Prepare(ctx context.Context) error {
 	if t.info.ProtobufPackageImport == "" {
 		return ErrProtobufEmpty
 	}
-	return nil
+	return prepareGRPCErrors(t.info)
 }
 
 func (t *gRPCServerTemplate) ChooseStrategy(ctx context.Context) (write_strategy.Strategy, error) {
@@ -160,14 +173,13 @@ func (t *gRPCServerTemplate) grpcServerFunc(signature *types.Function, i *types.
 // or
 //		*stringsvc.CountRequest
 func (t *gRPCServerTemplate) grpcServerReqStruct(fn *types.Function) *Statement {
-	args := RemoveContextIfFirst(fn.Args)
+	args := grpcRequestParams(fn)
 	if len(args) == 0 {
 		return Op("*").Qual(PackagePathEmptyProtobuf, "Empty")
 	}
 	if len(args) == 1 {
-		sp := specialTypeConverter(args[0].Type)
-		if sp != nil {
-			return sp
+		if m := lookupTypeMapping(args[0].Type); m != nil && m.isMessage() {
+			return m.protoGoType()
 		}
 	}
 	return Op("*").Qual(t.info.ProtobufPackageImport, requestStructName(fn))
@@ -179,14 +191,13 @@ func (t *gRPCServerTemplate) grpcServerReqStruct(fn *types.Function) *Statement
 // or
 //		*stringsvc.CountResponse
 func (t *gRPCServerTemplate) grpcServerRespStruct(fn *types.Function) *Statement {
-	results := removeErrorIfLast(fn.Results)
+	results := grpcResponseParams(fn)
 	if len(results) == 0 {
 		return Op("*").Qual(PackagePathEmptyProtobuf, "Empty")
 	}
 	if len(results) == 1 {
-		sp := specialTypeConverter(results[0].Type)
-		if sp != nil {
-			return sp
+		if m := lookupTypeMapping(results[0].Type); m != nil && m.isMessage() {
+			return m.protoGoType()
 		}
 	}
 	return Op("*").Qual(t.info.ProtobufPackageImport, responseStructName(fn))
@@ -215,16 +226,15 @@ func (t *gRPCServerTemplate) grpcServerFuncBody(signature *types.Function, i *ty
 }
 
 func (t *gRPCServerTemplate) serverOpts(ctx context.Context, fn *types.Function) *Statement {
-	s := &Statement{}
-	if Tags(ctx).Has(TracingMiddlewareTag) {
-		s.Op("append(")
-		defer s.Op(")")
-	}
-	s.Id("opts")
+	var opts []Code
 	if Tags(ctx).Has(TracingMiddlewareTag) {
-		s.Op(",").Qual(PackagePathGoKitTransportGRPC, "ServerBefore").Call(
+		opts = append(opts, Qual(PackagePathGoKitTransportGRPC, "ServerBefore").Call(
 			Line().Qual(PackagePathGoKitTracing, "GRPCToContext").Call(Id("tracer"), Lit(fn.Name), Id("logger")),
-		)
+		))
+	}
+	opts = append(opts, grpcMetadataServerOptions(t.info, fn)...)
+	if len(opts) == 0 {
+		return Id("opts")
 	}
-	return s
+	return Append(append([]Code{Id("opts")}, opts...)...)
 }
@@ -0,0 +1,512 @@
+package template
+
+import (
+	"context"
+
+	. "github.com/dave/jennifer/jen"
+	mstrings "github.com/devimteam/microgen/generator/strings"
+	"github.com/vetcher/go-astra/types"
+)
+
+// Parameters of method, which are transferred by grpc streams.
+// Receive-only channel argument or send-only channel result is a stream of requests,
+// receive-only channel result is a stream of responses.
+//
+//		Upload(ctx context.Context, name string, chunks <-chan []byte) (size int, err error)
+//		Watch(ctx context.Context, filter string) (events <-chan *Event, err error)
+//		Chat(ctx context.Context, in <-chan *Message) (out <-chan *Message, err error)
+//
+// The first message of request stream carries arguments, the following messages carry elements of channel.
+type grpcStreams struct {
+	Request  *types.Variable
+	Response *types.Variable
+	// Request stream is a result of method.
+	RequestIsResult bool
+}
+
+func methodStreams(fn *types.Function) (s grpcStreams) {
+	for i := range fn.Args {
+		if ch, ok := fn.Args[i].Type.(types.TChan); ok && ch.Direction == types.ChanDirRecv {
+			s.Request = &fn.Args[i]
+		}
+	}
+	for i := range fn.Results {
+		ch, ok := fn.Results[i].Type.(types.TChan)
+		if !ok {
+			continue
+		}
+		switch ch.Direction {
+		case types.ChanDirSend:
+			s.Request, s.RequestIsResult = &fn.Results[i], true
+		case types.ChanDirRecv:
+			s.Response = &fn.Results[i]
+		}
+	}
+	return s
+}
+
+func (s grpcStreams) IsStream() bool {
+	return s.Request != nil || s.Response != nil
+}
+
+func isChanType(t types.Type) bool {
+	_, ok := t.(types.TChan)
+	return ok
+}
+
+// Returns variable with type of channel elements.
+func streamElem(v *types.Variable) types.Variable {
+	return types.Variable{Base: v.Base, Type: v.Type.(types.TChan).Next}
+}
+
+// Returns parameters of method, which are fields of grpc request message.
+// Send-only channel result is sent by client, so it is a field of request.
+func grpcRequestParams(fn *types.Function) []types.Variable {
+	params := RemoveContextIfFirst(fn.Args)
+	if s := methodStreams(fn); s.RequestIsResult {
+		params = append(params[:len(params):len(params)], *s.Request)
+	}
+	return params
+}
+
+// Returns results of method, which are fields of grpc response message.
+func grpcResponseParams(fn *types.Function) (params []types.Variable) {
+	for _, v := range removeErrorIfLast(fn.Results) {
+		if ch, ok := v.Type.(types.TChan); ok && ch.Direction == types.ChanDirSend {
+			continue
+		}
+		params = append(params, v)
+	}
+	return params
+}
+
+// Replaces channels with types of elements, as they are declared in messages.
+func streamMessageFields(params []types.Variable) []types.Variable {
+	res := make([]types.Variable, len(params))
+	for i := range params {
+		res[i] = params[i]
+		if isChanType(params[i].Type) {
+			res[i] = streamElem(&params[i])
+		}
+	}
+	return res
+}
+
+// Parameters, which are converted by endpoint converters, channels are transferred by streams.
+func transferredParams(params []types.Variable) (res []types.Variable) {
+	for _, param := range params {
+		if !isChanType(param.Type) {
+			res = append(res, param)
+		}
+	}
+	return res
+}
+
+func grpcServerStreamName(iface *types.Interface, fn *types.Function) string {
+	return iface.Name + "_" + fn.Name + "Server"
+}
+
+func grpcClientStreamName(iface *types.Interface, fn *types.Function) string {
+	return iface.Name + "_" + fn.Name + "Client"
+}
+
+func streamEndpointName(fn *types.Function) string {
+	return "_" + fn.Name + "_Stream_Endpoint"
+}
+
+// Renders conversion of channel element to field of protobuf message.
+// Returns statements, which should precede usage of converted value, converter errors are handled by onErr.
+//
+//		conv, err := PtrEventToProto(elem)
+//		if err != nil {
+//			return err
+//		}
+//
+func streamElemToProto(elem types.Variable, value *Statement, onErr ...Code) (*Statement, *Statement) {
+	if isDefaultProtoField(&elem) && !types.IsArray(elem.Type) && !isPointer(elem.Type) {
+		return &Statement{}, value
+	}
+	if m := lookupTypeMapping(elem.Type); m != nil && m.isCast() {
+		return &Statement{}, Id(m.ProtoGoType).Call(value)
+	}
+	return convertStreamElem(typeToProto(elem.Type, 0), value, onErr), Id("conv")
+}
+
+// Renders conversion of field of protobuf message to channel element, the same as streamElemToProto.
+func streamElemProtoTo(ctx context.Context, elem types.Variable, value *Statement, onErr ...Code) (*Statement, *Statement) {
+	if isDefaultGolangField(&elem) && !types.IsArray(elem.Type) && !isPointer(elem.Type) {
+		return &Statement{}, fieldType(ctx, elem.Type, false).Call(value)
+	}
+	return convertStreamElem(protoToType(elem.Type, 0), value, onErr), Id("conv")
+}
+
+// Statements are joined to one, because jennifer separates statements of case clause by empty lines.
+func convertStreamElem(converter string, value *Statement, onErr []Code) *Statement {
+	return List(Id("conv"), Err()).Op(":=").Id(converter).Call(value).Line().
+		If(Err().Op("!=").Nil()).Block(onErr...).Line()
+}
+
+// Render service method for streaming rpc.
+//
+//		func (S *commentServiceServer) Watch(req *pb.WatchRequest, stream pb.CommentService_WatchServer) error {
+//			ctx, cancel := context.WithCancel(stream.Context())
+//			defer cancel()
+//			request, err := _Decode_Watch_Request(ctx, req)
+//			if err != nil {
+//				return err
+//			}
+//			response, err := S.watch(ctx, request)
+//			if err != nil {
+//				return err
+//			}
+//			events := response.(*transport.WatchResponse).Events
+//			for {
+//				select {
+//				case <-ctx.Done():
+//					return ctx.Err()
+//				case elem, ok := <-events:
+//					if !ok {
+//						return nil
+//					}
+//					conv, err := PtrEventToProto(elem)
+//					if err != nil {
+//						return err
+//					}
+//					if err := stream.Send(&pb.WatchResponse{Events: conv}); err != nil {
+//						return err
+//					}
+//				}
+//			}
+//		}
+//
+// Errors of receiving cancel context of service method, so it stops to process stream.
+func (t *gRPCServerTemplate) grpcServerStreamFunc(ctx context.Context, fn *types.Function, i *types.Interface) *Statement {
+	s := methodStreams(fn)
+	S := rec(privateServerStructName(i))
+	transportPkg := t.info.OutputPackageImport + "/transport"
+	return Func().
+		Params(Id(S).Op("*").Id(privateServerStructName(i))).
+		Id(fn.Name).
+		ParamsFunc(func(p *Group) {
+			if s.Request == nil {
+				p.Id("req").Add(t.grpcServerReqStruct(fn))
+			}
+			p.Id("stream").Qual(t.info.ProtobufPackageImport, grpcServerStreamName(i, fn))
+		}).
+		Error().
+		BlockFunc(func(g *Group) {
+			g.List(Id("ctx"), Id("cancel")).Op(":=").Qual(PackagePathContext, "WithCancel").Call(Id("stream").Dot("Context").Call())
+			g.Defer().Id("cancel").Call()
+			g.Add(grpcMetadataFromIncoming(t.info, fn))
+			if s.Request != nil {
+				g.List(Id("req"), Err()).Op(":=").Id("stream").Dot("Recv").Call()
+				g.If(Err().Op("!=").Nil()).Block(Return(Err()))
+			}
+			g.List(Id("request"), Err()).Op(":=").Id(decodeRequestName(fn)).Call(Id("ctx"), Id("req"))
+			g.If(Err().Op("!=").Nil()).Block(Return(Err()))
+			if s.Request != nil && !s.RequestIsResult {
+				elem := streamElem(s.Request)
+				g.Id(s.Request.Name).Op(":=").Make(Chan().Add(fieldType(ctx, elem.Type, false)))
+				g.Id("request").Assert(Op("*").Qual(transportPkg, requestStructName(fn))).Dot(mstrings.ToUpperFirst(s.Request.Name)).Op("=").Id(s.Request.Name)
+				g.Go().Func().Params().BlockFunc(func(g *Group) {
+					g.Defer().Close(Id(s.Request.Name))
+					g.Add(t.streamRecvLoop(ctx, s.Request, Id("cancel").Call().Line().Return(), Return()))
+				}).Call()
+			}
+			g.List(Id("response"), Err()).Op(":=").Id(S).Dot(mstrings.ToLowerFirst(fn.Name)).Call(Id("ctx"), Id("request"))
+			g.If(Err().Op("!=").Nil()).Block(Return(Err()))
+			if s.RequestIsResult {
+				g.Id(s.Request.Name).Op(":=").Id("response").Assert(Op("*").Qual(transportPkg, responseStructName(fn))).Dot(mstrings.ToUpperFirst(s.Request.Name))
+				if s.Response != nil {
+					g.Go().Func().Params().BlockFunc(func(g *Group) {
+						g.Defer().Close(Id(s.Request.Name))
+						g.Add(t.streamRecvLoop(ctx, s.Request, Id("cancel").Call().Line().Return(), Return()))
+					}).Call()
+				} else {
+					g.Add(t.streamRecvLoop(ctx, s.Request,
+						Close(Id(s.Request.Name)).Line().Return(Err()),
+						Close(Id(s.Request.Name)).Line().Return(Id("ctx").Dot("Err").Call()),
+					))
+					g.Close(Id(s.Request.Name))
+				}
+			}
+			if s.Response == nil {
+				g.List(Id("resp"), Err()).Op(":=").Id(encodeResponseName(fn)).Call(Id("ctx"), Id("response"))
+				g.If(Err().Op("!=").Nil()).Block(Return(Err()))
+				g.Return(Id("stream").Dot("SendAndClose").Call(Id("resp").Assert(t.grpcServerRespStruct(fn))))
+				return
+			}
+			g.Id(s.Response.Name).Op(":=").Id("response").Assert(Op("*").Qual(transportPkg, responseStructName(fn))).Dot(mstrings.ToUpperFirst(s.Response.Name))
+			g.For().Block(
+				Select().Block(
+					Case(Op("<-").Id("ctx").Dot("Done").Call()).Block(
+						Return(Id("ctx").Dot("Err").Call()),
+					),
+					Case(List(Id("elem"), Id("ok")).Op(":=").Op("<-").Id(s.Response.Name)).Block(
+						streamSend(t.info, fn, responseStructName, s.Response, Return(Nil()), Return(Err())),
+					),
+				),
+			)
+		})
+}
+
+// Renders loop, which receives messages of request stream and sends elements to channel.
+// Loop is finished by the end of stream or by context, errors are handled by onErr.
+//
+//		for {
+//			msg, err := stream.Recv()
+//			if err == io.EOF {
+//				break
+//			}
+//			if err != nil {
+//				cancel()
+//				return
+//			}
+//			select {
+//			case chunks <- msg.Chunks:
+//			case <-ctx.Done():
+//				return
+//			}
+//		}
+//
+func (t *gRPCServerTemplate) streamRecvLoop(ctx context.Context, v *types.Variable, onErr, onDone *Statement) *Statement {
+	return For().BlockFunc(func(g *Group) {
+		g.List(Id("msg"), Err()).Op(":=").Id("stream").Dot("Recv").Call()
+		g.If(Err().Op("==").Qual(PackagePathIO, "EOF")).Block(Break())
+		g.If(Err().Op("!=").Nil()).Block(onErr)
+		prelude, conv := streamElemProtoTo(ctx, streamElem(v), Id("msg").Dot(mstrings.ToUpperFirst(v.Name)), onErr)
+		g.Add(prelude)
+		g.Select().Block(
+			Case(Id(v.Name).Op("<-").Add(conv)),
+			Case(Op("<-").Id("ctx").Dot("Done").Call()).Block(onDone),
+		)
+	})
+}
+
+// Renders endpoint, which calls streaming rpc.
+// Channels of request are read and channels of response are filled by goroutines, which are finished
+// by the end of stream or by context of call. Errors of streams, which occur after endpoint returns, are
+// available by context, provided by WithStreamError.
+//
+//		func _Watch_Stream_Endpoint(client pb.CommentServiceClient) endpoint.Endpoint {
+//			return func(ctx context.Context, request interface{}) (interface{}, error) {
+//				req, err := _Encode_Watch_Request(ctx, request)
+//				if err != nil {
+//					return nil, err
+//				}
+//				stream, err := client.Watch(ctx, req.(*pb.WatchRequest))
+//				if err != nil {
+//					return nil, err
+//				}
+//				events := make(chan *service.Event)
+//				go func() {
+//					defer close(events)
+//					for {
+//						msg, err := stream.Recv()
+//						if err == io.EOF {
+//							return
+//						}
+//						if err != nil {
+//							setStreamError(ctx, err)
+//							return
+//						}
+//						conv, err := ProtoToPtrEvent(msg.Events)
+//						if err != nil {
+//							setStreamError(ctx, err)
+//							return
+//						}
+//						select {
+//						case events <- conv:
+//						case <-ctx.Done():
+//							return
+//						}
+//					}
+//				}()
+//				return &transport.WatchResponse{Events: events}, nil
+//			}
+//		}
+//
+func (t *gRPCClientTemplate) streamEndpoint(ctx context.Context, fn *types.Function) *Statement {
+	s := methodStreams(fn)
+	transportPkg := t.info.OutputPackageImport + "/transport"
+	onErr := []Code{Id("setStreamError").Call(Id("ctx"), Err()), Return()}
+	return Func().Id(streamEndpointName(fn)).
+		Params(Id("client").Qual(t.info.ProtobufPackageImport, t.info.Iface.Name+"Client")).
+		Qual(PackagePathGoKitEndpoint, "Endpoint").
+		Block(Return().Func().Params(Id("ctx").Qual(PackagePathContext, "Context"), Id("request").Interface()).Params(Interface(), Error()).BlockFunc(func(g *Group) {
+			g.Add(grpcMetadataToOutgoing(t.info, fn))
+			g.List(Id("req"), Err()).Op(":=").Id(encodeRequestName(fn)).Call(Id("ctx"), Id("request"))
+			g.If(Err().Op("!=").Nil()).Block(Return(Nil(), Err()))
+			if s.Request == nil {
+				g.List(Id("stream"), Err()).Op(":=").Id("client").Dot(fn.Name).Call(Id("ctx"), Id("req").Assert(t.grpcClientReqStruct(fn)))
+				g.If(Err().Op("!=").Nil()).Block(Return(Nil(), Err()))
+			} else {
+				g.List(Id("stream"), Err()).Op(":=").Id("client").Dot(fn.Name).Call(Id("ctx"))
+				g.If(Err().Op("!=").Nil()).Block(Return(Nil(), Err()))
+				g.If(Err().Op(":=").Id("stream").Dot("Send").Call(Id("req").Assert(t.grpcClientReqStruct(fn))), Err().Op("!=").Nil()).Block(Return(Nil(), Err()))
+				elem := streamElem(s.Request)
+				if s.RequestIsResult {
+					g.Id(s.Request.Name).Op(":=").Make(Chan().Add(fieldType(ctx, elem.Type, false)))
+				} else {
+					g.Id(s.Request.Name).Op(":=").Id("request").Assert(Op("*").Qual(transportPkg, requestStructName(fn))).Dot(mstrings.ToUpperFirst(s.Request.Name))
+				}
+				send := t.streamSendLoop(fn, s.Request, true)
+				switch {
+				case s.Response != nil:
+					g.Go().Func().Params().Block(
+						Defer().Id("stream").Dot("CloseSend").Call(),
+						send,
+					).Call()
+				case s.RequestIsResult:
+					g.Go().Func().Params().Block(
+						send,
+						If(List(Id("_"), Err()).Op(":=").Id("stream").Dot("CloseAndRecv").Call(), Err().Op("!=").Nil()).Block(
+							Id("setStreamError").Call(Id("ctx"), Err()),
+						),
+					).Call()
+				default:
+					g.Add(t.streamSendLoop(fn, s.Request, false))
+					g.List(Id("resp"), Err()).Op(":=").Id("stream").Dot("CloseAndRecv").Call()
+					g.If(Err().Op("!=").Nil()).Block(Return(Nil(), Err()))
+					g.Return(Id(decodeResponseName(fn)).Call(Id("ctx"), Id("resp")))
+					return
+				}
+			}
+			if s.Response != nil {
+				elem := streamElem(s.Response)
+				g.Id(s.Response.Name).Op(":=").Make(Chan().Add(fieldType(ctx, elem.Type, false)))
+				g.Go().Func().Params().BlockFunc(func(g *Group) {
+					g.Defer().Close(Id(s.Response.Name))
+					g.For().BlockFunc(func(g *Group) {
+						g.List(Id("msg"), Err()).Op(":=").Id("stream").Dot("Recv").Call()
+						g.If(Err().Op("==").Qual(PackagePathIO, "EOF")).Block(Return())
+						g.If(Err().Op("!=").Nil()).Block(onErr...)
+						prelude, conv := streamElemProtoTo(ctx, elem, Id("msg").Dot(mstrings.ToUpperFirst(s.Response.Name)), onErr...)
+						g.Add(prelude)
+						g.Select().Block(
+							Case(Id(s.Response.Name).Op("<-").Add(conv)),
+							Case(Op("<-").Id("ctx").Dot("Done").Call()).Block(Return()),
+						)
+					})
+				}).Call()
+			}
+			g.Return(Op("&").Qual(transportPkg, responseStructName(fn)).Values(DictFunc(func(d Dict) {
+				if s.Request != nil && s.RequestIsResult {
+					d[structFieldName(s.Request)] = Id(s.Request.Name)
+				}
+				if s.Response != nil {
+					d[structFieldName(s.Response)] = Id(s.Response.Name)
+				}
+			})), Nil())
+		}))
+}
+
+// Renders loop, which sends elements of channel to request stream, until channel is closed.
+// Loop in goroutine keeps errors in context, synchronous loop returns them.
+//
+//	Loop:
+//		for {
+//			select {
+//			case <-ctx.Done():
+//				return
+//			case elem, ok := <-in:
+//				if !ok {
+//					break Loop
+//				}
+//				if err := stream.Send(&pb.ChatRequest{In: elem}); err != nil {
+//					setStreamError(ctx, err)
+//					return
+//				}
+//			}
+//		}
+//
+func (t *gRPCClientTemplate) streamSendLoop(fn *types.Function, v *types.Variable, async bool) *Statement {
+	onErr := []Code{Return(Nil(), Err())}
+	onDone := Return(Nil(), Id("ctx").Dot("Err").Call())
+	if async {
+		onErr = []Code{Id("setStreamError").Call(Id("ctx"), Err()), Return()}
+		onDone = Return()
+	}
+	return Id("Loop").Op(":").Line().For().Block(
+		Select().Block(
+			Case(Op("<-").Id("ctx").Dot("Done").Call()).Block(onDone),
+			Case(List(Id("elem"), Id("ok")).Op(":=").Op("<-").Id(v.Name)).Block(
+				streamSend(t.info, fn, requestStructName, v, Break().Id("Loop"), onErr...),
+			),
+		),
+	)
+}
+
+// Renders sending of channel element to stream, when channel is not closed.
+//
+//		if !ok {
+//			return nil
+//		}
+//		if err := stream.Send(&pb.ChatResponse{Out: elem}); err != nil {
+//			return err
+//		}
+//
+func streamSend(info *GenerationInfo, fn *types.Function, message func(*types.Function) string, v *types.Variable, exit Code, onErr ...Code) *Statement {
+	prelude, conv := streamElemToProto(streamElem(v), Id("elem"), onErr...)
+	return If(Op("!").Id("ok")).Block(exit).Line().
+		Add(prelude).
+		If(
+			Err().Op(":=").Id("stream").Dot("Send").Call(Op("&").Qual(info.ProtobufPackageImport, message(fn)).Values(Dict{
+				structFieldName(v): conv,
+			})),
+			Err().Op("!=").Nil(),
+		).Block(onErr...)
+}
+
+// Renders type of request message of streaming rpc.
+func (t *gRPCClientTemplate) grpcClientReqStruct(fn *types.Function) *Statement {
+	return (&gRPCServerTemplate{info: t.info}).grpcServerReqStruct(fn)
+}
+
+// Renders context helpers for errors of streams, which occur after endpoint returns.
+//
+//		type streamErrorKey struct{}
+//
+//		type streamError struct {
+//			mu  sync.Mutex
+//			err error
+//		}
+//
+//		// WithStreamError returns context for calls of streaming methods and function, which returns
+//		// the first error of streams, started with this context.
+//		func WithStreamError(ctx context.Context) (context.Context, func() error) {
+//			e := &streamError{}
+//			return context.WithValue(ctx, streamErrorKey{}, e), func() error {
+//				e.mu.Lock()
+//				defer e.mu.Unlock()
+//				return e.err
+//			}
+//		}
+//
+func streamErrorHelpers() *Statement {
+	s := Type().Id("streamErrorKey").Struct().Line().Line()
+	s.Type().Id("streamError").Struct(
+		Id("mu").Qual(PackagePathSync, "Mutex"),
+		Id("err").Error(),
+	).Line().Line()
+	s.Comment("WithStreamError returns context for calls of streaming methods and function, which returns").Line()
+	s.Comment("the first error of streams, started with this context.").Line()
+	s.Func().Id("WithStreamError").Params(Id("ctx").Qual(PackagePathContext, "Context")).Params(Qual(PackagePathContext, "Context"), Func().Params().Error()).Block(
+		Id("e").Op(":=").Op("&").Id("streamError").Values(),
+		Return(Qual(PackagePathContext, "WithValue").Call(Id("ctx"), Id("streamErrorKey").Values(), Id("e")), Func().Params().Error().Block(
+			Id("e").Dot("mu").Dot("Lock").Call(),
+			Defer().Id("e").Dot("mu").Dot("Unlock").Call(),
+			Return(Id("e").Dot("err")),
+		)),
+	).Line().Line()
+	s.Func().Id("setStreamError").Params(Id("ctx").Qual(PackagePathContext, "Context"), Err().Error()).Block(
+		If(List(Id("e"), Id("ok")).Op(":=").Id("ctx").Dot("Value").Call(Id("streamErrorKey").Values()).Assert(Op("*").Id("streamError")), Id("ok")).Block(
+			Id("e").Dot("mu").Dot("Lock").Call(),
+			If(Id("e").Dot("err").Op("==").Nil()).Block(
+				Id("e").Dot("err").Op("=").Err(),
+			),
+			Id("e").Dot("mu").Dot("Unlock").Call(),
+		),
+	)
+	return s
+}
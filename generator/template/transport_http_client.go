@@ -84,11 +84,24 @@ func (t *httpClientTemplate) Render(ctx context.Context) write_strategy.Renderer
 	src.ImportAlias(PackagePathGoKitTransportHTTP, "httpkit")
 	src.HeaderComment(t.info.FileHeader)
 
+	src.Comment("NewHTTPClient returns endpoints, which send requests in json.")
 	src.Func().Id("NewHTTPClient").ParamsFunc(func(p *Group) {
 		p.Id("u").Op("*").Qual(PackagePathUrl, "URL")
 		p.Id("opts").Op("...").Qual(PackagePathGoKitTransportHTTP, "ClientOption")
 	}).Params(
 		Qual(t.info.OutputPackageImport+"/transport", EndpointsSetName),
+	).Block(
+		Return(Id("NewHTTPClientCodec").Call(Id("u"), Id(contentTypeJSON), Id("opts").Op("..."))),
+	)
+
+	src.Line().Comment("NewHTTPClientCodec returns endpoints, which send bodies of requests in given content type")
+	src.Comment("and accept responses in it, e.g. ContentTypeMsgpack.")
+	src.Func().Id("NewHTTPClientCodec").ParamsFunc(func(p *Group) {
+		p.Id("u").Op("*").Qual(PackagePathUrl, "URL")
+		p.Id("contentType").String()
+		p.Id("opts").Op("...").Qual(PackagePathGoKitTransportHTTP, "ClientOption")
+	}).Params(
+		Qual(t.info.OutputPackageImport+"/transport", EndpointsSetName),
 	).Block(
 		t.clientBody(ctx),
 	)
@@ -195,8 +208,8 @@ func (t *httpClientTemplate) clientBody(ctx context.Context) *Statement {
 				client := &Statement{}
 				client.Qual(PackagePathGoKitTransportHTTP, "NewClient").Call(
 					Line().Lit(method), Id("u"),
-					Line().Id(encodeRequestName(fn)),
-					Line().Id(decodeResponseName(fn)),
+					Line().Id("encodeWithContentType").Call(Id("contentType"), Id(encodeRequestName(fn))),
+					Line().Id("decodeHTTPErrors").Call(Id(decodeResponseName(fn)), Id(httpErrorDecoderName(fn))),
 					Line().Add(t.clientOpts(fn)).Op("...").Line(),
 				).Dot("Endpoint").Call()
 				d[Id(endpointsStructFieldName(fn.Name))] = client
@@ -206,8 +219,14 @@ func (t *httpClientTemplate) clientBody(ctx context.Context) *Statement {
 	return g
 }
 
+// Body of streamed response is left open to be read by caller.
 func (t *httpClientTemplate) clientOpts(fn *types.Function) *Statement {
 	s := &Statement{}
+	if body, _ := httpStreamResults(fn); body != nil {
+		return s.Append(Index().Qual(PackagePathGoKitTransportHTTP, "ClientOption").Values(
+			Qual(PackagePathGoKitTransportHTTP, "BufferedStream").Call(True()),
+		), Id("opts").Op("..."))
+	}
 	s.Id("opts")
 	return s
 }
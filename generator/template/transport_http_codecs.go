@@ -0,0 +1,414 @@
+package template
+
+import (
+	"context"
+
+	. "github.com/dave/jennifer/jen"
+	"github.com/devimteam/microgen/generator/write_strategy"
+)
+
+const (
+	PackagePathMime          = "mime"
+	PackagePathMimeMultipart = "mime/multipart"
+	PackagePathMsgpack       = "github.com/vmihailenco/msgpack"
+
+	contentTypeJSON        = "ContentTypeJSON"
+	contentTypeMsgpack     = "ContentTypeMsgpack"
+	contentTypeForm        = "ContentTypeForm"
+	contentTypeProtobuf    = "ContentTypeProtobuf"
+	contentTypeMultipart   = "ContentTypeMultipart"
+	contentTypeOctetStream = "ContentTypeOctetStream"
+)
+
+// Returns true, when http transport sends protobuf messages of grpc transport,
+// which are available, when grpc transport is generated too.
+func hasHttpProtobuf(ctx context.Context, info *GenerationInfo) bool {
+	return Tags(ctx).HasAny(HttpTag, HttpServerTag, HttpClientTag) &&
+		Tags(ctx).HasAny(GrpcTag, GrpcServerTag, GrpcClientTag) &&
+		!IsGRPCDirect(info.Iface) && info.ProtobufPackageImport != ""
+}
+
+// Returns true, when some methods send files in requests.
+func hasHttpFiles(params map[string][]HttpParam) bool {
+	for _, p := range params {
+		if len(filterHttpParams(p, HttpParamFile)) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns true, when some methods stream bodies of responses.
+func hasHttpStreams(info *GenerationInfo) bool {
+	for _, fn := range info.Iface.Methods {
+		if !info.AllowedMethods[fn.Name] {
+			continue
+		}
+		if body, _ := httpStreamResults(fn); body != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns true, when arguments of json body may be sent as form, so all of them may be placed to query.
+func isFormHttpBody(info *GenerationInfo, body []HttpParam) bool {
+	for _, p := range body {
+		if !CanPlaceHttpParam(info, p.Arg.Type, HttpParamQuery) {
+			return false
+		}
+	}
+	return len(body) > 0
+}
+
+// Returns arguments of body as fields of form, which are named like query parameters.
+func formHttpParams(body []HttpParam) (res []HttpParam) {
+	for _, p := range body {
+		res = append(res, HttpParam{Arg: p.Arg, Place: HttpParamQuery, Name: httpQueryName(&p.Arg)})
+	}
+	return res
+}
+
+type httpCodecsTemplate struct {
+	info   *GenerationInfo
+	params map[string][]HttpParam
+}
+
+func NewHttpCodecsTemplate(info *GenerationInfo) Template {
+	return &httpCodecsTemplate{
+		info: info,
+	}
+}
+
+func (t *httpCodecsTemplate) DefaultPath() string {
+	return filenameBuilder(PathTransport, "http", "codecs")
+}
+
+func (t *httpCodecsTemplate) ChooseStrategy(ctx context.Context) (write_strategy.Strategy, error) {
+	return write_strategy.NewCreateFileStrategy(t.info.OutputFilePath, t.DefaultPath()), nil
+}
+
+func (t *httpCodecsTemplate) Prepare(ctx context.Context) error {
+	params, err := methodsHttpParams(t.info)
+	t.params = params
+	return err
+}
+
+// Render content types of http bodies and functions, which select and apply codecs.
+// Server reads requests in type of Content-Type header and writes responses in type from Accept header,
+// client sends requests in type, given to NewHTTPClientCodec.
+//
+//		const (
+//			ContentTypeJSON     = "application/json"
+//			ContentTypeMsgpack  = "application/msgpack"
+//			ContentTypeForm     = "application/x-www-form-urlencoded"
+//			ContentTypeProtobuf = "application/x-protobuf"
+//		)
+//
+//		func unmarshalBody(contentType string, body io.Reader, v interface{}) error {
+//			switch contentType {
+//			case ContentTypeJSON:
+//				return json.NewDecoder(body).Decode(v)
+//			case ContentTypeMsgpack:
+//				return msgpack.NewDecoder(body).UseJSONTag(true).Decode(v)
+//			}
+//			return unsupportedContentType(contentType)
+//		}
+//
+func (t *httpCodecsTemplate) Render(ctx context.Context) write_strategy.Renderer {
+	f := NewFile("transporthttp")
+	f.ImportAlias(PackagePathGoKitTransportHTTP, "httpkit")
+	f.HeaderComment(t.info.FileHeader)
+	protobuf := hasHttpProtobuf(ctx, t.info)
+	files, streams := hasHttpFiles(t.params), hasHttpStreams(t.info)
+
+	f.Comment("Content types of bodies of requests and responses.")
+	f.Comment("Form is used only for requests of methods, which arguments may be placed to query.")
+	if files || streams {
+		f.Comment("Multipart form and octet stream are used by methods with files.")
+	}
+	f.Const().DefsFunc(func(g *Group) {
+		g.Id(contentTypeJSON).Op("=").Lit("application/json")
+		g.Id(contentTypeMsgpack).Op("=").Lit("application/msgpack")
+		g.Id(contentTypeForm).Op("=").Lit("application/x-www-form-urlencoded")
+		if protobuf {
+			g.Id(contentTypeProtobuf).Op("=").Lit("application/x-protobuf")
+		}
+		if files || streams {
+			g.Id(contentTypeMultipart).Op("=").Lit("multipart/form-data")
+			g.Id(contentTypeOctetStream).Op("=").Lit("application/octet-stream")
+		}
+	})
+
+	f.Line().Comment("Error of body, which content type is not supported by method, server responds to it with 415 status.")
+	f.Type().Id("unsupportedContentType").String()
+	f.Line().Func().Params(Id("e").Id("unsupportedContentType")).Id("Error").Params().String().Block(
+		Return(Lit("unsupported content type ").Op("+").String().Call(Id("e"))),
+	)
+	f.Line().Func().Params(Id("unsupportedContentType")).Id("StatusCode").Params().Int().Block(
+		Return(Qual(PackagePathHttp, "StatusUnsupportedMediaType")),
+	)
+
+	f.Line().Comment("Returns media type of body from Content-Type header, json is assumed, when header is missing.")
+	f.Func().Id("contentType").Params(Id("h").Qual(PackagePathHttp, "Header")).String().Block(
+		Id("value").Op(":=").Id("h").Dot("Get").Call(Lit("Content-Type")),
+		If(Id("value").Op("==").Lit("")).Block(
+			Return(Id(contentTypeJSON)),
+		),
+		List(Id("mediaType"), Id("_"), Err()).Op(":=").Qual(PackagePathMime, "ParseMediaType").Call(Id("value")),
+		If(Err().Op("!=").Nil()).Block(
+			Return(Id("value")),
+		),
+		Return(Id("mediaType")),
+	)
+
+	f.Line().Comment("Returns content type of response, which is the first supported type from Accept header of request, or json.")
+	f.Comment("Header is put to context by httpkit.PopulateRequestContext.")
+	f.Func().Id("acceptedContentType").Params(Id("ctx").Qual(PackagePathContext, "Context")).String().Block(
+		List(Id("accept"), Id("_")).Op(":=").Id("ctx").Dot("Value").Call(Qual(PackagePathGoKitTransportHTTP, "ContextKeyRequestAccept")).Assert(String()),
+		For(List(Id("_"), Id("value")).Op(":=").Range().Qual(PackagePathStrings, "Split").Call(Id("accept"), Lit(","))).Block(
+			List(Id("mediaType"), Id("params"), Err()).Op(":=").Qual(PackagePathMime, "ParseMediaType").Call(Id("value")),
+			If(Err().Op("!=").Nil().Op("||").Id("params").Index(Lit("q")).Op("==").Lit("0")).Block(
+				Continue(),
+			),
+			Switch(Id("mediaType")).BlockFunc(func(g *Group) {
+				if protobuf {
+					g.Case(Id(contentTypeMsgpack), Id(contentTypeProtobuf)).Block(Return(Id("mediaType")))
+				} else {
+					g.Case(Id(contentTypeMsgpack)).Block(Return(Id("mediaType")))
+				}
+				g.Case(Id(contentTypeJSON), Lit("application/*"), Lit("*/*")).Block(Return(Id(contentTypeJSON)))
+			}),
+		),
+		Return(Id(contentTypeJSON)),
+	)
+
+	f.Line().Type().Id("contentTypeKey").Struct()
+	f.Line().Comment("Returns content type of body of request, which is sent by client.")
+	f.Func().Id("requestContentType").Params(Id("ctx").Qual(PackagePathContext, "Context")).String().Block(
+		If(List(Id("contentType"), Id("ok")).Op(":=").Id("ctx").Dot("Value").Call(Id("contentTypeKey").Values()).Assert(String()), Id("ok")).Block(
+			Return(Id("contentType")),
+		),
+		Return(Id(contentTypeJSON)),
+	)
+
+	f.Line().Comment("Makes encoder of client send body in given content type. Responses are accepted in the same type,")
+	f.Comment("except form, which is used only for requests.")
+	f.Func().Id("encodeWithContentType").Params(
+		Id("contentType").String(),
+		Id("encode").Qual(PackagePathGoKitTransportHTTP, "EncodeRequestFunc"),
+	).Qual(PackagePathGoKitTransportHTTP, "EncodeRequestFunc").Block(
+		Return(Func().Params(
+			Id("ctx").Qual(PackagePathContext, "Context"),
+			Id("r").Op("*").Qual(PackagePathHttp, "Request"),
+			Id("request").Interface(),
+		).Error().Block(
+			If(Id("contentType").Op("!=").Id(contentTypeForm)).Block(
+				Id("r").Dot("Header").Dot("Set").Call(Lit("Accept"), Id("contentType")),
+			),
+			Return(Id("encode").Call(
+				Qual(PackagePathContext, "WithValue").Call(Id("ctx"), Id("contentTypeKey").Values(), Id("contentType")),
+				Id("r"), Id("request"),
+			)),
+		)),
+	)
+
+	f.Line().Comment("Marshals value to body of given content type and returns value of Content-Type header.")
+	if protobuf {
+		f.Comment("Form values and protobuf messages are prepared by methods, which support them, and marshaled as is.")
+	} else {
+		f.Comment("Form values are prepared by methods, which support them, and marshaled as is.")
+	}
+	f.Func().Id("marshalBody").Params(Id("contentType").String(), Id("v").Interface()).Params(String(), Index().Byte(), Error()).Block(
+		Switch(Id("v").Op(":=").Id("v").Assert(Type())).BlockFunc(func(g *Group) {
+			g.Case(Qual(PackagePathUrl, "Values")).Block(
+				Return(Id(contentTypeForm), Index().Byte().Call(Id("v").Dot("Encode").Call()), Nil()),
+			)
+			if protobuf {
+				g.Case(Qual(PackagePathGolangProtobufProto, "Message"))
+				g.List(Id("data"), Err()).Op(":=").Qual(PackagePathGolangProtobufProto, "Marshal").Call(Id("v"))
+				g.Return(Id(contentTypeProtobuf), Id("data"), Err())
+			}
+		}),
+		If(Id("contentType").Op("==").Id(contentTypeMsgpack)).Block(
+			Var().Id("buf").Qual(PackagePathBytes, "Buffer"),
+			Err().Op(":=").Qual(PackagePathMsgpack, "NewEncoder").Call(Op("&").Id("buf")).Dot("UseJSONTag").Call(True()).Dot("Encode").Call(Id("v")),
+			Return(Id(contentTypeMsgpack), Id("buf").Dot("Bytes").Call(), Err()),
+		),
+		List(Id("data"), Err()).Op(":=").Qual(PackagePathJson, "Marshal").Call(Id("v")),
+		Return(Id(contentTypeJSON).Op("+").Lit("; charset=utf-8"), Id("data"), Err()),
+	)
+
+	f.Line().Comment("Unmarshals body of given content type to value. Other content types are decoded by methods, which support them.")
+	f.Func().Id("unmarshalBody").Params(
+		Id("contentType").String(),
+		Id("body").Qual(PackagePathIO, "Reader"),
+		Id("v").Interface(),
+	).Error().Block(
+		Switch(Id("contentType")).Block(
+			Case(Id(contentTypeJSON)).Block(
+				Return(Qual(PackagePathJson, "NewDecoder").Call(Id("body")).Dot("Decode").Call(Id("v"))),
+			),
+			Case(Id(contentTypeMsgpack)).Block(
+				Return(Qual(PackagePathMsgpack, "NewDecoder").Call(Id("body")).Dot("UseJSONTag").Call(True()).Dot("Decode").Call(Id("v"))),
+			),
+		),
+		Return(Id("unsupportedContentType").Call(Id("contentType"))),
+	)
+
+	if protobuf {
+		f.Line().Comment("Reads protobuf message from body and converts it to exchange by converter of grpc transport.")
+		f.Func().Id("unmarshalProtobuf").Params(
+			Id("ctx").Qual(PackagePathContext, "Context"),
+			Id("body").Qual(PackagePathIO, "Reader"),
+			Id("m").Qual(PackagePathGolangProtobufProto, "Message"),
+			Id("decode").Func().Params(Qual(PackagePathContext, "Context"), Interface()).Params(Interface(), Error()),
+		).Params(Interface(), Error()).Block(
+			List(Id("data"), Err()).Op(":=").Qual(PackagePathIOUtil, "ReadAll").Call(Id("body")),
+			If(Err().Op("!=").Nil()).Block(
+				Return(Nil(), Err()),
+			),
+			If(Err().Op(":=").Qual(PackagePathGolangProtobufProto, "Unmarshal").Call(Id("data"), Id("m")), Err().Op("!=").Nil()).Block(
+				Return(Nil(), Err()),
+			),
+			Return(Id("decode").Call(Id("ctx"), Id("m"))),
+		)
+	}
+	if files {
+		renderHttpFileCodecs(f)
+	}
+	if streams {
+		f.Line().Comment("Streams body to response and closes it, octet stream is written, when content type is empty.")
+		f.Func().Id("writeResponseBody").Params(
+			Id("w").Qual(PackagePathHttp, "ResponseWriter"),
+			Id("contentType").String(),
+			Id("body").Qual(PackagePathIO, "ReadCloser"),
+		).Error().Block(
+			If(Id("contentType").Op("==").Lit("")).Block(
+				Id("contentType").Op("=").Id(contentTypeOctetStream),
+			),
+			Id("w").Dot("Header").Call().Dot("Set").Call(Lit("Content-Type"), Id("contentType")),
+			If(Id("body").Op("==").Nil()).Block(
+				Return(Nil()),
+			),
+			Defer().Id("body").Dot("Close").Call(),
+			List(Id("_"), Err()).Op(":=").Qual(PackagePathIO, "Copy").Call(Id("w"), Id("body")),
+			Return(Err()),
+		)
+	}
+	return f
+}
+
+// Renders functions, which read files of requests on server side and send them on client side.
+//
+//		func setMultipartBody(r *http.Request, fields url.Values, files []multipartFile) {
+//			body, w := io.Pipe()
+//			form := multipart.NewWriter(w)
+//			go func() {
+//				w.CloseWithError(writeMultipart(form, fields, files))
+//			}()
+//			r.Header.Set("Content-Type", form.FormDataContentType())
+//			setRequestBody(r, body)
+//		}
+//
+func renderHttpFileCodecs(f *File) {
+	f.Line().Comment("Size of multipart form, which is kept in memory, other parts are stored in temporary files.")
+	f.Const().Id("multipartMemory").Op("=").Lit(32).Op("<<").Lit(20)
+
+	f.Line().Comment("Returns file from multipart form of request, missing file is empty.")
+	f.Func().Id("requestFile").Params(
+		Id("r").Op("*").Qual(PackagePathHttp, "Request"),
+		Id("name").String(),
+	).Params(Qual(PackagePathIO, "Reader"), Error()).Block(
+		List(Id("f"), Id("_"), Err()).Op(":=").Id("r").Dot("FormFile").Call(Id("name")),
+		If(Err().Op("==").Qual(PackagePathHttp, "ErrMissingFile")).Block(
+			Return(Qual(PackagePathHttp, "NoBody"), Nil()),
+		),
+		Return(Id("f"), Err()),
+	)
+
+	f.Line().Comment("Reads file from multipart form of request, missing file is nil.")
+	f.Func().Id("readRequestFile").Params(
+		Id("r").Op("*").Qual(PackagePathHttp, "Request"),
+		Id("name").String(),
+	).Params(Index().Byte(), Error()).Block(
+		List(Id("f"), Id("_"), Err()).Op(":=").Id("r").Dot("FormFile").Call(Id("name")),
+		If(Err().Op("==").Qual(PackagePathHttp, "ErrMissingFile")).Block(
+			Return(Nil(), Nil()),
+		),
+		If(Err().Op("!=").Nil()).Block(
+			Return(Nil(), Err()),
+		),
+		Defer().Id("f").Dot("Close").Call(),
+		Return(Qual(PackagePathIOUtil, "ReadAll").Call(Id("f"))),
+	)
+
+	f.Line().Comment("Sends reader as body of request, body of unknown length is streamed in chunks.")
+	f.Func().Id("setRequestBody").Params(
+		Id("r").Op("*").Qual(PackagePathHttp, "Request"),
+		Id("body").Qual(PackagePathIO, "Reader"),
+	).Block(
+		Switch(Id("b").Op(":=").Id("body").Assert(Type())).Block(
+			Case(Nil()).Block(
+				Return(),
+			),
+			Case(Op("*").Qual(PackagePathBytes, "Reader")).Block(
+				Id("r").Dot("ContentLength").Op("=").Int64().Call(Id("b").Dot("Len").Call()),
+			),
+		),
+		List(Id("rc"), Id("ok")).Op(":=").Id("body").Assert(Qual(PackagePathIO, "ReadCloser")),
+		If(Op("!").Id("ok")).Block(
+			Id("rc").Op("=").Qual(PackagePathIOUtil, "NopCloser").Call(Id("body")),
+		),
+		Id("r").Dot("Body").Op("=").Id("rc"),
+	)
+
+	f.Line().Comment("File, which is sent as part of multipart form.")
+	f.Type().Id("multipartFile").Struct(
+		Id("name").String(),
+		Id("body").Qual(PackagePathIO, "Reader"),
+	)
+
+	f.Line().Comment("Streams multipart form with fields and files as body of request.")
+	f.Func().Id("setMultipartBody").Params(
+		Id("r").Op("*").Qual(PackagePathHttp, "Request"),
+		Id("fields").Qual(PackagePathUrl, "Values"),
+		Id("files").Index().Id("multipartFile"),
+	).Block(
+		List(Id("body"), Id("w")).Op(":=").Qual(PackagePathIO, "Pipe").Call(),
+		Id("form").Op(":=").Qual(PackagePathMimeMultipart, "NewWriter").Call(Id("w")),
+		Go().Func().Params().Block(
+			Id("w").Dot("CloseWithError").Call(Id("writeMultipart").Call(Id("form"), Id("fields"), Id("files"))),
+		).Call(),
+		Id("r").Dot("Header").Dot("Set").Call(Lit("Content-Type"), Id("form").Dot("FormDataContentType").Call()),
+		Id("setRequestBody").Call(Id("r"), Id("body")),
+	)
+
+	f.Line().Comment("Writes fields and files to multipart form, nil files are skipped.")
+	f.Func().Id("writeMultipart").Params(
+		Id("form").Op("*").Qual(PackagePathMimeMultipart, "Writer"),
+		Id("fields").Qual(PackagePathUrl, "Values"),
+		Id("files").Index().Id("multipartFile"),
+	).Error().Block(
+		For(List(Id("name"), Id("values")).Op(":=").Range().Id("fields")).Block(
+			For(List(Id("_"), Id("value")).Op(":=").Range().Id("values")).Block(
+				If(Err().Op(":=").Id("form").Dot("WriteField").Call(Id("name"), Id("value")), Err().Op("!=").Nil()).Block(
+					Return(Err()),
+				),
+			),
+		),
+		For(List(Id("_"), Id("f")).Op(":=").Range().Id("files")).Block(
+			If(Id("f").Dot("body").Op("==").Nil()).Block(
+				Continue(),
+			),
+			List(Id("part"), Err()).Op(":=").Id("form").Dot("CreateFormFile").Call(Id("f").Dot("name"), Id("f").Dot("name")),
+			If(Err().Op("!=").Nil()).Block(
+				Return(Err()),
+			),
+			If(List(Id("_"), Err()).Op(":=").Qual(PackagePathIO, "Copy").Call(Id("part"), Id("f").Dot("body")), Err().Op("!=").Nil()).Block(
+				Return(Err()),
+			),
+		),
+		Return(Id("form").Dot("Close").Call()),
+	)
+}
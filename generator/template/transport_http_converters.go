@@ -2,7 +2,9 @@ package template
 
 import (
 	"context"
+	"net/http"
 	"path/filepath"
+	"strings"
 
 	. "github.com/dave/jennifer/jen"
 	mstrings "github.com/devimteam/microgen/generator/strings"
@@ -24,6 +26,7 @@ type httpConverterTemplate struct {
 	state                        WriteStrategyState
 	isCommonEncoderRequestExist  bool
 	isCommonEncoderResponseExist bool
+	params                       map[string][]HttpParam
 }
 
 func NewHttpConverterTemplate(info *GenerationInfo) Template {
@@ -79,7 +82,9 @@ func (t *httpConverterTemplate) Prepare(ctx context.Context) error {
 		t.decodersResponse = append(t.decodersResponse, fn)
 		t.encodersResponse = append(t.encodersResponse, fn)
 	}
-	return nil
+	params, err := methodsHttpParams(t.info)
+	t.params = params
+	return err
 }
 
 // Render http converters: for exchanges and common.
@@ -90,36 +95,41 @@ func (t *httpConverterTemplate) Prepare(ctx context.Context) error {
 //		import (
 //			bytes "bytes"
 //			context "context"
-//			json "encoding/json"
 //			svc "github.com/devimteam/microgen/examples/svc"
 //			ioutil "io/ioutil"
 //			http "net/http"
 //		)
 //
-//		func DefaultRequestEncoder(_ context.Context, r *http.Request, request interface{}) error {
-//			var buf bytes.Buffer
-//			if err := json.NewEncoder(&buf).Encode(request); err != nil {
+//		func DefaultRequestEncoder(ctx context.Context, r *http.Request, request interface{}) error {
+//			contentType, body, err := marshalBody(requestContentType(ctx), request)
+//			if err != nil {
 //				return err
 //			}
-//			r.Body = iomstrings.NopCloser(&buf)
+//			r.Header.Set("Content-Type", contentType)
+//			r.Body = ioutil.NopCloser(bytes.NewReader(body))
 //			return nil
 //		}
 //
-//		func DefaultResponseEncoder(_ context.Context, w http.ResponseWriter, response interface{}) error {
-//			w.Header().Set("Content-Type", "application/json; charset=utf-8")
-//			return json.NewEncoder(w).Encode(response)
+//		func DefaultResponseEncoder(ctx context.Context, w http.ResponseWriter, response interface{}) error {
+//			contentType, body, err := marshalBody(acceptedContentType(ctx), response)
+//			if err != nil {
+//				return err
+//			}
+//			w.Header().Set("Content-Type", contentType)
+//			_, err = w.Write(body)
+//			return err
 //		}
 //
 //		func DecodeHTTPCountRequest(_ context.Context, r *http.Request) (interface{}, error) {
 //			var req svc.CountRequest
-//			err := json.NewDecoder(r.Body).Decode(&req)
-//			return req, err
+//			err := unmarshalBody(contentType(r.Header), r.Body, &req)
+//			return &req, err
 //		}
 //
 //		func DecodeHTTPCountResponse(_ context.Context, r *http.Response) (interface{}, error) {
 //			var resp svc.CountResponse
-//			err := json.NewDecoder(r.Body).Decode(&resp)
-//			return resp, err
+//			err := unmarshalBody(contentType(r.Header), r.Body, &resp)
+//			return &resp, err
 //		}
 //
 //		func EncodeHTTPCountRequest(ctx context.Context, r *http.Request, request interface{}) error {
@@ -141,16 +151,16 @@ func (t *httpConverterTemplate) Render(ctx context.Context) write_strategy.Rende
 	}
 
 	for _, fn := range t.decodersRequest {
-		f.Line().Add(t.decodeHTTPRequest(fn)).Line()
+		f.Line().Add(t.decodeHTTPRequest(ctx, fn)).Line()
 	}
 	for _, fn := range t.decodersResponse {
-		f.Line().Add(t.decodeHTTPResponse(fn)).Line()
+		f.Line().Add(t.decodeHTTPResponse(ctx, fn)).Line()
 	}
 	for _, fn := range t.encodersRequest {
-		f.Line().Add(t.encodeHTTPRequest(fn)).Line()
+		f.Line().Add(t.encodeHTTPRequest(ctx, fn)).Line()
 	}
 	for _, fn := range t.encodersResponse {
-		f.Line().Add(encodeHTTPResponse(fn)).Line()
+		f.Line().Add(t.encodeHTTPResponse(ctx, fn)).Line()
 	}
 
 	if t.state == AppendStrat {
@@ -159,6 +169,7 @@ func (t *httpConverterTemplate) Render(ctx context.Context) write_strategy.Rende
 
 	file := NewFile("transporthttp")
 	file.ImportAlias(t.info.SourcePackageImport, serviceAlias)
+	file.ImportAlias(t.info.OutputPackageImport+"/transport/grpc", "transportgrpc")
 	file.HeaderComment(t.info.FileHeader)
 	file.PackageComment(`Please, do not change functions names!`)
 	file.Add(f)
@@ -167,130 +178,487 @@ func (t *httpConverterTemplate) Render(ctx context.Context) write_strategy.Rende
 }
 
 // https://github.com/go-kit/kit/blob/master/examples/addsvc/pkg/addtransport/http.go#L201
+// Body is written in content type of client.
 func commonHTTPRequestEncoder() *Statement {
 	return Func().Id(commonHTTPRequestEncoderName).
 		Params(
-			Id("_").Qual(PackagePathContext, "Context"),
+			Id("ctx").Qual(PackagePathContext, "Context"),
 			Id("r").Op("*").Qual(PackagePathHttp, "Request"),
 			Id("request").Interface(),
 		).Params(
 		Error(),
 	).BlockFunc(func(g *Group) {
-		g.Var().Id("buf").Qual(PackagePathBytes, "Buffer")
-		g.If(
-			Err().Op(":=").Qual(PackagePathJson, "NewEncoder").Call(Op("&").Id("buf")).Dot("Encode").Call(Id("request")),
-			Err().Op("!=").Nil(),
-		).Block(
+		g.List(Id("contentType"), Id("body"), Err()).Op(":=").Id("marshalBody").Call(Id("requestContentType").Call(Id("ctx")), Id("request"))
+		g.If(Err().Op("!=").Nil()).Block(
 			Return(Err()),
 		)
-		g.Id("r").Dot("Body").Op("=").Qual(PackagePathIOUtil, "NopCloser").Call(Op("&").Id("buf"))
+		g.Id("r").Dot("Header").Dot("Set").Call(Lit("Content-Type"), Id("contentType"))
+		g.Id("r").Dot("Body").Op("=").Qual(PackagePathIOUtil, "NopCloser").Call(Qual(PackagePathBytes, "NewReader").Call(Id("body")))
 		g.Return(Nil())
 	})
 }
 
 // https://github.com/go-kit/kit/blob/master/examples/addsvc/pkg/addtransport/http.go#L212
+// Body is written in content type, accepted by client.
 func commonHTTPResponseEncoder() *Statement {
 	return Func().Id(commonHTTPResponseEncoderName).
 		Params(
-			Id("_").Qual(PackagePathContext, "Context"),
+			Id("ctx").Qual(PackagePathContext, "Context"),
 			Id("w").Qual(PackagePathHttp, "ResponseWriter"),
 			Id("response").Interface(),
 		).Params(
 		Error(),
 	).BlockFunc(func(g *Group) {
-		g.Id("w").Dot("Header").Call().Dot("Set").Call(Lit("Content-Type"), Lit("application/json; charset=utf-8"))
-		g.Return(
-			Qual(PackagePathJson, "NewEncoder").Call(Id("w")).Dot("Encode").Call(Id("response")),
+		g.List(Id("contentType"), Id("body"), Err()).Op(":=").Id("marshalBody").Call(Id("acceptedContentType").Call(Id("ctx")), Id("response"))
+		g.If(Err().Op("!=").Nil()).Block(
+			Return(Err()),
 		)
+		g.Id("w").Dot("Header").Call().Dot("Set").Call(Lit("Content-Type"), Id("contentType"))
+		g.List(Id("_"), Err()).Op("=").Id("w").Dot("Write").Call(Id("body"))
+		g.Return(Err())
 	})
 }
 
 //		func DecodeHTTPCountRequest(_ context.Context, r *http.Request) (interface{}, error) {
 //			var req svc.CountRequest
-//			err := json.NewDecoder(r.Body).Decode(&req)
-//			return req, err
+//			err := unmarshalBody(contentType(r.Header), r.Body, &req)
+//			return &req, err
 //		}
-func (t *httpConverterTemplate) decodeHTTPRequest(fn *types.Function) *Statement {
+//
+// Arguments are taken from path variables, query and headers, as placed by ParseHttpParams, and other arguments from body.
+// When request does not have body arguments, body is not read. Decoded enums are validated.
+// Files are read from body itself or from multipart form together with other body arguments.
+func (t *httpConverterTemplate) decodeHTTPRequest(ctx context.Context, fn *types.Function) *Statement {
+	params := t.params[fn.Name]
+	body := filterHttpParams(params, HttpParamBody)
+	multipart := isMultipartHttpBody(params)
+	hasBody := FetchHttpMethodTag(fn.Docs) != "GET" && len(filterHttpParams(params, HttpParamFile)) == 0 &&
+		(!hasHttpPlacedParams(params) || len(body) > 0)
+	ctxName := "_"
+	if hasBody && hasHttpProtobuf(ctx, t.info) {
+		ctxName = "ctx"
+	}
 	return Func().Id(decodeRequestName(fn)).
 		Params(
-			Id("_").Qual(PackagePathContext, "Context"),
+			Id(ctxName).Qual(PackagePathContext, "Context"),
 			Id("r").Op("*").Qual(PackagePathHttp, "Request"),
 		).Params(
 		Interface(),
 		Error(),
 	).BlockFunc(func(g *Group) {
-		arguments := RemoveContextIfFirst(fn.Args)
-		if FetchHttpMethodTag(fn.Docs) == "GET" {
-			if len(arguments) > 0 {
-				g.Var().Call(Id("_param").String())
-				g.Var().Id("ok").Bool()
-				g.Id("_vars").Op(":=").Qual(PackagePathGorillaMux, "Vars").Call(Id("r"))
-				for _, arg := range arguments {
-					g.List(Id("_param"), Id("ok")).Op("=").Id("_vars").Index(Lit(arg.Name)).
-						Line().If(Op("!").Id("ok")).Block(
-						Return(Nil(), Qual(PackagePathErrors, "New").Call(Lit("param "+arg.Name+" not found"))),
-					)
-					g.Add(stringToTypeConverter(&arg))
+		form := isFormHttpBody(t.info, body)
+		if FetchHttpMethodTag(fn.Docs) != "GET" && !hasHttpPlacedParams(params) && !form && !hasHttpProtobuf(ctx, t.info) {
+			g.Var().Id("req").Qual(t.info.OutputPackageImport+"/transport", requestStructName(fn))
+			g.Err().Op(":=").Id("unmarshalBody").Call(Id("contentType").Call(Id("r").Dot("Header")), Id("r").Dot("Body"), Op("&").Id("req"))
+			if !hasRequestValidation(t.info, fn) {
+				g.Return(Op("&").Id("req"), Err())
+				return
+			}
+			g.If(Err().Op("!=").Nil()).Block(
+				Return(Nil(), Err()),
+			)
+			g.Return(Op("&").Id("req"), Id(validateRequestName(fn)).Call(Op("&").Id("req")))
+			return
+		}
+		if hasBody {
+			g.Var().Id("req").Qual(t.info.OutputPackageImport+"/transport", requestStructName(fn))
+		}
+		if hasScalarHttpParams(params) || (form || multipart) && hasScalarHttpParams(formHttpParams(body)) {
+			g.Var().Call(Id("_param").String())
+		}
+		if hasBody {
+			g.Add(t.decodeRequestBody(ctx, fn, body))
+		}
+		if multipart {
+			g.If(
+				Id("_contentType").Op(":=").Id("contentType").Call(Id("r").Dot("Header")),
+				Id("_contentType").Op("!=").Id(contentTypeMultipart),
+			).Block(
+				Return(Nil(), Id("unsupportedContentType").Call(Id("_contentType"))),
+			)
+			g.If(Err().Op(":=").Id("r").Dot("ParseMultipartForm").Call(Id("multipartMemory")), Err().Op("!=").Nil()).Block(
+				Return(Nil(), Err()),
+			)
+		}
+		if len(filterHttpParams(params, HttpParamPath)) > 0 {
+			g.Var().Id("ok").Bool()
+			g.Id("_vars").Op(":=").Qual(PackagePathGorillaMux, "Vars").Call(Id("r"))
+		}
+		if len(filterHttpParams(params, HttpParamQuery)) > 0 {
+			g.Id("_query").Op(":=").Id("r").Dot("URL").Dot("Query").Call()
+		}
+		for _, p := range params {
+			switch p.Place {
+			case HttpParamBody:
+				if !multipart {
+					continue
 				}
+				name := httpQueryName(&p.Arg)
+				g.Add(t.optionalParamToTypeConverter(ctx, &p.Arg, Id("r").Dot("PostForm").Dot("Get").Call(Lit(name)), Id("r").Dot("PostForm").Index(Lit(name))))
+			case HttpParamFile:
+				g.Add(requestFileConverter(&p, multipart))
+			case HttpParamPath:
+				g.Add(t.pathVarToTypeConverter(p.Name, &p.Arg))
+			case HttpParamQuery:
+				g.Add(t.optionalParamToTypeConverter(ctx, &p.Arg, Id("_query").Dot("Get").Call(Lit(p.Name)), Id("_query").Index(Lit(p.Name))))
+			case HttpParamHeader:
+				g.Add(t.optionalParamToTypeConverter(ctx, &p.Arg, Id("r").Dot("Header").Dot("Get").Call(Lit(p.Name)), Id("r").Dot("Header").Index(Lit(http.CanonicalHeaderKey(p.Name)))))
 			}
+			if hasBody {
+				g.Id("req").Op(".").Add(structFieldName(&p.Arg)).Op("=").Add(t.parsedParam(ctx, &p.Arg))
+			}
+		}
+		if !hasBody {
 			g.Return(Op("&").Qual(t.info.OutputPackageImport+"/transport", requestStructName(fn)).Values(DictFunc(func(d Dict) {
-				for _, arg := range arguments {
-					typename := types.TypeName(arg.Type)
-					if typename == nil {
-						panic("need to check and update validation rules: (1)")
-					}
-					d[structFieldName(&arg)] = Line().Id(*typename).Call(Id(arg.Name))
+				for _, p := range params {
+					d[structFieldName(&p.Arg)] = Line().Add(t.parsedParam(ctx, &p.Arg))
 				}
 			})), Nil())
-		} else {
-			g.Var().Id("req").Qual(t.info.OutputPackageImport+"/transport", requestStructName(fn))
-			g.Err().Op(":=").Qual(PackagePathJson, "NewDecoder").Call(Id("r").Dot("Body")).Dot("Decode").Call(Op("&").Id("req"))
-			g.Return(Op("&").Id("req"), Err())
+			return
 		}
+		if hasRequestValidation(t.info, fn) {
+			g.Return(Op("&").Id("req"), Id(validateRequestName(fn)).Call(Op("&").Id("req")))
+			return
+		}
+		g.Return(Op("&").Id("req"), Nil())
 	})
 }
 
-func stringToTypeConverter(arg *types.Variable) *Statement {
-	typename := types.TypeName(arg.Type)
-	if typename == nil {
-		panic("need to check and update validation rules (2)")
-	}
-	switch *typename {
-	case "string":
-		return Id(arg.Name).Op(":=").Id("_param")
-	case "int", "int64":
-		return List(Id(arg.Name), Err()).Op(":=").Qual(PackagePathStrconv, "ParseInt").Call(Id("_param"), Lit(10), Lit(64)).
-			Line().If(Err().Op("!=").Nil()).Block(
+// Renders decoding of body in its content type. Form is decoded, when all body arguments may be placed to query,
+// and protobuf message is converted by converters of grpc transport. Form of DELETE and other methods
+// is parsed from body by url.ParseQuery, because http.Request.ParseForm skips their bodies.
+//
+//		switch _contentType := contentType(r.Header); _contentType {
+//		case ContentTypeForm:
+//			if err := r.ParseForm(); err != nil {
+//				return nil, err
+//			}
+//			_param = r.PostForm.Get("text")
+//			text := _param
+//			req.Text = text
+//		case ContentTypeProtobuf:
+//			m, err := unmarshalProtobuf(ctx, r.Body, transportgrpc.CountMessageConverters.NewRequest(), transportgrpc.CountMessageConverters.DecodeRequest)
+//			if err != nil {
+//				return nil, err
+//			}
+//			req = *m.(*transport.CountRequest)
+//		default:
+//			if err := unmarshalBody(_contentType, r.Body, &req); err != nil {
+//				return nil, err
+//			}
+//		}
+//
+func (t *httpConverterTemplate) decodeRequestBody(ctx context.Context, fn *types.Function, body []HttpParam) *Statement {
+	unmarshal := func(contentType *Statement) *Statement {
+		return If(
+			Err().Op(":=").Id("unmarshalBody").Call(contentType, Id("r").Dot("Body"), Op("&").Id("req")),
+			Err().Op("!=").Nil(),
+		).Block(
 			Return(Nil(), Err()),
 		)
-	case "int32":
-		return List(Id(arg.Name), Err()).Op(":=").Qual(PackagePathStrconv, "ParseInt").Call(Id("_param"), Lit(10), Lit(32)).
-			Line().If(Err().Op("!=").Nil()).Block(
-			Return(Nil(), Err()),
+	}
+	form := isFormHttpBody(t.info, body)
+	protobuf := hasHttpProtobuf(ctx, t.info)
+	if !form && !protobuf {
+		return unmarshal(Id("contentType").Call(Id("r").Dot("Header")))
+	}
+	return Switch(Id("_contentType").Op(":=").Id("contentType").Call(Id("r").Dot("Header")), Id("_contentType")).BlockFunc(func(g *Group) {
+		if form {
+			g.Case(Id(contentTypeForm))
+			values := Id("r").Dot("PostForm")
+			if parsesFormBody(FetchHttpMethodTag(fn.Docs)) {
+				g.If(Err().Op(":=").Id("r").Dot("ParseForm").Call(), Err().Op("!=").Nil()).Block(
+					Return(Nil(), Err()),
+				)
+			} else {
+				values = Id("_form")
+				g.List(Id("_body"), Err()).Op(":=").Qual(PackagePathIOUtil, "ReadAll").Call(Id("r").Dot("Body"))
+				g.If(Err().Op("!=").Nil()).Block(
+					Return(Nil(), Err()),
+				)
+				g.List(Id("_form"), Err()).Op(":=").Qual(PackagePathUrl, "ParseQuery").Call(String().Call(Id("_body")))
+				g.If(Err().Op("!=").Nil()).Block(
+					Return(Nil(), Err()),
+				)
+			}
+			for _, p := range formHttpParams(body) {
+				g.Add(t.optionalParamToTypeConverter(ctx, &p.Arg, values.Clone().Dot("Get").Call(Lit(p.Name)), values.Clone().Index(Lit(p.Name))))
+				g.Id("req").Op(".").Add(structFieldName(&p.Arg)).Op("=").Add(t.parsedParam(ctx, &p.Arg))
+			}
+		}
+		if protobuf {
+			converters := Qual(t.info.OutputPackageImport+"/transport/grpc", messageConvertersName(fn))
+			g.Case(Id(contentTypeProtobuf))
+			g.List(Id("m"), Err()).Op(":=").Id("unmarshalProtobuf").Call(
+				Id("ctx"), Id("r").Dot("Body"),
+				converters.Clone().Dot("NewRequest").Call(),
+				converters.Clone().Dot("DecodeRequest"),
+			)
+			g.If(Err().Op("!=").Nil()).Block(
+				Return(Nil(), Err()),
+			)
+			g.Id("req").Op("=").Op("*").Id("m").Assert(Op("*").Qual(t.info.OutputPackageImport+"/transport", requestStructName(fn)))
+		}
+		g.Default()
+		g.Add(unmarshal(Id("_contentType")))
+	})
+}
+
+// Returns true for methods, which bodies are read by http.Request.ParseForm,
+// form of other methods is parsed from body explicitly.
+func parsesFormBody(method string) bool {
+	switch method {
+	case "POST", "PUT", "PATCH":
+		return true
+	}
+	return false
+}
+
+// Renders reading of file from body of request or from multipart form.
+//
+//		data, err := readRequestFile(r, "data")
+//		if err != nil {
+//			return nil, err
+//		}
+//
+func requestFileConverter(p *HttpParam, multipart bool) *Statement {
+	var read *Statement
+	switch {
+	case !multipart && !isBytesType(p.Arg.Type):
+		return Id(p.Arg.Name).Op(":=").Id("r").Dot("Body")
+	case !multipart:
+		read = Qual(PackagePathIOUtil, "ReadAll").Call(Id("r").Dot("Body"))
+	case isBytesType(p.Arg.Type):
+		read = Id("readRequestFile").Call(Id("r"), Lit(p.Name))
+	default:
+		read = Id("requestFile").Call(Id("r"), Lit(p.Name))
+	}
+	return List(Id(p.Arg.Name), Err()).Op(":=").Add(read).
+		Line().If(Err().Op("!=").Nil()).Block(
+		Return(Nil(), Err()),
+	)
+}
+
+// Returns true, when some arguments are single values from path, query or headers, which are read to _param variable.
+func hasScalarHttpParams(params []HttpParam) bool {
+	for _, p := range params {
+		if p.Place != HttpParamBody && p.Place != HttpParamFile && isScalarHttpParam(p.Arg.Type) {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns value of argument, parsed from path, query or headers.
+// Builtin numbers are parsed to 64 bit values and named types are parsed to their underlying types,
+// so they are converted to type of argument, other values are parsed to type of argument itself.
+func (t *httpConverterTemplate) parsedParam(ctx context.Context, arg *types.Variable) *Statement {
+	if !isScalarHttpParam(arg.Type) {
+		return Id(arg.Name)
+	}
+	return t.httpParamValue(ctx, arg.Type, Id(arg.Name))
+}
+
+func (t *httpConverterTemplate) pathVarToTypeConverter(name string, arg *types.Variable) *Statement {
+	return List(Id("_param"), Id("ok")).Op("=").Id("_vars").Index(Lit(name)).
+		Line().If(Op("!").Id("ok")).Block(
+		Return(Nil(), Qual(PackagePathErrors, "New").Call(Lit("param "+arg.Name+" not found"))),
+	).Line().Add(t.stringToTypeConverter(arg))
+}
+
+// Query parameters and headers are optional, zero value is used for empty parameter.
+// Pointer is nil, when parameter is missing, and slice holds values of repeated parameter.
+func (t *httpConverterTemplate) optionalParamToTypeConverter(ctx context.Context, arg *types.Variable, value, values *Statement) *Statement {
+	elem, pointer, slice := httpParamElem(arg.Type)
+	switch {
+	case pointer:
+		return t.pointerParamToTypeConverter(ctx, arg, elem, values)
+	case slice:
+		return t.repeatedParamToTypeConverter(ctx, arg, elem, values)
+	}
+	s := Id("_param").Op("=").Add(value).Line()
+	parse, parsed := t.httpParamParser(arg.Type, Id("_param"))
+	if parse == nil {
+		return s.Add(t.stringToTypeConverter(arg))
+	}
+	return s.Var().Id(arg.Name).Add(parsed).Line().If(Id("_param").Op("!=").Lit("")).Block(
+		Var().Err().Error(),
+		If(List(Id(arg.Name), Err()).Op("=").Add(parse), Err().Op("!=").Nil()).Block(
+			httpParamError(arg),
+		),
+	)
+}
+
+//		var limit *int
+//		if _values := _query["limit"]; len(_values) > 0 {
+//			_parsed, err := strconv.ParseInt(_values[0], 10, 64)
+//			if err != nil {
+//				return nil, fmt.Errorf("param limit: %v", err)
+//			}
+//			limit = new(int)
+//			*limit = int(_parsed)
+//		}
+//
+func (t *httpConverterTemplate) pointerParamToTypeConverter(ctx context.Context, arg *types.Variable, elem types.Type, values *Statement) *Statement {
+	parse, _ := t.httpParamParser(elem, Id("_values").Index(Lit(0)))
+	casted := t.isCastedHttpParam(elem)
+	var body []Code
+	switch {
+	case parse == nil && !casted:
+		body = append(body, Id(arg.Name).Op("=").Op("&").Id("_values").Index(Lit(0)))
+	case parse == nil:
+		body = append(body,
+			Id(arg.Name).Op("=").New(fieldType(ctx, elem, false)),
+			Op("*").Id(arg.Name).Op("=").Add(t.httpParamValue(ctx, elem, Id("_values").Index(Lit(0)))),
 		)
-	case "uint", "uint64":
-		return List(Id(arg.Name), Err()).Op(":=").Qual(PackagePathStrconv, "ParseUint").Call(Id("_param"), Lit(10), Lit(64)).
-			Line().If(Err().Op("!=").Nil()).Block(
-			Return(Nil(), Err()),
+	case casted:
+		body = append(body,
+			List(Id("_parsed"), Err()).Op(":=").Add(parse),
+			If(Err().Op("!=").Nil()).Block(httpParamError(arg)),
+			Id(arg.Name).Op("=").New(fieldType(ctx, elem, false)),
+			Op("*").Id(arg.Name).Op("=").Add(t.httpParamValue(ctx, elem, Id("_parsed"))),
 		)
-	case "uint32":
-		return List(Id(arg.Name), Err()).Op(":=").Qual(PackagePathStrconv, "ParseUint").Call(Id("_param"), Lit(10), Lit(32)).
-			Line().If(Err().Op("!=").Nil()).Block(
-			Return(Nil(), Err()),
+	default:
+		body = append(body,
+			List(Id("_parsed"), Err()).Op(":=").Add(parse),
+			If(Err().Op("!=").Nil()).Block(httpParamError(arg)),
+			Id(arg.Name).Op("=").Op("&").Id("_parsed"),
+		)
+	}
+	return Var().Id(arg.Name).Add(fieldType(ctx, arg.Type, false)).
+		Line().If(Id("_values").Op(":=").Add(values), Len(Id("_values")).Op(">").Lit(0)).BlockFunc(func(g *Group) {
+		for _, code := range body {
+			g.Add(code)
+		}
+	})
+}
+
+//		var ids []int
+//		for _, _value := range _query["ids"] {
+//			_parsed, err := strconv.ParseInt(_value, 10, 64)
+//			if err != nil {
+//				return nil, fmt.Errorf("param ids: %v", err)
+//			}
+//			ids = append(ids, int(_parsed))
+//		}
+//
+func (t *httpConverterTemplate) repeatedParamToTypeConverter(ctx context.Context, arg *types.Variable, elem types.Type, values *Statement) *Statement {
+	parse, _ := t.httpParamParser(elem, Id("_value"))
+	if parse == nil && !t.isCastedHttpParam(elem) {
+		return Id(arg.Name).Op(":=").Add(values)
+	}
+	loop := Var().Id(arg.Name).Add(fieldType(ctx, arg.Type, false)).
+		Line().For(List(Id("_"), Id("_value")).Op(":=").Range().Add(values))
+	if parse == nil {
+		return loop.Block(
+			Id(arg.Name).Op("=").Append(Id(arg.Name), t.httpParamValue(ctx, elem, Id("_value"))),
 		)
 	}
-	return Line().Lit(arg.Name)
+	return loop.Block(
+		List(Id("_parsed"), Err()).Op(":=").Add(parse),
+		If(Err().Op("!=").Nil()).Block(httpParamError(arg)),
+		Id(arg.Name).Op("=").Append(Id(arg.Name), t.httpParamValue(ctx, elem, Id("_parsed"))),
+	)
+}
+
+func httpParamError(arg *types.Variable) *Statement {
+	return Return(Nil(), Qual(PackagePathFmt, "Errorf").Call(Lit("param "+arg.Name+": %v"), Err()))
+}
+
+func bitSize(typename string) int {
+	switch {
+	case strings.HasSuffix(typename, "8"):
+		return 8
+	case strings.HasSuffix(typename, "16"):
+		return 16
+	case strings.HasSuffix(typename, "32"):
+		return 32
+	}
+	return 64
+}
+
+// Name of query parameter for argument.
+func httpQueryName(arg *types.Variable) string {
+	return mstrings.ToSnakeCase(arg.Name)
+}
+
+func (t *httpConverterTemplate) stringToTypeConverter(arg *types.Variable) *Statement {
+	parse, _ := t.httpParamParser(arg.Type, Id("_param"))
+	if parse == nil {
+		return Id(arg.Name).Op(":=").Id("_param")
+	}
+	return List(Id(arg.Name), Err()).Op(":=").Add(parse).
+		Line().If(Err().Op("!=").Nil()).Block(
+		httpParamError(arg),
+	)
+}
+
+// Returns call, which parses string value to scalar type, and type of parsed value.
+// Strings are not parsed, so call is nil for them. Named types are parsed to their underlying types.
+//
+//		strconv.ParseInt(value, 10, 32) int64
+//		time.Parse(time.RFC3339, value) time.Time
+//
+func (t *httpConverterTemplate) httpParamParser(typ types.Type, value *Statement) (parse, parsed *Statement) {
+	base := httpParamBase(t.info, typ)
+	switch httpParamKindOf(t.info, typ) {
+	case httpParamConverted:
+		m := lookupTypeMapping(typ)
+		return converterCall(m.FromString, value), goTypeCode(m.GoType)
+	case httpParamString:
+		return nil, String()
+	case httpParamBool:
+		return Qual(PackagePathStrconv, "ParseBool").Call(value), Bool()
+	case httpParamInt:
+		return Qual(PackagePathStrconv, "ParseInt").Call(value, Lit(10), Lit(bitSize(*types.TypeName(base)))), Int64()
+	case httpParamUint:
+		return Qual(PackagePathStrconv, "ParseUint").Call(value, Lit(10), Lit(bitSize(*types.TypeName(base)))), Uint64()
+	case httpParamFloat:
+		return Qual(PackagePathStrconv, "ParseFloat").Call(value, Lit(bitSize(*types.TypeName(base)))), Float64()
+	case httpParamTime:
+		return Qual(PackagePathTime, "Parse").Call(Qual(PackagePathTime, "RFC3339"), value), Qual(PackagePathTime, "Time")
+	}
+	panic("need to check and update validation rules")
+}
+
+// Builtin numbers are parsed to 64 bit values and named types are parsed to their underlying types,
+// so they should be converted to type of parameter.
+func (t *httpConverterTemplate) isCastedHttpParam(typ types.Type) bool {
+	switch httpParamKindOf(t.info, typ) {
+	case httpParamInt, httpParamUint, httpParamFloat:
+		return true
+	case httpParamString, httpParamBool:
+		return !types.IsBuiltin(typ)
+	}
+	return false
+}
+
+func (t *httpConverterTemplate) httpParamValue(ctx context.Context, typ types.Type, value *Statement) *Statement {
+	if t.isCastedHttpParam(typ) {
+		return fieldType(ctx, typ, false).Call(value)
+	}
+	return value
 }
 
 //		func DecodeHTTPCountResponse(_ context.Context, r *http.Response) (interface{}, error) {
 //			var resp svc.CountResponse
-//			err := json.NewDecoder(r.Body).Decode(&resp)
-//			return resp, err
+//			err := unmarshalBody(contentType(r.Header), r.Body, &resp)
+//			return &resp, err
 //		}
-func (t *httpConverterTemplate) decodeHTTPResponse(fn *types.Function) *Statement {
+//
+// Protobuf response is converted by converters of grpc transport. Streamed body is returned as is.
+func (t *httpConverterTemplate) decodeHTTPResponse(ctx context.Context, fn *types.Function) *Statement {
+	if body, contentType := httpStreamResults(fn); body != nil {
+		return t.decodeHTTPStreamResponse(fn, body, contentType)
+	}
+	protobuf := hasHttpProtobuf(ctx, t.info)
+	ctxName := "_"
+	if protobuf {
+		ctxName = "ctx"
+	}
 	return Func().Id(decodeResponseName(fn)).
 		Params(
-			Id("_").Qual(PackagePathContext, "Context"),
+			Id(ctxName).Qual(PackagePathContext, "Context"),
 			Id("r").Op("*").Qual(PackagePathHttp, "Response"),
 		).Params(
 		Interface(),
@@ -298,26 +666,114 @@ func (t *httpConverterTemplate) decodeHTTPResponse(fn *types.Function) *Statemen
 	).
 		BlockFunc(func(g *Group) {
 			g.Var().Id("resp").Qual(t.info.OutputPackageImport+"/transport", responseStructName(fn))
-			g.Err().Op(":=").Qual(PackagePathJson, "NewDecoder").Call(Id("r").Dot("Body")).Dot("Decode").Call(Op("&").Id("resp"))
-			g.Return(Op("&").Id("resp"), Err())
+			if !protobuf {
+				g.Err().Op(":=").Id("unmarshalBody").Call(Id("contentType").Call(Id("r").Dot("Header")), Id("r").Dot("Body"), Op("&").Id("resp"))
+				if !hasResponseValidation(t.info, fn) {
+					g.Return(Op("&").Id("resp"), Err())
+					return
+				}
+				g.If(Err().Op("!=").Nil()).Block(
+					Return(Nil(), Err()),
+				)
+			} else {
+				converters := Qual(t.info.OutputPackageImport+"/transport/grpc", messageConvertersName(fn))
+				g.If(
+					Id("_contentType").Op(":=").Id("contentType").Call(Id("r").Dot("Header")),
+					Id("_contentType").Op("==").Id(contentTypeProtobuf),
+				).Block(
+					List(Id("m"), Err()).Op(":=").Id("unmarshalProtobuf").Call(
+						Id("ctx"), Id("r").Dot("Body"),
+						converters.Clone().Dot("NewResponse").Call(),
+						converters.Clone().Dot("DecodeResponse"),
+					),
+					If(Err().Op("!=").Nil()).Block(
+						Return(Nil(), Err()),
+					),
+					Id("resp").Op("=").Op("*").Id("m").Assert(Op("*").Qual(t.info.OutputPackageImport+"/transport", responseStructName(fn))),
+				).Else().If(
+					Err().Op(":=").Id("unmarshalBody").Call(Id("_contentType"), Id("r").Dot("Body"), Op("&").Id("resp")),
+					Err().Op("!=").Nil(),
+				).Block(
+					Return(Nil(), Err()),
+				)
+				if !hasResponseValidation(t.info, fn) {
+					g.Return(Op("&").Id("resp"), Nil())
+					return
+				}
+			}
+			g.Return(Op("&").Id("resp"), Id(validateResponseName(fn)).Call(Op("&").Id("resp")))
 		})
 }
 
-// Render response encoder.
+//		func DecodeHTTPDownloadResponse(_ context.Context, r *http.Response) (interface{}, error) {
+//			return &transport.DownloadResponse{
+//				Body:        r.Body,
+//				ContentType: r.Header.Get("Content-Type"),
+//			}, nil
+//		}
+//
+func (t *httpConverterTemplate) decodeHTTPStreamResponse(fn *types.Function, body, contentType *types.Variable) *Statement {
+	return Func().Id(decodeResponseName(fn)).Params(
+		Id("_").Qual(PackagePathContext, "Context"),
+		Id("r").Op("*").Qual(PackagePathHttp, "Response"),
+	).Params(
+		Interface(),
+		Error(),
+	).Block(
+		Return(Op("&").Qual(t.info.OutputPackageImport+"/transport", responseStructName(fn)).Values(DictFunc(func(d Dict) {
+			d[structFieldName(body)] = Line().Id("r").Dot("Body")
+			if contentType != nil {
+				d[structFieldName(contentType)] = Line().Id("r").Dot("Header").Dot("Get").Call(Lit("Content-Type"))
+			}
+		})), Nil()),
+	)
+}
+
+// Render response encoder. Protobuf message is written, when client accepts it, and streamed body is written as is.
 //		func EncodeHTTPCountResponse(ctx context.Context, w http.ResponseWriter, response interface{}) error {
-//			return DefaultResponseEncoder(ctx, w, response)
+//			if acceptedContentType(ctx) == ContentTypeProtobuf {
+//				m, err := transportgrpc.CountMessageConverters.EncodeResponse(ctx, response)
+//				if err != nil {
+//					return err
+//				}
+//				return CommonHTTPResponseEncoder(ctx, w, m)
+//			}
+//			return CommonHTTPResponseEncoder(ctx, w, response)
+//		}
+//
+//		func EncodeHTTPDownloadResponse(ctx context.Context, w http.ResponseWriter, response interface{}) error {
+//			resp := response.(*transport.DownloadResponse)
+//			return writeResponseBody(w, resp.ContentType, resp.Body)
 //		}
 //
-func encodeHTTPResponse(fn *types.Function) *Statement {
+func (t *httpConverterTemplate) encodeHTTPResponse(ctx context.Context, fn *types.Function) *Statement {
 	return Func().Id(encodeResponseName(fn)).Params(
 		Id("ctx").Qual(PackagePathContext, "Context"),
 		Id("w").Qual(PackagePathHttp, "ResponseWriter"),
 		Id("response").Interface(),
 	).Params(
 		Error(),
-	).Block(
-		Return().Id(commonHTTPResponseEncoderName).Call(Id("ctx"), Id("w"), Id("response")),
-	)
+	).BlockFunc(func(g *Group) {
+		if body, contentType := httpStreamResults(fn); body != nil {
+			value := Lit("")
+			if contentType != nil {
+				value = Id("resp").Op(".").Add(structFieldName(contentType))
+			}
+			g.Id("resp").Op(":=").Id("response").Assert(Op("*").Qual(t.info.OutputPackageImport+"/transport", responseStructName(fn)))
+			g.Return(Id("writeResponseBody").Call(Id("w"), value, Id("resp").Op(".").Add(structFieldName(body))))
+			return
+		}
+		if hasHttpProtobuf(ctx, t.info) {
+			g.If(Id("acceptedContentType").Call(Id("ctx")).Op("==").Id(contentTypeProtobuf)).Block(
+				List(Id("m"), Err()).Op(":=").Qual(t.info.OutputPackageImport+"/transport/grpc", messageConvertersName(fn)).Dot("EncodeResponse").Call(Id("ctx"), Id("response")),
+				If(Err().Op("!=").Nil()).Block(
+					Return(Err()),
+				),
+				Return().Id(commonHTTPResponseEncoderName).Call(Id("ctx"), Id("w"), Id("m")),
+			)
+		}
+		g.Return().Id(commonHTTPResponseEncoderName).Call(Id("ctx"), Id("w"), Id("response"))
+	})
 }
 
 // Render request encoder.
@@ -325,7 +781,7 @@ func encodeHTTPResponse(fn *types.Function) *Statement {
 //			return DefaultRequestEncoder(ctx, r, request)
 //		}
 //
-func (t *httpConverterTemplate) encodeHTTPRequest(fn *types.Function) *Statement {
+func (t *httpConverterTemplate) encodeHTTPRequest(ctx context.Context, fn *types.Function) *Statement {
 	return Func().Id(encodeRequestName(fn)).Params(
 		Id("ctx").Qual(PackagePathContext, "Context"),
 		Id("r").Op("*").Qual(PackagePathHttp, "Request"),
@@ -333,51 +789,282 @@ func (t *httpConverterTemplate) encodeHTTPRequest(fn *types.Function) *Statement
 	).Params(
 		Error(),
 	).Block(
-		Add(t.encodeHTTPRequestBody(fn)),
+		Add(t.encodeHTTPRequestBody(ctx, fn)),
 	)
 }
 
-func (t *httpConverterTemplate) encodeHTTPRequestBody(fn *types.Function) *Statement {
+// Path of request is built from path template of method, arguments are placed to query and headers by ParseHttpParams.
+// Json body contains only other arguments and is not sent, when there are no such arguments.
+// Files are sent instead of json body.
+func (t *httpConverterTemplate) encodeHTTPRequestBody(ctx context.Context, fn *types.Function) *Statement {
 	s := &Statement{}
-	pathVars := Lit(mstrings.ToURLSnakeCase(fn.Name))
-	if FetchHttpMethodTag(fn.Docs) == "GET" {
+	params := t.params[fn.Name]
+	body := filterHttpParams(params, HttpParamBody)
+	files := filterHttpParams(params, HttpParamFile)
+	placed := hasHttpPlacedParams(params)
+	form := isFormHttpBody(t.info, body)
+	if placed || form {
 		s.Id("req").Op(":=").Id("request").Assert(Op("*").Qual(t.info.OutputPackageImport+"/transport", requestStructName(fn))).Line()
-		pathVars.Add(t.pathConverters(fn))
 	}
 	s.Id("r").Dot("URL").Dot("Path").Op("=").
-		Qual(PackagePathPath, "Join").Call(Id("r").Dot("URL").Dot("Path"), pathVars)
-	if FetchHttpMethodTag(fn.Docs) == "GET" {
-		s.Line().Return(Nil())
-	} else {
+		Qual(PackagePathPath, "Join").Call(Id("r").Dot("URL").Dot("Path"), t.pathElements(fn, MethodPathVars(fn)))
+	if query := filterHttpParams(params, HttpParamQuery); len(query) > 0 {
+		s.Line().Id("_query").Op(":=").Id("r").Dot("URL").Dot("Query").Call()
+		for _, p := range query {
+			s.Line().Add(t.setHttpParam(Id("_query"), &p))
+		}
+		s.Line().Id("r").Dot("URL").Dot("RawQuery").Op("=").Id("_query").Dot("Encode").Call()
+	}
+	for _, p := range filterHttpParams(params, HttpParamHeader) {
+		s.Line().Add(t.setHttpParam(Id("r").Dot("Header"), &p))
+	}
+	if FetchHttpMethodTag(fn.Docs) == "GET" || placed && len(body) == 0 && len(files) == 0 {
+		return s.Line().Return(Nil())
+	}
+	if len(files) > 0 {
+		return s.Line().Add(t.encodeRequestFiles(params, body, files)).Line().Return(Nil())
+	}
+	if form || hasHttpProtobuf(ctx, t.info) {
+		s.Line().Add(t.encodeRequestContentTypes(ctx, fn, body))
+	}
+	switch {
+	case !placed:
 		s.Line().Return(Id(commonHTTPRequestEncoderName).Call(Id("ctx"), Id("r"), Id("request")))
+	default:
+		s.Line().Return(Id(commonHTTPRequestEncoderName).Call(Id("ctx"), Id("r"), t.requestBody(ctx, fn, body)))
+	}
+	return s
+}
+
+// Renders sending of files. Single file without other body arguments is sent as body of request,
+// octet stream is its content type, when it is not set by header argument.
+// Otherwise files and body arguments are sent as parts of multipart form.
+//
+//		_form := url.Values{}
+//		_form.Set("name", req.Name)
+//		setMultipartBody(r, _form, []multipartFile{
+//			{"data", bytes.NewReader(req.Data)},
+//		})
+//
+func (t *httpConverterTemplate) encodeRequestFiles(params, body, files []HttpParam) *Statement {
+	file := func(p *HttpParam) *Statement {
+		field := Id("req").Op(".").Add(structFieldName(&p.Arg))
+		if isBytesType(p.Arg.Type) {
+			return Qual(PackagePathBytes, "NewReader").Call(field)
+		}
+		return field
+	}
+	if !isMultipartHttpBody(params) {
+		s := &Statement{}
+		setContentType := Id("r").Dot("Header").Dot("Set").Call(Lit("Content-Type"), Id(contentTypeOctetStream))
+		for _, p := range filterHttpParams(params, HttpParamHeader) {
+			if http.CanonicalHeaderKey(p.Name) == "Content-Type" {
+				setContentType = If(Id("r").Dot("Header").Dot("Get").Call(Lit("Content-Type")).Op("==").Lit("")).Block(setContentType)
+			}
+		}
+		return s.Add(setContentType).Line().Id("setRequestBody").Call(Id("r"), file(&files[0]))
+	}
+	s := &Statement{}
+	fields := Nil()
+	if len(body) > 0 {
+		fields = Id("_form")
+		s.Id("_form").Op(":=").Qual(PackagePathUrl, "Values").Values()
+		for _, p := range formHttpParams(body) {
+			s.Line().Add(t.setHttpParam(Id("_form"), &p))
+		}
+		s.Line()
+	}
+	return s.Id("setMultipartBody").Call(Id("r"), fields, Index().Id("multipartFile").ValuesFunc(func(g *Group) {
+		for _, p := range files {
+			g.Line().Values(Lit(p.Name), file(&p))
+		}
+		g.Line()
+	}))
+}
+
+// Renders encoding of body in content type of client, other than json and msgpack.
+//
+//		switch requestContentType(ctx) {
+//		case ContentTypeForm:
+//			_form := url.Values{}
+//			_form.Set("text", req.Text)
+//			return CommonHTTPRequestEncoder(ctx, r, _form)
+//		case ContentTypeProtobuf:
+//			m, err := transportgrpc.CountMessageConverters.EncodeRequest(ctx, request)
+//			if err != nil {
+//				return err
+//			}
+//			return CommonHTTPRequestEncoder(ctx, r, m)
+//		}
+//
+func (t *httpConverterTemplate) encodeRequestContentTypes(ctx context.Context, fn *types.Function, body []HttpParam) *Statement {
+	return Switch(Id("requestContentType").Call(Id("ctx"))).BlockFunc(func(g *Group) {
+		if isFormHttpBody(t.info, body) {
+			g.Case(Id(contentTypeForm))
+			g.Id("_form").Op(":=").Qual(PackagePathUrl, "Values").Values()
+			for _, p := range formHttpParams(body) {
+				g.Add(t.setHttpParam(Id("_form"), &p))
+			}
+			g.Return(Id(commonHTTPRequestEncoderName).Call(Id("ctx"), Id("r"), Id("_form")))
+		}
+		if hasHttpProtobuf(ctx, t.info) {
+			g.Case(Id(contentTypeProtobuf))
+			g.List(Id("m"), Err()).Op(":=").Qual(t.info.OutputPackageImport+"/transport/grpc", messageConvertersName(fn)).Dot("EncodeRequest").Call(Id("ctx"), Id("request"))
+			g.If(Err().Op("!=").Nil()).Block(
+				Return(Err()),
+			)
+			g.Return(Id(commonHTTPRequestEncoderName).Call(Id("ctx"), Id("r"), Id("m")))
+		}
+	})
+}
+
+// Renders value of json body, which contains fields of request for arguments from body.
+//
+//		struct {
+//			Text string `json:"text"`
+//		}{Text: req.Text}
+//
+func (t *httpConverterTemplate) requestBody(ctx context.Context, fn *types.Function, body []HttpParam) *Statement {
+	args := RemoveContextIfFirst(transportFunction(t.info, fn).Args)
+	var fields []Code
+	values := Dict{}
+	for _, p := range body {
+		arg := findArgument(args, p.Arg.Name)
+		fields = append(fields, structFieldName(arg).Add(fieldType(ctx, arg.Type, false)).Tag(map[string]string{"json": mstrings.ToSnakeCase(arg.Name)}))
+		values[structFieldName(arg)] = Id("req").Op(".").Add(structFieldName(arg))
+	}
+	return Struct(fields...).Values(values)
+}
+
+// Renders elements of path, where variables are replaced with values of request fields.
+//
+//		comments/{id}.json -> "comments", strconv.FormatInt(int64(req.Id), 10) + ".json",
+//
+func (t *httpConverterTemplate) pathElements(fn *types.Function, vars []PathVar) *Statement {
+	s := &Statement{}
+	hasVars := false
+	first := true
+	for _, segment := range strings.Split(buildMethodPath(fn), "/") {
+		if segment == "" {
+			continue
+		}
+		if !first {
+			s.Op(",")
+		}
+		first = false
+		if !strings.Contains(segment, "{") {
+			s.Lit(segment)
+			continue
+		}
+		hasVars = true
+		s.Line()
+		for j, part := range splitPathSegment(segment) {
+			if j > 0 {
+				s.Op("+")
+			}
+			if !strings.HasPrefix(part, "{") {
+				s.Lit(part)
+				continue
+			}
+			name := pathTemplateVars(part)[0]
+			for _, v := range vars {
+				if v.Name == name && v.Arg != nil {
+					s.Add(t.typeToStringConverters(v.Arg))
+				}
+			}
+		}
+	}
+	if hasVars {
+		s.Op(",").Line()
 	}
 	return s
 }
 
-func (t *httpConverterTemplate) pathConverters(fn *types.Function) *Statement {
-	converters := &Statement{}
-	for _, arg := range RemoveContextIfFirst(fn.Args) {
-		typename := types.TypeName(arg.Type)
-		if typename == nil {
-			panic("need to check and update validation rules (3)")
+// Splits segment of path to literals and variables.
+//
+//		{id}.json -> [{id} .json]
+//
+func splitPathSegment(segment string) (parts []string) {
+	for segment != "" {
+		start := strings.IndexByte(segment, '{')
+		end := strings.IndexByte(segment, '}')
+		switch {
+		case start == -1 || end < start:
+			return append(parts, segment)
+		case start > 0:
+			parts = append(parts, segment[:start])
 		}
-		converters.Op(",").Add(typeToStringConverters(&arg))
+		parts = append(parts, segment[start:end+1])
+		segment = segment[end+1:]
 	}
-	return converters.Op(",").Line()
+	return parts
 }
 
-func typeToStringConverters(arg *types.Variable) *Statement {
-	typename := types.TypeName(arg.Type)
-	if typename == nil {
-		panic("need to check and update validation rules")
+func (t *httpConverterTemplate) typeToStringConverters(arg *types.Variable) *Statement {
+	return t.httpParamToString(arg.Type, Id("req").Op(".").Add(structFieldName(arg)))
+}
+
+// Renders conversion of scalar value to string.
+//
+//		strconv.FormatInt(int64(req.Limit), 10)
+//		req.Since.Format(time.RFC3339Nano)
+//		string(req.Status)
+//
+func (t *httpConverterTemplate) httpParamToString(typ types.Type, value *Statement) *Statement {
+	base := httpParamBase(t.info, typ)
+	switch httpParamKindOf(t.info, typ) {
+	case httpParamConverted:
+		return converterCall(lookupTypeMapping(typ).ToString, value)
+	case httpParamString:
+		if !types.IsBuiltin(typ) {
+			return String().Call(value)
+		}
+		return value
+	case httpParamBool:
+		if !types.IsBuiltin(typ) {
+			value = Bool().Call(value)
+		}
+		return Qual(PackagePathStrconv, "FormatBool").Call(value)
+	case httpParamInt:
+		return Qual(PackagePathStrconv, "FormatInt").Call(Int64().Call(value), Lit(10))
+	case httpParamUint:
+		return Qual(PackagePathStrconv, "FormatUint").Call(Uint64().Call(value), Lit(10))
+	case httpParamFloat:
+		return Qual(PackagePathStrconv, "FormatFloat").Call(Float64().Call(value), LitRune('g'), Lit(-1), Lit(bitSize(*types.TypeName(base))))
+	case httpParamTime:
+		return value.Clone().Dot("Format").Call(Qual(PackagePathTime, "RFC3339Nano"))
 	}
-	switch *typename {
-	case "string":
-		return Line().Id("req").Op(".").Add(structFieldName(arg))
-	case "int", "int32", "int64":
-		return Line().Qual(PackagePathStrconv, "FormatInt").Call(Int64().Call(Id("req").Op(".").Add(structFieldName(arg))), Lit(10))
-	case "uint", "uint32", "uint64":
-		return Line().Qual(PackagePathStrconv, "FormatUint").Call(Int64().Call(Id("req").Op(".").Add(structFieldName(arg))), Lit(10))
+	panic("need to check and update validation rules")
+}
+
+// Renders setting of query parameter or header of request.
+// Pointer is set only when it is not nil and values of slice are added as repeated parameter.
+//
+//		_query.Set("limit", strconv.FormatInt(int64(req.Limit), 10))
+//		if req.Offset != nil {
+//			_query.Set("offset", strconv.FormatInt(int64(*req.Offset), 10))
+//		}
+//		for _, _value := range req.Tags {
+//			_query.Add("tags", _value)
+//		}
+//
+func (t *httpConverterTemplate) setHttpParam(target *Statement, p *HttpParam) *Statement {
+	field := Id("req").Op(".").Add(structFieldName(&p.Arg))
+	elem, pointer, slice := httpParamElem(p.Arg.Type)
+	switch {
+	case pointer:
+		value := Op("*").Add(field.Clone())
+		// Methods of value are called on pointer itself.
+		if m := lookupTypeMapping(elem); httpParamKindOf(t.info, elem) == httpParamTime || m != nil && strings.HasPrefix(m.ToString, ".") {
+			value = field.Clone()
+		}
+		return If(field.Clone().Op("!=").Nil()).Block(
+			target.Clone().Dot("Set").Call(Lit(p.Name), t.httpParamToString(elem, value)),
+		)
+	case slice:
+		return For(List(Id("_"), Id("_value")).Op(":=").Range().Add(field)).Block(
+			target.Clone().Dot("Add").Call(Lit(p.Name), t.httpParamToString(elem, Id("_value"))),
+		)
 	}
-	return Line().Lit(arg.Name)
+	return target.Dot("Set").Call(Lit(p.Name), t.typeToStringConverters(&p.Arg))
 }
@@ -0,0 +1,373 @@
+package template
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	. "github.com/dave/jennifer/jen"
+	"github.com/devimteam/microgen/generator/write_strategy"
+	"github.com/vetcher/go-astra/types"
+)
+
+const HttpErrorTag = "http-error"
+
+// HTTPError is a mapping of error to http status code, declared by @http-error tag of interface or method.
+// Error is a name of variable from source package or qualified by name of package, imported by source file.
+//
+//		// @http-error ErrNotFound=404 entity.ErrExists=409
+//
+type HTTPError struct {
+	Error  string
+	Status int
+}
+
+// ParseHTTPErrors returns mappings, declared by @http-error tags.
+func ParseHTTPErrors(docs []string) ([]HTTPError, error) {
+	var res []HTTPError
+	for _, doc := range docs {
+		if !strings.HasPrefix(doc, TagMark+HttpErrorTag+" ") {
+			continue
+		}
+		for _, field := range strings.Fields(strings.TrimPrefix(doc, TagMark+HttpErrorTag)) {
+			kv := strings.SplitN(field, "=", 2)
+			if len(kv) != 2 || kv[0] == "" {
+				return nil, fmt.Errorf("@%s: %s should be in form of Error=Status", HttpErrorTag, field)
+			}
+			status, err := strconv.Atoi(kv[1])
+			if err != nil || status < 400 || status > 599 {
+				return nil, fmt.Errorf("@%s: %s is not http status code of error, use 4xx or 5xx code", HttpErrorTag, kv[1])
+			}
+			res = append(res, HTTPError{Error: kv[0], Status: status})
+		}
+	}
+	return res, nil
+}
+
+// Returns mappings of method. Mappings of interface are overridden by mappings of method for the same errors.
+func methodHTTPErrors(iface *types.Interface, fn *types.Function) []HTTPError {
+	res, _ := ParseHTTPErrors(iface.Docs)
+	own, _ := ParseHTTPErrors(fn.Docs)
+Own:
+	for _, e := range own {
+		for i := range res {
+			if res[i].Error == e.Error {
+				res[i] = e
+				continue Own
+			}
+		}
+		res = append(res, e)
+	}
+	return res
+}
+
+// Returns true, when method declares own mappings, so it has own error encoder and decoder.
+func hasOwnHTTPErrors(fn *types.Function) bool {
+	own, _ := ParseHTTPErrors(fn.Docs)
+	return len(own) > 0
+}
+
+// Name of error encoder of method for go-kit http server.
+func httpErrorEncoderName(fn *types.Function) string {
+	if hasOwnHTTPErrors(fn) {
+		return encodeErrorName(fn)
+	}
+	return "ErrorEncoder"
+}
+
+// Name of error decoder of method for http client.
+func httpErrorDecoderName(fn *types.Function) string {
+	if hasOwnHTTPErrors(fn) {
+		return decodeErrorName(fn)
+	}
+	return "ErrorDecoder"
+}
+
+type httpErrorsTemplate struct {
+	info *GenerationInfo
+}
+
+func NewHttpErrorsTemplate(info *GenerationInfo) Template {
+	return &httpErrorsTemplate{
+		info: info,
+	}
+}
+
+func (t *httpErrorsTemplate) DefaultPath() string {
+	return filenameBuilder(PathTransport, "http", "errors")
+}
+
+func (t *httpErrorsTemplate) ChooseStrategy(ctx context.Context) (write_strategy.Strategy, error) {
+	return write_strategy.NewCreateFileStrategy(t.info.OutputFilePath, t.DefaultPath()), nil
+}
+
+// Checks, that all errors of allowed methods can be resolved.
+func (t *httpErrorsTemplate) Prepare(ctx context.Context) error {
+	errs, _ := ParseHTTPErrors(t.info.Iface.Docs)
+	for _, fn := range t.info.Iface.Methods {
+		if !t.info.AllowedMethods[fn.Name] {
+			continue
+		}
+		own, _ := ParseHTTPErrors(fn.Docs)
+		errs = append(errs, own...)
+	}
+	for _, e := range errs {
+		if _, err := sourceValue(t.info, e.Error); err != nil {
+			return fmt.Errorf("@%s: %v", HttpErrorTag, err)
+		}
+	}
+	return nil
+}
+
+// Render error encoder and decoder of http transport. Errors are written as problem details (RFC 7807)
+// with status code of error, client restores mapped errors from status codes.
+//
+//		// Problem is a body of error response, described by RFC 7807.
+//		type Problem struct {
+//			Type     string `json:"type,omitempty"`
+//			Title    string `json:"title,omitempty"`
+//			Status   int    `json:"status,omitempty"`
+//			Detail   string `json:"detail,omitempty"`
+//			Instance string `json:"instance,omitempty"`
+//		}
+//
+//		// ErrorEncoder writes error as problem details with status code of error.
+//		func ErrorEncoder(ctx context.Context, err error, w http.ResponseWriter) {
+//			switch err {
+//			case service.ErrNotFound:
+//				writeProblem(w, 404, err)
+//			default:
+//				writeProblem(w, errorStatus(err), err)
+//			}
+//		}
+//
+//		// ErrorDecoder restores error from problem details of response.
+//		func ErrorDecoder(r *http.Response) error {
+//			p, ok := readProblem(r)
+//			if !ok {
+//				return p
+//			}
+//			return problemError(p)
+//		}
+//
+func (t *httpErrorsTemplate) Render(ctx context.Context) write_strategy.Renderer {
+	f := NewFile("transporthttp")
+	f.ImportAlias(t.info.SourcePackageImport, serviceAlias)
+	f.ImportAlias(PackagePathGoKitTransportHTTP, "httpkit")
+	f.HeaderComment(t.info.FileHeader)
+
+	errs, _ := ParseHTTPErrors(t.info.Iface.Docs)
+	f.Comment("Problem is a body of error response, described by RFC 7807.")
+	f.Type().Id("Problem").Struct(
+		Id("Type").String().Tag(map[string]string{"json": "type,omitempty"}),
+		Id("Title").String().Tag(map[string]string{"json": "title,omitempty"}),
+		Id("Status").Int().Tag(map[string]string{"json": "status,omitempty"}),
+		Id("Detail").String().Tag(map[string]string{"json": "detail,omitempty"}),
+		Id("Instance").String().Tag(map[string]string{"json": "instance,omitempty"}),
+	)
+	f.Line().Comment("Error returns details of problem or its title, when details are empty.")
+	f.Func().Params(Id("p").Op("*").Id("Problem")).Id("Error").Params().String().Block(
+		If(Id("p").Dot("Detail").Op("==").Lit("")).Block(
+			Return(Id("p").Dot("Title")),
+		),
+		Return(Id("p").Dot("Detail")),
+	)
+	f.Line().Comment("StatusCode makes server, which returns Problem, respond with the same status.")
+	f.Func().Params(Id("p").Op("*").Id("Problem")).Id("StatusCode").Params().Int().Block(
+		Return(Id("p").Dot("Status")),
+	)
+
+	f.Line().Comment("ErrorEncoder writes error as problem details with status code of error.")
+	f.Comment("Errors without mapping have status of httpkit.StatusCoder or 500.")
+	f.Add(t.errorEncoder("ErrorEncoder", errs, Id("writeProblem").Call(Id("w"), Id("errorStatus").Call(Err()), Err())))
+	f.Line().Comment("ErrorDecoder restores error from problem details of response.")
+	f.Comment("Errors without mapping and responses without problem details are returned as *Problem.")
+	f.Func().Id("ErrorDecoder").Params(Id("r").Op("*").Qual(PackagePathHttp, "Response")).Error().Block(
+		readProblemOrReturn(),
+		Return(Id("problemError").Call(Id("p"))),
+	)
+	f.Line().Comment("Restores mapped error from problem, errors with the same status code are distinguished by details.")
+	f.Func().Id("problemError").Params(Id("p").Op("*").Id("Problem")).Error().Block(
+		t.statusSwitch(errs, errs),
+		Return(Id("p")),
+	)
+
+	for _, fn := range t.info.Iface.Methods {
+		if !t.info.AllowedMethods[fn.Name] || !hasOwnHTTPErrors(fn) {
+			continue
+		}
+		own, _ := ParseHTTPErrors(fn.Docs)
+		f.Line().Comment("Writes errors of " + fn.Name + " as problem details.")
+		f.Add(t.errorEncoder(encodeErrorName(fn), own, Id("ErrorEncoder").Call(Id("ctx"), Err(), Id("w"))))
+		f.Line().Comment("Restores errors of " + fn.Name + " from problem details.")
+		f.Func().Id(decodeErrorName(fn)).Params(Id("r").Op("*").Qual(PackagePathHttp, "Response")).Error().Block(
+			readProblemOrReturn(),
+			t.statusSwitch(own, methodHTTPErrors(t.info.Iface, fn)),
+			Return(Id("problemError").Call(Id("p"))),
+		)
+	}
+
+	f.Line().Add(t.helpers())
+	return f
+}
+
+// Renders reading of problem details, response without them is returned as generic error with its status,
+// because status of proxy or other server does not mean mapped error.
+//
+//		p, ok := readProblem(r)
+//		if !ok {
+//			return p
+//		}
+//
+func readProblemOrReturn() *Statement {
+	return List(Id("p"), Id("ok")).Op(":=").Id("readProblem").Call(Id("r")).Line().
+		If(Op("!").Id("ok")).Block(Return(Id("p")))
+}
+
+// Renders error encoder, which writes mapped errors with their status codes and passes other errors to fallback.
+//
+//		func ErrorEncoder(ctx context.Context, err error, w http.ResponseWriter) {
+//			switch err {
+//			case service.ErrNotFound:
+//				writeProblem(w, 404, err)
+//			default:
+//				writeProblem(w, errorStatus(err), err)
+//			}
+//		}
+//
+func (t *httpErrorsTemplate) errorEncoder(name string, errs []HTTPError, fallback *Statement) *Statement {
+	return Func().Id(name).Params(
+		Id("ctx").Qual(PackagePathContext, "Context"),
+		Err().Error(),
+		Id("w").Qual(PackagePathHttp, "ResponseWriter"),
+	).BlockFunc(func(g *Group) {
+		if len(errs) == 0 {
+			g.Add(fallback)
+			return
+		}
+		g.Switch(Err()).BlockFunc(func(g *Group) {
+			for _, e := range errs {
+				value, _ := sourceValue(t.info, e.Error)
+				g.Case(value).Block(
+					Id("writeProblem").Call(Id("w"), Lit(e.Status), Err()),
+				)
+			}
+			g.Default().Block(fallback)
+		})
+	})
+}
+
+// Renders switch by status code of problem for errors. Details are compared, when other errors of scope have the same code.
+func (t *httpErrorsTemplate) statusSwitch(errs []HTTPError, scope []HTTPError) Code {
+	if len(errs) == 0 {
+		return Null()
+	}
+	statuses := make(map[int]int)
+	for _, e := range scope {
+		statuses[e.Status]++
+	}
+	return Switch().BlockFunc(func(g *Group) {
+		for _, e := range errs {
+			value, _ := sourceValue(t.info, e.Error)
+			cond := Id("p").Dot("Status").Op("==").Lit(e.Status)
+			if statuses[e.Status] > 1 {
+				cond.Op("&&").Id("p").Dot("Detail").Op("==").Add(value.Clone()).Dot("Error").Call()
+			}
+			g.Case(cond).Block(Return(value))
+		}
+	})
+}
+
+// Renders common functions for error encoders and decoders.
+//
+//		func writeProblem(w http.ResponseWriter, status int, err error) {
+//			w.Header().Set("Content-Type", "application/problem+json")
+//			w.WriteHeader(status)
+//			json.NewEncoder(w).Encode(&Problem{
+//				Title:  http.StatusText(status),
+//				Status: status,
+//				Detail: err.Error(),
+//			})
+//		}
+//
+//		func decodeHTTPErrors(decode httpkit.DecodeResponseFunc, decodeError func(*http.Response) error) httpkit.DecodeResponseFunc {
+//			return func(ctx context.Context, r *http.Response) (interface{}, error) {
+//				if r.StatusCode >= http.StatusBadRequest {
+//					return nil, decodeError(r)
+//				}
+//				return decode(ctx, r)
+//			}
+//		}
+//
+func (t *httpErrorsTemplate) helpers() *Statement {
+	s := &Statement{}
+	s.Comment("Returns status of httpkit.StatusCoder or 500 for other errors.").Line()
+	s.Func().Id("errorStatus").Params(Err().Error()).Int().Block(
+		If(List(Id("sc"), Id("ok")).Op(":=").Err().Assert(Qual(PackagePathGoKitTransportHTTP, "StatusCoder")), Id("ok")).Block(
+			Return(Id("sc").Dot("StatusCode").Call()),
+		),
+		Return(Qual(PackagePathHttp, "StatusInternalServerError")),
+	).Line().Line()
+
+	s.Comment("Writes problem details of error, headers of httpkit.Headerer are written too.").Line()
+	s.Func().Id("writeProblem").Params(
+		Id("w").Qual(PackagePathHttp, "ResponseWriter"),
+		Id("status").Int(),
+		Err().Error(),
+	).Block(
+		If(List(Id("h"), Id("ok")).Op(":=").Err().Assert(Qual(PackagePathGoKitTransportHTTP, "Headerer")), Id("ok")).Block(
+			For(List(Id("k"), Id("values")).Op(":=").Range().Id("h").Dot("Headers").Call()).Block(
+				For(List(Id("_"), Id("v")).Op(":=").Range().Id("values")).Block(
+					Id("w").Dot("Header").Call().Dot("Add").Call(Id("k"), Id("v")),
+				),
+			),
+		),
+		Id("w").Dot("Header").Call().Dot("Set").Call(Lit("Content-Type"), Lit("application/problem+json")),
+		Id("w").Dot("WriteHeader").Call(Id("status")),
+		Qual(PackagePathJson, "NewEncoder").Call(Id("w")).Dot("Encode").Call(Op("&").Id("Problem").Values(Dict{
+			Id("Title"):  Qual(PackagePathHttp, "StatusText").Call(Id("status")),
+			Id("Status"): Id("status"),
+			Id("Detail"): Err().Dot("Error").Call(),
+		})),
+	).Line().Line()
+
+	s.Comment("Reads problem details from body of response. When body is not a problem, it becomes details of problem with status of response").Line()
+	s.Comment("and false is returned.").Line()
+	s.Func().Id("readProblem").Params(Id("r").Op("*").Qual(PackagePathHttp, "Response")).Params(Op("*").Id("Problem"), Bool()).Block(
+		List(Id("body"), Id("_")).Op(":=").Qual(PackagePathIOUtil, "ReadAll").Call(Id("r").Dot("Body")),
+		Var().Id("p").Id("Problem"),
+		If(
+			Err().Op(":=").Qual(PackagePathJson, "Unmarshal").Call(Id("body"), Op("&").Id("p")),
+			Err().Op("!=").Nil().Op("||").Id("p").Dot("Status").Op("==").Lit(0),
+		).Block(
+			Return(Op("&").Id("Problem").Values(Dict{
+				Id("Title"):  Qual(PackagePathHttp, "StatusText").Call(Id("r").Dot("StatusCode")),
+				Id("Status"): Id("r").Dot("StatusCode"),
+				Id("Detail"): Qual(PackagePathStrings, "TrimSpace").Call(String().Call(Id("body"))),
+			}), False()),
+		),
+		Return(Op("&").Id("p"), True()),
+	).Line().Line()
+
+	s.Comment("Replaces decoding of responses with error status by decodeError.").Line()
+	s.Func().Id("decodeHTTPErrors").Params(
+		Id("decode").Qual(PackagePathGoKitTransportHTTP, "DecodeResponseFunc"),
+		Id("decodeError").Func().Params(Op("*").Qual(PackagePathHttp, "Response")).Error(),
+	).Qual(PackagePathGoKitTransportHTTP, "DecodeResponseFunc").Block(
+		Return(Func().Params(
+			Id("ctx").Qual(PackagePathContext, "Context"),
+			Id("r").Op("*").Qual(PackagePathHttp, "Response"),
+		).Params(Interface(), Error()).Block(
+			If(Id("r").Dot("StatusCode").Op(">=").Qual(PackagePathHttp, "StatusBadRequest")).BlockFunc(func(g *Group) {
+				if hasHttpStreams(t.info) {
+					g.Comment("Body of streamed response is left open by client.")
+					g.Defer().Id("r").Dot("Body").Dot("Close").Call()
+				}
+				g.Return(Nil(), Id("decodeError").Call(Id("r")))
+			}),
+			Return(Id("decode").Call(Id("ctx"), Id("r"))),
+		)),
+	)
+	return s
+}
@@ -0,0 +1,376 @@
+package template
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/vetcher/go-astra/types"
+)
+
+const (
+	HttpQueryTag     = "http-query"
+	HttpHeaderTag    = "http-header"
+	HttpPathParamTag = "http-path-param"
+	HttpFileTag      = "http-file"
+)
+
+// HttpParamPlace is a part of http request, which holds value of argument.
+type HttpParamPlace string
+
+const (
+	HttpParamBody   HttpParamPlace = "body"
+	HttpParamPath   HttpParamPlace = "path"
+	HttpParamQuery  HttpParamPlace = "query"
+	HttpParamHeader HttpParamPlace = "header"
+	HttpParamFile   HttpParamPlace = "file"
+)
+
+// HttpParam is an argument of method and its place in http request.
+type HttpParam struct {
+	Arg   types.Variable
+	Place HttpParamPlace
+	// Name of path variable, query parameter, http header or part of multipart form, empty for body.
+	Name string
+}
+
+// Arguments, which are placed to query, headers and path by tags of method.
+// Keys of maps are names of arguments.
+//
+//		// @http-query limit,offset page-size=pageSize
+//		// @http-header X-Request-Id=requestID
+//		// @http-path-param id
+//		// @http-file data avatar=image
+//
+type httpParamTags struct {
+	query  map[string]string
+	header map[string]string
+	path   map[string]bool
+	file   map[string]string
+}
+
+var httpHeaderNameRegexp = regexp.MustCompile("^[0-9A-Za-z!#$%&'*+\\-.^_`|~]+$")
+
+// Returns values of tag, separated by commas or spaces.
+func fetchHttpTagValues(docs []string, tag string) (values []string) {
+	for _, doc := range docs {
+		if !strings.HasPrefix(doc, TagMark+tag+" ") {
+			continue
+		}
+		values = append(values, strings.FieldsFunc(strings.TrimPrefix(doc, TagMark+tag), func(r rune) bool {
+			return r == ',' || unicode.IsSpace(r)
+		})...)
+	}
+	return values
+}
+
+func parseHttpParamTags(fn *types.Function) (*httpParamTags, error) {
+	tags := &httpParamTags{
+		query:  make(map[string]string),
+		header: make(map[string]string),
+		path:   make(map[string]bool),
+		file:   make(map[string]string),
+	}
+	args := RemoveContextIfFirst(fn.Args)
+	seen := make(map[string]string)
+	use := func(tag, arg string) error {
+		if findArgument(args, arg) == nil {
+			return fmt.Errorf("@%s: argument %s not found", tag, arg)
+		}
+		if prev, ok := seen[arg]; ok {
+			return fmt.Errorf("@%s: argument %s is already placed by @%s", tag, arg, prev)
+		}
+		seen[arg] = tag
+		return nil
+	}
+	for _, value := range fetchHttpTagValues(fn.Docs, HttpQueryTag) {
+		key, arg := "", value
+		if kv := strings.SplitN(value, "=", 2); len(kv) == 2 {
+			key, arg = kv[0], kv[1]
+			if key == "" || arg == "" {
+				return nil, fmt.Errorf("@%s: %s should be in form of argument or query-key=argument", HttpQueryTag, value)
+			}
+		}
+		if err := use(HttpQueryTag, arg); err != nil {
+			return nil, err
+		}
+		if key == "" {
+			key = httpQueryName(findArgument(args, arg))
+		}
+		tags.query[arg] = key
+	}
+	for _, value := range fetchHttpTagValues(fn.Docs, HttpHeaderTag) {
+		kv := strings.SplitN(value, "=", 2)
+		if len(kv) != 2 || kv[1] == "" {
+			return nil, fmt.Errorf("@%s: %s should be in form of Header-Name=argument", HttpHeaderTag, value)
+		}
+		if !httpHeaderNameRegexp.MatchString(kv[0]) {
+			return nil, fmt.Errorf("@%s: %s is not a valid header name", HttpHeaderTag, kv[0])
+		}
+		if err := use(HttpHeaderTag, kv[1]); err != nil {
+			return nil, err
+		}
+		tags.header[kv[1]] = kv[0]
+	}
+	for _, arg := range fetchHttpTagValues(fn.Docs, HttpPathParamTag) {
+		if err := use(HttpPathParamTag, arg); err != nil {
+			return nil, err
+		}
+		tags.path[arg] = true
+	}
+	for _, value := range fetchHttpTagValues(fn.Docs, HttpFileTag) {
+		key, arg := "", value
+		if kv := strings.SplitN(value, "=", 2); len(kv) == 2 {
+			key, arg = kv[0], kv[1]
+			if key == "" || arg == "" {
+				return nil, fmt.Errorf("@%s: %s should be in form of argument or part-name=argument", HttpFileTag, value)
+			}
+		}
+		if err := use(HttpFileTag, arg); err != nil {
+			return nil, err
+		}
+		if key == "" {
+			key = httpQueryName(findArgument(args, arg))
+		}
+		tags.file[arg] = key
+	}
+	return tags, nil
+}
+
+func findArgument(args []types.Variable, name string) *types.Variable {
+	for i := range args {
+		if args[i].Name == name {
+			return &args[i]
+		}
+	}
+	return nil
+}
+
+// ParseHttpParams returns places of arguments of method in http request in order of arguments.
+// Arguments from @http-query and @http-header tags are placed to query and headers, arguments,
+// which match path variables, are placed to path. Other arguments of GET method are placed to query
+// and other arguments of other methods are fields of json body.
+// Arguments from @http-file tag are files, which are sent in body of request, see isMultipartHttpBody.
+// Default path of method has variables for arguments from @http-path-param tag, path from @http-path tag
+// should have them itself.
+func ParseHttpParams(fn *types.Function) ([]HttpParam, error) {
+	tags, err := parseHttpParamTags(fn)
+	if err != nil {
+		return nil, err
+	}
+	vars := MethodPathVars(fn)
+	isGet := FetchHttpMethodTag(fn.Docs) == "GET"
+	var params []HttpParam
+	for _, arg := range RemoveContextIfFirst(fn.Args) {
+		p := HttpParam{Arg: arg, Place: HttpParamBody}
+		name, inPath := pathVarName(vars, &arg)
+		switch {
+		case tags.query[arg.Name] != "":
+			p.Place, p.Name = HttpParamQuery, tags.query[arg.Name]
+		case tags.header[arg.Name] != "":
+			p.Place, p.Name = HttpParamHeader, tags.header[arg.Name]
+		case tags.file[arg.Name] != "" && isGet:
+			return nil, fmt.Errorf("@%s: GET request does not have body for argument %s", HttpFileTag, arg.Name)
+		case tags.file[arg.Name] != "":
+			p.Place, p.Name = HttpParamFile, tags.file[arg.Name]
+		case inPath:
+			p.Place, p.Name = HttpParamPath, name
+		case tags.path[arg.Name]:
+			return nil, fmt.Errorf("@%s: path does not have variable for argument %s", HttpPathParamTag, arg.Name)
+		case isGet:
+			p.Place, p.Name = HttpParamQuery, httpQueryName(&arg)
+		}
+		if p.Place != HttpParamPath && p.Place != HttpParamBody && inPath {
+			return nil, fmt.Errorf("argument %s is placed to %s, but path has variable {%s} for it", arg.Name, p.Place, name)
+		}
+		params = append(params, p)
+	}
+	return params, nil
+}
+
+// Returns places of arguments in http request for allowed methods by names of methods, see ParseHttpParams.
+// Types of arguments are checked by CanPlaceHttpParam.
+func methodsHttpParams(info *GenerationInfo) (map[string][]HttpParam, error) {
+	res := make(map[string][]HttpParam, len(info.Iface.Methods))
+	for _, fn := range info.Iface.Methods {
+		if !info.AllowedMethods[fn.Name] {
+			continue
+		}
+		params, err := ParseHttpParams(fn)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", fn.Name, err)
+		}
+		for _, p := range params {
+			if !CanPlaceHttpParam(info, p.Arg.Type, p.Place) {
+				return nil, fmt.Errorf("%s: argument %s of type %s can't be placed to %s of http request", fn.Name, p.Arg.Name, p.Arg.Type.String(), p.Place)
+			}
+		}
+		res[fn.Name] = params
+	}
+	return res, nil
+}
+
+// Returns params, which are placed to given part of request.
+func filterHttpParams(params []HttpParam, place HttpParamPlace) (res []HttpParam) {
+	for _, p := range params {
+		if p.Place == place {
+			res = append(res, p)
+		}
+	}
+	return res
+}
+
+// Returns true, when files are sent as parts of multipart form together with other body arguments.
+// Single file without other body arguments is sent as body of request itself.
+func isMultipartHttpBody(params []HttpParam) bool {
+	files := filterHttpParams(params, HttpParamFile)
+	return len(files) > 1 || len(files) == 1 && len(filterHttpParams(params, HttpParamBody)) > 0
+}
+
+// Returns true, when some arguments of method are not in json body.
+func hasHttpPlacedParams(params []HttpParam) bool {
+	return len(params) != len(filterHttpParams(params, HttpParamBody))
+}
+
+// Kind of scalar value of http parameter, which defines conversion of value to and from string.
+type httpParamKind int
+
+const (
+	httpParamUnsupported httpParamKind = iota
+	// Type has string converters in registry of type mappings.
+	httpParamConverted
+	httpParamString
+	httpParamBool
+	httpParamInt
+	httpParamUint
+	httpParamFloat
+	// time.Time in RFC3339 format.
+	httpParamTime
+)
+
+var httpParamBuiltinKinds = map[string]httpParamKind{
+	"string":  httpParamString,
+	"bool":    httpParamBool,
+	"int":     httpParamInt,
+	"int8":    httpParamInt,
+	"int16":   httpParamInt,
+	"int32":   httpParamInt,
+	"int64":   httpParamInt,
+	"uint":    httpParamUint,
+	"uint8":   httpParamUint,
+	"uint16":  httpParamUint,
+	"uint32":  httpParamUint,
+	"uint64":  httpParamUint,
+	"float32": httpParamFloat,
+	"float64": httpParamFloat,
+}
+
+// Returns type, which defines kind of http parameter. Named types, which are declared in source package
+// or in imported packages, are resolved to their underlying types, e.g. string for type Status string.
+// Types with string converters and time.Time are kinds themselves.
+func httpParamBase(info *GenerationInfo, t types.Type) types.Type {
+	if HasStringConverters(t) || typeMappingKey(t) == PackagePathTime+".Time" {
+		return t
+	}
+	if _, named := sourceDeclaration(info, t); named != nil {
+		return httpParamBase(info, qualifiedType(t, named.Type))
+	}
+	return t
+}
+
+func httpParamKindOf(info *GenerationInfo, t types.Type) httpParamKind {
+	t = httpParamBase(info, t)
+	if HasStringConverters(t) {
+		return httpParamConverted
+	}
+	if name, ok := t.(types.TName); ok {
+		return httpParamBuiltinKinds[name.TypeName]
+	}
+	if typeMappingKey(t) == PackagePathTime+".Time" {
+		return httpParamTime
+	}
+	return httpParamUnsupported
+}
+
+// Splits type of http parameter to type of its values and the way they are passed:
+// pointer is an optional value, which is nil when parameter is missing, and slice holds values of repeated parameter.
+func httpParamElem(t types.Type) (elem types.Type, pointer, slice bool) {
+	if HasStringConverters(t) {
+		return t, false, false
+	}
+	switch tt := t.(type) {
+	case types.TPointer:
+		if tt.NumberOfPointers == 1 {
+			return tt.Next, true, false
+		}
+	case types.TArray:
+		if tt.IsSlice {
+			return tt.Next, false, true
+		}
+	}
+	return t, false, false
+}
+
+// Returns false for optional and repeated http parameters.
+func isScalarHttpParam(t types.Type) bool {
+	_, pointer, slice := httpParamElem(t)
+	return !pointer && !slice
+}
+
+// Returns true for []byte.
+func isBytesType(t types.Type) bool {
+	arr, ok := t.(types.TArray)
+	if !ok || !arr.IsSlice {
+		return false
+	}
+	name, ok := arr.Next.(types.TName)
+	return ok && (name.TypeName == "byte" || name.TypeName == "uint8")
+}
+
+// IsReaderType returns true for io.Reader, which is streamed from body of request.
+func IsReaderType(t types.Type) bool {
+	return typeMappingKey(t) == PackagePathIO+".Reader"
+}
+
+// IsReadCloserType returns true for io.ReadCloser, which is streamed to body of response.
+func IsReadCloserType(t types.Type) bool {
+	return typeMappingKey(t) == PackagePathIO+".ReadCloser"
+}
+
+// CanPlaceHttpParam returns true, when value of type may be placed to given part of http request.
+// Strings, booleans, numbers, named types of them, time.Time and types with string converters are placed anywhere.
+// Pointers to them and slices of them are placed only to query and headers, because path variables are required and single.
+// Files are []byte or io.Reader.
+func CanPlaceHttpParam(info *GenerationInfo, t types.Type, place HttpParamPlace) bool {
+	switch place {
+	case HttpParamBody:
+		return true
+	case HttpParamFile:
+		return isBytesType(t) || IsReaderType(t)
+	}
+	if elem, _, _ := httpParamElem(t); httpParamKindOf(info, elem) == httpParamUnsupported {
+		return false
+	}
+	return place != HttpParamPath || isScalarHttpParam(t)
+}
+
+// Returns io.ReadCloser result of method, which is streamed as body of response,
+// and string result with content type of body, when method has them.
+func httpStreamResults(fn *types.Function) (body, contentType *types.Variable) {
+	results := removeErrorIfLast(fn.Results)
+	for i := range results {
+		if IsReadCloserType(results[i].Type) {
+			body = &results[i]
+		}
+	}
+	if body == nil {
+		return nil, nil
+	}
+	for i := range results {
+		if typeMappingKey(results[i].Type) == "string" {
+			contentType = &results[i]
+		}
+	}
+	return body, contentType
+}
@@ -58,18 +58,40 @@ func FetchHttpMethodTag(rawString []string) string {
 }
 
 func buildMethodPath(fn *types.Function) string {
-	url := strings.Replace(mstrings.FetchMetaInfo(TagMark+HttpMethodPath, fn.Docs), " ", "", -1)
+	url := strings.TrimPrefix(strings.Replace(fetchHttpPath(fn.Docs), " ", "", -1), "/")
 	if url == "" {
 		return buildDefaultMethodPath(fn)
 	}
 	return url
 }
 
+// Returns value of @http-path tag, but not of tags with the same prefix, like @http-path-param.
+func fetchHttpPath(docs []string) string {
+	for _, doc := range docs {
+		if strings.HasPrefix(doc, TagMark+HttpMethodPath+" ") {
+			return strings.TrimPrefix(doc, TagMark+HttpMethodPath+" ")
+		}
+	}
+	return ""
+}
+
+// Default path has variables for arguments from @http-path-param tag.
+// Arguments of GET method, which are not placed to query or headers by tags, are placed to path too,
+// except optional and repeated ones, which are left to query.
 func buildDefaultMethodPath(fn *types.Function) string {
 	edges := []string{mstrings.ToURLSnakeCase(fn.Name)} // parts of full path
-	if FetchHttpMethodTag(fn.Docs) == "GET" {
-		edges = append(edges, gorillaMuxUrlTemplateVarList(RemoveContextIfFirst(fn.Args))...)
+	tags, err := parseHttpParamTags(fn)
+	if err != nil {
+		tags = &httpParamTags{}
 	}
+	isGet := FetchHttpMethodTag(fn.Docs) == "GET"
+	var vars []types.Variable
+	for _, arg := range RemoveContextIfFirst(fn.Args) {
+		if tags.path[arg.Name] || isGet && tags.query[arg.Name] == "" && tags.header[arg.Name] == "" && isScalarHttpParam(arg.Type) {
+			vars = append(vars, arg)
+		}
+	}
+	edges = append(edges, gorillaMuxUrlTemplateVarList(vars)...)
 	return path.Join(edges...)
 }
 
@@ -81,6 +103,65 @@ func gorillaMuxUrlTemplateVarList(vars []types.Variable) []string {
 	return list
 }
 
+// PathVar is a variable of http path of method.
+type PathVar struct {
+	Name string
+	// Argument, which value is placed to path, nil if there is no such argument.
+	Arg *types.Variable
+}
+
+// MethodPathVars returns variables of http path of method in order of appearance.
+// Names of variables and arguments are compared ignoring case, dashes and underscores,
+// so {comment_id} and {comment-id} hold argument commentId.
+func MethodPathVars(fn *types.Function) (vars []PathVar) {
+	for _, name := range pathTemplateVars(buildMethodPath(fn)) {
+		v := PathVar{Name: name}
+		for _, arg := range RemoveContextIfFirst(fn.Args) {
+			if normalizePathVarName(arg.Name) == normalizePathVarName(name) {
+				arg := arg
+				v.Arg = &arg
+				break
+			}
+		}
+		vars = append(vars, v)
+	}
+	return vars
+}
+
+// Returns names of variables of gorilla mux path template.
+//
+//		comments/{id}/{kind:[a-z]+} -> [id kind]
+//
+func pathTemplateVars(url string) (vars []string) {
+	for {
+		start := strings.IndexByte(url, '{')
+		end := strings.IndexByte(url, '}')
+		if start == -1 || end < start {
+			return
+		}
+		name := url[start+1 : end]
+		if i := strings.IndexByte(name, ':'); i != -1 {
+			name = name[:i]
+		}
+		vars = append(vars, name)
+		url = url[end+1:]
+	}
+}
+
+func normalizePathVarName(name string) string {
+	return strings.ToLower(strings.Replace(strings.Replace(name, "_", "", -1), "-", "", -1))
+}
+
+// Returns name of path variable for argument.
+func pathVarName(vars []PathVar, arg *types.Variable) (string, bool) {
+	for _, v := range vars {
+		if v.Arg != nil && v.Arg.Name == arg.Name {
+			return v.Name, true
+		}
+	}
+	return "", false
+}
+
 // Render http server constructor.
 //		// This file was automatically generated by "microgen" utility.
 //		// DO NOT EDIT.
@@ -150,19 +231,29 @@ func (t *httpServerTemplate) Render(ctx context.Context) write_strategy.Renderer
 	return f
 }
 
+// Error encoder of method goes before options, so it may be replaced by them.
+// Headers of request are put to context, so response encoder may read Accept header.
+//
+//		append([]http.ServerOption{
+//			http.ServerErrorEncoder(ErrorEncoder),
+//			http.ServerBefore(http.PopulateRequestContext),
+//		}, opts...)
+//
 func (t *httpServerTemplate) serverOpts(ctx context.Context, fn *types.Function) *Statement {
-	s := &Statement{}
-	if Tags(ctx).Has(TracingMiddlewareTag) {
-		s.Op("append(")
-		defer s.Op(")")
-	}
-	s.Id("opts")
+	s := Id("opts")
 	if Tags(ctx).Has(TracingMiddlewareTag) {
-		s.Op(",").Qual(PackagePathGoKitTransportHTTP, "ServerBefore").Call(
+		s = Append(Id("opts"), Qual(PackagePathGoKitTransportHTTP, "ServerBefore").Call(
 			Line().Qual(PackagePathGoKitTracing, "HTTPToContext").Call(Id("tracer"), Lit(fn.Name), Id("logger")),
-		)
+		))
 	}
-	return s
+	return Append(
+		Index().Qual(PackagePathGoKitTransportHTTP, "ServerOption").Values(
+			Line().Qual(PackagePathGoKitTransportHTTP, "ServerErrorEncoder").Call(Id(httpErrorEncoderName(fn))),
+			Line().Qual(PackagePathGoKitTransportHTTP, "ServerBefore").Call(Qual(PackagePathGoKitTransportHTTP, "PopulateRequestContext")),
+			Line(),
+		),
+		s.Op("..."),
+	)
 }
 
 func pathToHttpConverter(servicePath string) string {
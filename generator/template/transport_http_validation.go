@@ -0,0 +1,253 @@
+package template
+
+import (
+	"context"
+	"fmt"
+	"go/constant"
+	"go/token"
+
+	. "github.com/dave/jennifer/jen"
+	mstrings "github.com/devimteam/microgen/generator/strings"
+	"github.com/devimteam/microgen/generator/write_strategy"
+	"github.com/vetcher/go-astra/types"
+)
+
+// Returns true, when values of type may contain enums, which should be validated after decoding from json.
+func containsEnum(info *GenerationInfo, p types.Type, visiting map[string]bool) bool {
+	switch f := p.(type) {
+	case types.TPointer:
+		return containsEnum(info, f.Next, visiting)
+	case types.TArray:
+		return containsEnum(info, f.Next, visiting)
+	case types.TEllipsis:
+		return containsEnum(info, f.Next, visiting)
+	case types.TMap:
+		return containsEnum(info, f.Key, visiting) || containsEnum(info, f.Value, visiting)
+	case types.TName, types.TImport:
+		if lookupTypeMapping(p) != nil || IsInlineType(p) || findNamedType(info, p) != nil {
+			return false
+		}
+		if isEnumType(info, p) {
+			return true
+		}
+		name := typeToProto(p, 1)
+		if visiting[name] {
+			return false
+		}
+		visiting[name] = true
+		defer delete(visiting, name)
+		strct, named := sourceDeclaration(info, p)
+		switch {
+		case strct != nil:
+			for _, field := range strct.Fields {
+				if untransferableField(field) == "" && containsEnum(info, qualifiedType(p, field.Type), visiting) {
+					return true
+				}
+			}
+		case named != nil:
+			return containsEnum(info, qualifiedType(p, named.Type), visiting)
+		}
+	}
+	return false
+}
+
+// Returns arguments of exchange, which should be validated.
+func validatedParams(info *GenerationInfo, params []types.Variable) (res []types.Variable) {
+	for _, param := range params {
+		if containsEnum(info, param.Type, make(map[string]bool)) {
+			res = append(res, param)
+		}
+	}
+	return res
+}
+
+func validateRequestName(fn *types.Function) string {
+	return "_Validate_" + fn.Name + "_Request"
+}
+
+func validateResponseName(fn *types.Function) string {
+	return "_Validate_" + fn.Name + "_Response"
+}
+
+// Returns true, when request of method contains enums and its decoder calls validation.
+func hasRequestValidation(info *GenerationInfo, fn *types.Function) bool {
+	return len(validatedParams(info, RemoveContextIfFirst(fn.Args))) > 0
+}
+
+// Returns true, when response of method contains enums and its decoder calls validation.
+func hasResponseValidation(info *GenerationInfo, fn *types.Function) bool {
+	return len(validatedParams(info, removeErrorIfLast(fn.Results))) > 0
+}
+
+type httpValidationTemplate struct {
+	info *GenerationInfo
+	// Types, which validators should be rendered, in order of discovery.
+	queue    []types.Type
+	rendered map[string]bool
+}
+
+func NewHttpValidationTemplate(info *GenerationInfo) Template {
+	return &httpValidationTemplate{
+		info: info,
+	}
+}
+
+// Render validators of decoded exchanges, which reject unknown values of enums.
+//
+//		func _Validate_SetStatus_Request(req *transport.SetStatusRequest) error {
+//			if err := validateEntityStatus(req.Status); err != nil {
+//				return fmt.Errorf("status: %v", err)
+//			}
+//			return nil
+//		}
+//
+//		func validateEntityStatus(value entity.Status) error {
+//			switch value {
+//			case entity.StatusDraft, entity.StatusPublished, "":
+//				return nil
+//			}
+//			return fmt.Errorf("unknown value %q of entity.Status", value)
+//		}
+//
+func (t *httpValidationTemplate) Render(ctx context.Context) write_strategy.Renderer {
+	f := NewFile("transporthttp")
+	f.ImportAlias(t.info.SourcePackageImport, serviceAlias)
+	f.HeaderComment(t.info.FileHeader)
+
+	t.queue, t.rendered = nil, make(map[string]bool)
+	for _, fn := range t.info.Iface.Methods {
+		if !t.info.AllowedMethods[fn.Name] {
+			continue
+		}
+		if hasRequestValidation(t.info, fn) {
+			f.Add(t.exchangeValidator(ctx, validateRequestName(fn), requestStructName(fn), "req", RemoveContextIfFirst(fn.Args))).Line()
+		}
+		if hasResponseValidation(t.info, fn) {
+			f.Add(t.exchangeValidator(ctx, validateResponseName(fn), responseStructName(fn), "resp", removeErrorIfLast(fn.Results))).Line()
+		}
+	}
+	// Validators are added to queue while previous are rendered.
+	for i := 0; i < len(t.queue); i++ {
+		f.Add(t.typeValidator(ctx, t.queue[i])).Line()
+	}
+	return f
+}
+
+func (t *httpValidationTemplate) exchangeValidator(ctx context.Context, name, exchange, value string, params []types.Variable) *Statement {
+	return Func().Id(name).Params(Id(value).Op("*").Qual(t.info.OutputPackageImport+"/transport", exchange)).Error().BlockFunc(func(g *Group) {
+		for _, param := range validatedParams(t.info, params) {
+			g.Add(t.check(param.Type, Id(value).Dot(mstrings.ToUpperFirst(param.Name)), Lit(mstrings.ToSnakeCase(param.Name)+": %v")))
+		}
+		g.Return(Nil())
+	})
+}
+
+// Adds type to queue of validators to render and returns name of its validator.
+func (t *httpValidationTemplate) validator(p types.Type) string {
+	name := "validate" + typeToProto(p, 1)
+	if !t.rendered[name] {
+		t.rendered[name] = true
+		t.queue = append(t.queue, p)
+	}
+	return name
+}
+
+// Renders call of validator, which error is wrapped with path to invalid value.
+//
+//		if err := validateEntityStatus(req.Status); err != nil {
+//			return fmt.Errorf("status: %v", err)
+//		}
+//
+func (t *httpValidationTemplate) check(p types.Type, value *Statement, format *Statement, args ...Code) *Statement {
+	return If(Err().Op(":=").Id(t.validator(p)).Call(value), Err().Op("!=").Nil()).Block(
+		Return(Qual(PackagePathFmt, "Errorf").Call(append([]Code{format}, append(args, Err())...)...)),
+	)
+}
+
+func (t *httpValidationTemplate) typeValidator(ctx context.Context, p types.Type) *Statement {
+	return Func().Id(t.validator(p)).Params(Id("value").Add(fieldType(ctx, p, false))).Error().BlockFunc(func(g *Group) {
+		visiting := make(map[string]bool)
+		switch f := p.(type) {
+		case types.TPointer:
+			g.If(Id("value").Op("==").Nil()).Block(Return(Nil()))
+			g.Return(Id(t.validator(f.Next)).Call(Op("*").Id("value")))
+			return
+		case types.TArray, types.TEllipsis:
+			next := f.(types.LinearType).NextType()
+			g.For(List(Id("i"), Id("elem")).Op(":=").Range().Id("value")).Block(
+				t.check(next, Id("elem"), Lit("%d: %v"), Id("i")),
+			)
+		case types.TMap:
+			checkKey, checkElem := containsEnum(t.info, f.Key, visiting), containsEnum(t.info, f.Value, visiting)
+			vars := List(Id("key"))
+			if checkElem {
+				vars = List(Id("key"), Id("elem"))
+			}
+			g.For(vars.Op(":=").Range().Id("value")).BlockFunc(func(g *Group) {
+				if checkKey {
+					g.Add(t.check(f.Key, Id("key"), Lit("%v: %v"), Id("key")))
+				}
+				if checkElem {
+					g.Add(t.check(f.Value, Id("elem"), Lit("%v: %v"), Id("key")))
+				}
+			})
+		case types.TName, types.TImport:
+			if values := enumValues(t.info, p); len(values) > 0 {
+				g.Add(t.enumSwitch(ctx, p, values))
+				return
+			}
+			strct, named := sourceDeclaration(t.info, p)
+			switch {
+			case strct != nil:
+				for _, field := range strct.Fields {
+					typ := qualifiedType(p, field.Type)
+					if untransferableField(field) == "" && containsEnum(t.info, typ, visiting) {
+						g.Add(t.check(typ, Id("value").Dot(field.Name), Lit(field.Name+": %v")))
+					}
+				}
+			case named != nil:
+				underlying := qualifiedType(p, named.Type)
+				g.Return(Id(t.validator(underlying)).Call(fieldType(ctx, underlying, false).Call(Id("value"))))
+				return
+			}
+		}
+		g.Return(Nil())
+	})
+}
+
+// Renders check, that value is one of constants of enum or zero value.
+func (t *httpValidationTemplate) enumSwitch(ctx context.Context, p types.Type, values []enumValue) *Statement {
+	format, zero, zeroLit := "unknown value %%d of %#v", constant.MakeInt64(0), Lit(0)
+	if values[0].Value.Kind() == constant.String {
+		format, zero, zeroLit = "unknown value %%q of %#v", constant.MakeString(""), Lit("")
+	}
+	var cases []Code
+	hasZero := false
+	for _, v := range distinctEnumValues(values) {
+		hasZero = hasZero || constant.Compare(v.Value, token.EQL, zero)
+		cases = append(cases, enumConstant(t.info, p, v.Name))
+	}
+	if !hasZero {
+		cases = append(cases, zeroLit)
+	}
+	return Switch(Id("value")).Block(
+		Case(cases...).Block(Return(Nil())),
+	).Line().Return(Qual(PackagePathFmt, "Errorf").Call(Lit(fmt.Sprintf(format, fieldType(ctx, p, false))), Id("value")))
+}
+
+func (httpValidationTemplate) DefaultPath() string {
+	return filenameBuilder(PathTransport, "http", "validation")
+}
+
+func (t *httpValidationTemplate) Prepare(ctx context.Context) error {
+	return nil
+}
+
+func (t *httpValidationTemplate) ChooseStrategy(ctx context.Context) (write_strategy.Strategy, error) {
+	for _, fn := range t.info.Iface.Methods {
+		if t.info.AllowedMethods[fn.Name] && (hasRequestValidation(t.info, fn) || hasResponseValidation(t.info, fn)) {
+			return write_strategy.NewCreateFileStrategy(t.info.OutputFilePath, t.DefaultPath()), nil
+		}
+	}
+	return write_strategy.NewNopStrategy("", ""), nil
+}
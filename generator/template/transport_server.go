@@ -42,8 +42,11 @@ func NewEndpointsServerTemplate(info *GenerationInfo) Template {
 //		}
 //
 func (t *endpointsServerTemplate) Render(ctx context.Context) write_strategy.Renderer {
-	f := NewFile("transport")
+	f := NewFilePathName(t.info.OutputPackageImport+"/"+PathTransport, "transport")
 	f.HeaderComment(t.info.FileHeader)
+	if IsGRPCDirect(t.info.Iface) {
+		f.ImportAlias(t.info.ProtobufPackageImport, "pb")
+	}
 
 	f.Add(t.allEndpoints()).Line()
 	if Tags(ctx).HasAny(TracingMiddlewareTag) {
@@ -52,7 +55,7 @@ func (t *endpointsServerTemplate) Render(ctx context.Context) write_strategy.Ren
 	}
 	for _, signature := range t.info.Iface.Methods {
 		if t.info.AllowedMethods[signature.Name] {
-			f.Add(createEndpoint(signature, t.info)).Line().Line()
+			f.Add(createEndpoint(ctx, signature, t.info)).Line().Line()
 		}
 	}
 	return f
@@ -82,8 +85,12 @@ func (t *endpointsServerTemplate) ChooseStrategy(ctx context.Context) (write_str
 //		}
 //
 func createEndpointBody(signature *normalizedFunction) *Statement {
+	ctxName := "_"
+	if IsContextFirst(signature.parent.Args) {
+		ctxName = firstArgName(&signature.Function)
+	}
 	return Return(Func().Params(
-		Id(firstArgName(&signature.Function)).Qual("context", "Context"),
+		Id(ctxName).Qual("context", "Context"),
 		Id("request").Interface(),
 	).Params(
 		Interface(),
@@ -94,12 +101,12 @@ func createEndpointBody(signature *normalizedFunction) *Statement {
 			g.Id("req").Op(":=").Id("request").Assert(Op("*").Id(requestStructName(signature.parent)))
 		}
 
-		g.Add(paramNames(signature.Results).
-			Op(":=").
-			Id("svc").
+		call := Id("svc").
 			Dot(signature.Name).
 			CallFunc(func(g *Group) {
-				g.Add(Id(firstArgName(&signature.Function)))
+				if IsContextFirst(signature.parent.Args) {
+					g.Add(Id(ctxName))
+				}
 				for _, field := range methodParams {
 					v := Dot(mstrings.ToUpperFirst(field.Name))
 					if types.IsEllipsis(field.Type) {
@@ -107,14 +114,23 @@ func createEndpointBody(signature *normalizedFunction) *Statement {
 					}
 					g.Add(Id("req").Add(v))
 				}
-			}))
+			})
+		if len(signature.Results) > 0 {
+			g.Add(paramNames(signature.Results).Op(":=").Add(call))
+		} else {
+			g.Add(call)
+		}
 
+		errResult := Nil()
+		if IsErrorLast(signature.parent.Results) {
+			errResult = Id(nameOfLastResultError(&signature.Function))
+		}
 		g.Return(
 			Op("&").Id(responseStructName(signature.parent)).Values(dictByNormalVariables(
 				removeErrorIfLast(signature.parent.Results),
 				removeErrorIfLast(signature.Results),
 			)),
-			Id(nameOfLastResultError(&signature.Function)),
+			errResult,
 		)
 	}))
 }
@@ -132,11 +148,15 @@ func createEndpointBody(signature *normalizedFunction) *Statement {
 //			}
 //		}
 //
-func createEndpoint(signature *types.Function, info *GenerationInfo) *Statement {
+func createEndpoint(ctx context.Context, signature *types.Function, info *GenerationInfo) *Statement {
 	normal := normalizeFunction(signature)
+	body := createEndpointBody(normal)
+	if IsGRPCDirect(info.Iface) {
+		body = directEndpointBody(ctx, info, normal)
+	}
 	return Func().
 		Id(endpointsStructFieldName(signature.Name)).Params(Id("svc").Qual(info.SourcePackageImport, info.Iface.Name)).Params(Qual(PackagePathGoKitEndpoint, "Endpoint")).
-		Block(createEndpointBody(normal))
+		Block(body)
 }
 
 func (t *endpointsServerTemplate) allEndpoints() *Statement {
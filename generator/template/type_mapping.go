@@ -0,0 +1,338 @@
+package template
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	. "github.com/dave/jennifer/jen"
+	mstrings "github.com/devimteam/microgen/generator/strings"
+	"github.com/vetcher/go-astra/types"
+)
+
+// TypeMapping declares, how go type is transferred by grpc and http transports.
+// Types are written as builtin name or as import path with name, both may be prefixed with pointer.
+//
+//		int, *string, time.Time, github.com/google/uuid.UUID
+//
+// Converters are qualified names of functions with signatures
+//
+//		ToProto    func(T) (P, error)
+//		FromProto  func(P) (T, error)
+//		ToString   func(T) string
+//		FromString func(string) (T, error)
+//
+// or names of methods of T, prefixed with dot, e.g. `.String`.
+// When grpc converters are not provided, converters for type are generated as stubs or with builtin bodies.
+type TypeMapping struct {
+	GoType string
+	// Type of field in service.proto and .proto file, which declares it.
+	ProtoType   string
+	ProtoImport string
+	// Go type of field, generated by protoc.
+	ProtoGoType string
+
+	ToProto    string
+	FromProto  string
+	ToString   string
+	FromString string
+}
+
+var defaultTypeMappings = []TypeMapping{
+	{GoType: "int", ProtoType: "int64", ProtoGoType: "int64"},
+	{GoType: "uint", ProtoType: "uint64", ProtoGoType: "uint64"},
+	{GoType: "int8", ProtoType: "int32", ProtoGoType: "int32"},
+	{GoType: "int16", ProtoType: "int32", ProtoGoType: "int32"},
+	{GoType: "rune", ProtoType: "int32", ProtoGoType: "int32"},
+	{GoType: "uint8", ProtoType: "uint32", ProtoGoType: "uint32"},
+	{GoType: "uint16", ProtoType: "uint32", ProtoGoType: "uint32"},
+	{GoType: "byte", ProtoType: "uint32", ProtoGoType: "uint32"},
+	{GoType: "float64", ProtoType: "double", ProtoGoType: "float64"},
+	{GoType: "float32", ProtoType: "float", ProtoGoType: "float32"},
+	{GoType: "error", ProtoType: "string", ProtoGoType: "string"},
+	{GoType: JsonbPackage + ".JSONB", ProtoType: "string", ProtoGoType: "string"},
+	{
+		GoType:      "time.Time",
+		ProtoType:   googleProtobufTimestamp,
+		ProtoImport: importGoogleProtobufTimestamp,
+		ProtoGoType: "*" + GolangProtobufPtypesTimestamp + ".Timestamp",
+		ToProto:     GolangProtobufPtypes + ".TimestampProto",
+		FromProto:   GolangProtobufPtypes + ".Timestamp",
+	},
+	{
+		GoType:      "*time.Time",
+		ProtoType:   googleProtobufTimestamp,
+		ProtoImport: importGoogleProtobufTimestamp,
+		ProtoGoType: "*" + GolangProtobufPtypesTimestamp + ".Timestamp",
+	},
+	{
+		GoType:      "time.Duration",
+		ProtoType:   googleProtobufDuration,
+		ProtoImport: importGoogleProtobufDuration,
+		ProtoGoType: "*" + GolangProtobufPtypesDuration + ".Duration",
+		FromProto:   GolangProtobufPtypes + ".Duration",
+		ToString:    ".String",
+		FromString:  "time.ParseDuration",
+	},
+	{
+		GoType:      "interface{}",
+		ProtoType:   googleProtobufValue,
+		ProtoImport: importGoogleProtobufStruct,
+		ProtoGoType: "*" + GolangProtobufPtypesStruct + ".Value",
+	},
+	{
+		GoType:      "map[string]interface{}",
+		ProtoType:   googleProtobufStruct,
+		ProtoImport: importGoogleProtobufStruct,
+		ProtoGoType: "*" + GolangProtobufPtypesStruct + ".Struct",
+	},
+	{
+		GoType:      PackagePathGolangProtobufProto + ".Message",
+		ProtoType:   googleProtobufAny,
+		ProtoImport: importGoogleProtobufAny,
+		ProtoGoType: "*" + GolangProtobufPtypesAny + ".Any",
+	},
+	wrapperTypeMapping("string", "StringValue"),
+	wrapperTypeMapping("bool", "BoolValue"),
+	wrapperTypeMapping("int64", "Int64Value"),
+	wrapperTypeMapping("int32", "Int32Value"),
+	wrapperTypeMapping("uint64", "UInt64Value"),
+	wrapperTypeMapping("uint32", "UInt32Value"),
+	wrapperTypeMapping("float64", "DoubleValue"),
+	wrapperTypeMapping("float32", "FloatValue"),
+	wrapperTypeMapping("int", "Int64Value"),
+	wrapperTypeMapping("uint", "UInt64Value"),
+	wrapperTypeMapping("int16", "Int32Value"),
+	wrapperTypeMapping("int8", "Int32Value"),
+	wrapperTypeMapping("rune", "Int32Value"),
+	wrapperTypeMapping("uint16", "UInt32Value"),
+	wrapperTypeMapping("uint8", "UInt32Value"),
+	wrapperTypeMapping("byte", "UInt32Value"),
+}
+
+// Go types of Value field of wrappers.
+var wrapperValueTypes = map[string]string{
+	"StringValue": "string",
+	"BoolValue":   "bool",
+	"Int64Value":  "int64",
+	"Int32Value":  "int32",
+	"UInt64Value": "uint64",
+	"UInt32Value": "uint32",
+	"DoubleValue": "float64",
+	"FloatValue":  "float32",
+}
+
+// Pointers to scalar types are transferred as wrappers, so nil value is kept.
+// Values of types, which are narrower than value of wrapper, are converted by cast.
+func wrapperTypeMapping(goType, wrapper string) TypeMapping {
+	return TypeMapping{
+		GoType:      "*" + goType,
+		ProtoType:   googleProtobuf + wrapper,
+		ProtoImport: importGoogleProtobufWrappers,
+		ProtoGoType: "*" + GolangProtobufWrappers + "." + wrapper,
+	}
+}
+
+var typeMappings = make(map[string]*TypeMapping)
+
+func init() {
+	ResetTypeMappings()
+}
+
+// ResetTypeMappings restores registry to default mappings, so mappings of tags of one interface
+// are not used for generation of another one.
+func ResetTypeMappings() {
+	typeMappings = make(map[string]*TypeMapping)
+	for _, m := range defaultTypeMappings {
+		if err := RegisterTypeMapping(m); err != nil {
+			panic(err)
+		}
+	}
+}
+
+// RegisterTypeMapping adds mapping to registry or replaces mapping for the same go type.
+// Go type of protobuf field may be omitted for scalar and well-known protobuf types.
+func RegisterTypeMapping(m TypeMapping) error {
+	if known, ok := protoGoTypes[m.ProtoType]; ok {
+		if m.ProtoGoType == "" {
+			m.ProtoGoType = known.goType
+		}
+		if m.ProtoImport == "" {
+			m.ProtoImport = known.importPath
+		}
+	}
+	if err := m.validate(); err != nil {
+		return err
+	}
+	typeMappings[m.GoType] = &m
+	return nil
+}
+
+// Go types, which are generated by protoc for scalar and well-known protobuf types.
+var protoGoTypes = map[string]struct{ goType, importPath string }{
+	"double":   {goType: "float64"},
+	"float":    {goType: "float32"},
+	"int64":    {goType: "int64"},
+	"sint64":   {goType: "int64"},
+	"sfixed64": {goType: "int64"},
+	"uint64":   {goType: "uint64"},
+	"fixed64":  {goType: "uint64"},
+	"int32":    {goType: "int32"},
+	"sint32":   {goType: "int32"},
+	"sfixed32": {goType: "int32"},
+	"uint32":   {goType: "uint32"},
+	"fixed32":  {goType: "uint32"},
+	"bool":     {goType: "bool"},
+	"string":   {goType: "string"},
+	"bytes":    {goType: "[]byte"},
+
+	googleProtobufTimestamp:    {"*" + GolangProtobufPtypesTimestamp + ".Timestamp", importGoogleProtobufTimestamp},
+	googleProtobufDuration:     {"*" + GolangProtobufPtypesDuration + ".Duration", importGoogleProtobufDuration},
+	googleProtobufEmpty:        {"*" + PackagePathEmptyProtobuf + ".Empty", importGoogleProtobufEmpty},
+	googleProtobufStringValue:  {"*" + GolangProtobufWrappers + ".StringValue", importGoogleProtobufWrappers},
+	googleProtobufBoolValue:    {"*" + GolangProtobufWrappers + ".BoolValue", importGoogleProtobufWrappers},
+	googleProtobufInt64Value:   {"*" + GolangProtobufWrappers + ".Int64Value", importGoogleProtobufWrappers},
+	googleProtobufUInt64Value:  {"*" + GolangProtobufWrappers + ".UInt64Value", importGoogleProtobufWrappers},
+	googleProtobufInt32Value:   {"*" + GolangProtobufWrappers + ".Int32Value", importGoogleProtobufWrappers},
+	googleProtobufUInt32Value:  {"*" + GolangProtobufWrappers + ".UInt32Value", importGoogleProtobufWrappers},
+	googleProtobufFloat64Value: {"*" + GolangProtobufWrappers + ".DoubleValue", importGoogleProtobufWrappers},
+	googleProtobufFloat32Value: {"*" + GolangProtobufWrappers + ".FloatValue", importGoogleProtobufWrappers},
+	googleProtobufBytesValue:   {"*" + GolangProtobufWrappers + ".BytesValue", importGoogleProtobufWrappers},
+	googleProtobufValue:        {"*" + GolangProtobufPtypesStruct + ".Value", importGoogleProtobufStruct},
+	googleProtobufStruct:       {"*" + GolangProtobufPtypesStruct + ".Struct", importGoogleProtobufStruct},
+	googleProtobufAny:          {"*" + GolangProtobufPtypesAny + ".Any", importGoogleProtobufAny},
+}
+
+// Returns mapping for go type or nil, when type is transferred as is.
+// Mappings of types, which protoc generates as the same go type, e.g. float64 as double, only name protobuf type,
+// so they are returned only by protoTypeMapping.
+func lookupTypeMapping(t types.Type) *TypeMapping {
+	if m := protoTypeMapping(t); m != nil && !m.isProtoName() {
+		return m
+	}
+	return nil
+}
+
+// Returns mapping for go type, including mappings, which only name protobuf type.
+func protoTypeMapping(t types.Type) *TypeMapping {
+	return typeMappings[typeMappingKey(t)]
+}
+
+// Returns go type in the form of TypeMapping.GoType.
+//
+//		*github.com/google/uuid.UUID
+//		map[string]interface{}
+//
+func typeMappingKey(t types.Type) string {
+	switch f := t.(type) {
+	case types.TInterface:
+		if f.Interface == nil || len(f.Interface.Methods)+len(f.Interface.Interfaces) == 0 {
+			return "interface{}"
+		}
+	case types.TMap:
+		return "map[" + typeMappingKey(f.Key) + "]" + typeMappingKey(f.Value)
+	case types.TName:
+		return f.TypeName
+	case types.TImport:
+		if f.Import == nil {
+			return typeMappingKey(f.Next)
+		}
+		return f.Import.Package + "." + typeMappingKey(f.Next)
+	case types.TPointer:
+		return strings.Repeat("*", f.NumberOfPointers) + typeMappingKey(f.Next)
+	}
+	return t.String()
+}
+
+// Splits type to pointers, package and name.
+//
+//		*github.com/google/uuid.UUID -> *, github.com/google/uuid, UUID
+//
+func splitGoType(t string) (ptr string, pkg string, name string) {
+	name = strings.TrimLeft(t, "*")
+	ptr = t[:len(t)-len(name)]
+	if i := strings.LastIndex(name, "."); i > strings.LastIndex(name, "/") {
+		pkg, name = name[:i], name[i+1:]
+	}
+	return ptr, pkg, name
+}
+
+func goTypeCode(t string) *Statement {
+	ptr, pkg, name := splitGoType(t)
+	if pkg == "" {
+		return Op(ptr).Id(name)
+	}
+	return Op(ptr).Qual(pkg, name)
+}
+
+// Returns type with package name instead of import path, as it is printed by go-astra.
+//
+//		*github.com/golang/protobuf/ptypes/duration.Duration -> *duration.Duration
+//
+func shortGoType(t string) string {
+	ptr, pkg, name := splitGoType(t)
+	if pkg == "" {
+		return t
+	}
+	return ptr + path.Base(pkg) + "." + name
+}
+
+// Mapped type, which is converted by cast, e.g. int to int64.
+func (m *TypeMapping) isCast() bool {
+	return m.ToProto == "" && m.FromProto == "" &&
+		mstrings.IsInStringSlice(m.GoType, castableGolangTypes) &&
+		mstrings.IsInStringSlice(m.ProtoGoType, defaultProtoTypes)
+}
+
+// Mapping only names protobuf type, value is transferred as is.
+func (m *TypeMapping) isProtoName() bool {
+	return m.GoType == m.ProtoGoType && m.ToProto == "" && m.FromProto == "" && m.ToString == "" && m.FromString == ""
+}
+
+var castableGolangTypes = append([]string{"int8", "int16", "uint8", "uint16", "rune"}, defaultGolangTypes...)
+
+// Protobuf type is message, e.g. google.protobuf.Timestamp.
+func (m *TypeMapping) isMessage() bool {
+	return strings.HasPrefix(m.ProtoGoType, "*")
+}
+
+func (m *TypeMapping) protoGoType() *Statement {
+	return goTypeCode(m.ProtoGoType)
+}
+
+// Renders call of converter function or method.
+//
+//		uuid.Parse(value)
+//		value.String()
+//
+func converterCall(fn string, value *Statement) *Statement {
+	if strings.HasPrefix(fn, ".") {
+		return value.Clone().Dot(fn[1:]).Call()
+	}
+	_, pkg, name := splitGoType(fn)
+	if pkg == "" {
+		return Id(name).Call(value)
+	}
+	return Qual(pkg, name).Call(value)
+}
+
+// HasStringConverters returns true, when type may be placed to path or query of http request.
+func HasStringConverters(t types.Type) bool {
+	m := lookupTypeMapping(t)
+	return m != nil && m.ToString != "" && m.FromString != ""
+}
+
+// Returns error, when mapping can not be used for generation.
+func (m *TypeMapping) validate() error {
+	switch {
+	case m.GoType == "":
+		return fmt.Errorf("go type is required")
+	case m.ProtoType == "" && (m.ToProto != "" || m.FromProto != ""):
+		return fmt.Errorf("%s: protobuf type is required for protobuf converters", m.GoType)
+	case m.ProtoType != "" && m.ProtoGoType == "":
+		return fmt.Errorf("%s: go type of protobuf field is unknown for %s", m.GoType, m.ProtoType)
+	case (m.ToString == "") != (m.FromString == ""):
+		return fmt.Errorf("%s: both string converters should be provided", m.GoType)
+	}
+	return nil
+}
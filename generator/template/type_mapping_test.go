@@ -0,0 +1,85 @@
+package template
+
+import (
+	"testing"
+
+	"github.com/vetcher/go-astra/types"
+)
+
+func TestRegisterTypeMapping(t *testing.T) {
+	ResetTypeMappings()
+	defer ResetTypeMappings()
+
+	uuid := types.TImport{Import: &types.Import{Package: "github.com/google/uuid"}, Next: types.TName{TypeName: "UUID"}}
+	if err := RegisterTypeMapping(TypeMapping{GoType: "github.com/google/uuid.UUID", ProtoType: "string"}); err != nil {
+		t.Fatal(err)
+	}
+	if m := lookupTypeMapping(uuid); m == nil || m.ProtoGoType != "string" || m.ProtoImport != "" {
+		t.Errorf("go type of scalar protobuf type is not filled: %+v", m)
+	}
+	if err := RegisterTypeMapping(TypeMapping{GoType: "github.com/google/uuid.UUID", ProtoType: googleProtobufStringValue}); err != nil {
+		t.Fatal(err)
+	}
+	if m := lookupTypeMapping(uuid); m == nil || m.ProtoGoType != "*"+GolangProtobufWrappers+".StringValue" || m.ProtoImport != importGoogleProtobufWrappers {
+		t.Errorf("mapping is not replaced or well-known type is not filled: %+v", m)
+	}
+
+	for _, test := range []struct {
+		mapping TypeMapping
+		err     string
+	}{
+		{TypeMapping{ProtoType: "string"}, "go type is required"},
+		{TypeMapping{GoType: "a.T", ToProto: "a.ToProto"}, "a.T: protobuf type is required for protobuf converters"},
+		{TypeMapping{GoType: "a.T", ProtoType: "a.Message"}, "a.T: go type of protobuf field is unknown for a.Message"},
+		{TypeMapping{GoType: "a.T", ToString: ".String"}, "a.T: both string converters should be provided"},
+	} {
+		if err := RegisterTypeMapping(test.mapping); err == nil || err.Error() != test.err {
+			t.Errorf("%+v: error %v, expected %s", test.mapping, err, test.err)
+		}
+	}
+
+	ResetTypeMappings()
+	if lookupTypeMapping(uuid) != nil {
+		t.Error("registered mapping is kept after reset")
+	}
+}
+
+func TestDefaultTypeMappings(t *testing.T) {
+	ResetTypeMappings()
+	defer ResetTypeMappings()
+
+	// Protobuf types of builtin types should be the same, as protobuf package is validated with.
+	for goType, protoGoType := range builtinProtoTypes {
+		m := protoTypeMapping(types.TName{TypeName: goType})
+		if m == nil {
+			if goType != protoGoType {
+				t.Errorf("%s: mapping to %s is not registered", goType, protoGoType)
+			}
+			continue
+		}
+		if m.ProtoGoType != protoGoType {
+			t.Errorf("%s: mapped to %s, but protobuf package is validated with %s", goType, m.ProtoGoType, protoGoType)
+		}
+	}
+	for goType, cast := range map[string]bool{"int": true, "int8": true, "uint16": true, "float64": false, "string": false} {
+		m := lookupTypeMapping(types.TName{TypeName: goType})
+		if (m != nil && m.isCast()) != cast {
+			t.Errorf("%s: cast is %v, expected %v", goType, m != nil && m.isCast(), cast)
+		}
+	}
+	if name, _ := protoTypeName(types.TArray{IsSlice: true, Next: types.TName{TypeName: "byte"}}); name != "bytes" {
+		t.Errorf("[]byte is mapped to %s", name)
+	}
+
+	ptr := types.TPointer{NumberOfPointers: 1, Next: types.TName{TypeName: "string"}}
+	if lookupTypeMapping(ptr) == nil {
+		t.Fatal("pointer to string is not mapped to wrapper")
+	}
+	UseOptionalPointers()
+	if lookupTypeMapping(ptr) != nil {
+		t.Error("wrapper is kept for optional pointers")
+	}
+	if lookupTypeMapping(types.TName{TypeName: "int"}) == nil {
+		t.Error("mapping of int is removed with wrappers")
+	}
+}
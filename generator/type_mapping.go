@@ -0,0 +1,99 @@
+package generator
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/devimteam/microgen/generator/template"
+	"github.com/vetcher/go-astra/types"
+)
+
+// RegisterTypeMappings adds mappings, declared by @type-mapping tags of interface, to registry of type mappings.
+// Wrappers of pointers to scalar types are removed, when interface has @protobuf-pointers optional tag.
+// Each tag declares mapping for one go type, go type and functions are qualified by import paths
+// or by names of packages, imported by source file.
+//
+//		// @type-mapping uuid.UUID proto=string to-proto=example.com/conv.UUIDToString from-proto=example.com/conv.StringToUUID to-string=.String from-string=github.com/google/uuid.Parse
+//		// @type-mapping time.Duration proto=google.protobuf.Duration
+//
+func RegisterTypeMappings(iface *types.Interface, file *types.File) error {
+	var errs []error
+	// Wrappers are removed before mappings of tags are registered, so tags may declare other mappings for pointers.
+	mode, err := template.ParseProtobufPointers(iface.Docs)
+	if err != nil {
+		return err
+	}
+	if mode == template.ProtobufPointersOptional {
+		template.UseOptionalPointers()
+	}
+	for _, doc := range iface.Docs {
+		if !strings.HasPrefix(doc, TagMark+TypeMappingTag+" ") {
+			continue
+		}
+		m, err := parseTypeMapping(strings.TrimPrefix(doc, TagMark+TypeMappingTag), file)
+		if err == nil {
+			err = template.RegisterTypeMapping(m)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("@%s: %v", TypeMappingTag, err))
+		}
+	}
+	return composeErrors(errs...)
+}
+
+func parseTypeMapping(tag string, file *types.File) (template.TypeMapping, error) {
+	fields := strings.Fields(tag)
+	if len(fields) == 0 {
+		return template.TypeMapping{}, fmt.Errorf("go type is required")
+	}
+	m := template.TypeMapping{GoType: resolveQualifiedName(fields[0], file)}
+	for _, field := range fields[1:] {
+		kv := strings.SplitN(field, "=", 2)
+		if len(kv) != 2 {
+			return m, fmt.Errorf("%s: %s should be in form of key=value", m.GoType, field)
+		}
+		value := resolveQualifiedName(kv[1], file)
+		switch kv[0] {
+		case "proto":
+			m.ProtoType = kv[1]
+		case "proto-import":
+			m.ProtoImport = kv[1]
+		case "proto-go":
+			m.ProtoGoType = value
+		case "to-proto":
+			m.ToProto = value
+		case "from-proto":
+			m.FromProto = value
+		case "to-string":
+			m.ToString = value
+		case "from-string":
+			m.FromString = value
+		default:
+			return m, fmt.Errorf("%s: unknown key %s", m.GoType, kv[0])
+		}
+	}
+	return m, nil
+}
+
+// Replaces name of package, imported by file, with import path.
+//
+//		*uuid.UUID -> *github.com/google/uuid.UUID
+//
+func resolveQualifiedName(name string, file *types.File) string {
+	qualified := strings.TrimLeft(name, "*")
+	ptr := name[:len(name)-len(qualified)]
+	i := strings.LastIndex(qualified, ".")
+	if i <= 0 || strings.Contains(qualified[:i], "/") || file == nil {
+		return name
+	}
+	for _, imp := range file.Imports {
+		if imp == nil {
+			continue
+		}
+		if imp.Name == qualified[:i] || (imp.Name == "" && path.Base(imp.Package) == qualified[:i]) {
+			return ptr + imp.Package + qualified[i:]
+		}
+	}
+	return name
+}
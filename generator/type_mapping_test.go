@@ -0,0 +1,92 @@
+package generator
+
+import (
+	"testing"
+
+	"github.com/devimteam/microgen/generator/template"
+	"github.com/stretchr/testify/assert"
+	"github.com/vetcher/go-astra/types"
+)
+
+func TestParseTypeMapping(t *testing.T) {
+	file := &types.File{Imports: []*types.Import{
+		{Package: "github.com/google/uuid"},
+		{Base: types.Base{Name: "c"}, Package: "example.com/conv"},
+	}}
+	tests := []struct {
+		tag     string
+		mapping template.TypeMapping
+		err     string
+	}{
+		{
+			tag: " uuid.UUID proto=string to-proto=c.UUIDToString from-proto=c.StringToUUID to-string=.String from-string=uuid.Parse",
+			mapping: template.TypeMapping{
+				GoType:     "github.com/google/uuid.UUID",
+				ProtoType:  "string",
+				ToProto:    "example.com/conv.UUIDToString",
+				FromProto:  "example.com/conv.StringToUUID",
+				ToString:   ".String",
+				FromString: "github.com/google/uuid.Parse",
+			},
+		},
+		{
+			tag: " *uuid.UUID proto=google.protobuf.StringValue proto-import=google/protobuf/wrappers.proto proto-go=*github.com/golang/protobuf/ptypes/wrappers.StringValue",
+			mapping: template.TypeMapping{
+				GoType:      "*github.com/google/uuid.UUID",
+				ProtoType:   "google.protobuf.StringValue",
+				ProtoImport: "google/protobuf/wrappers.proto",
+				ProtoGoType: "*github.com/golang/protobuf/ptypes/wrappers.StringValue",
+			},
+		},
+		{
+			tag:     " example.com/money.Amount proto=int64",
+			mapping: template.TypeMapping{GoType: "example.com/money.Amount", ProtoType: "int64"},
+		},
+		{
+			tag:     " unknown.Type proto=string",
+			mapping: template.TypeMapping{GoType: "unknown.Type", ProtoType: "string"},
+		},
+		{
+			tag: " ",
+			err: "go type is required",
+		},
+		{
+			tag: " uuid.UUID proto",
+			err: "github.com/google/uuid.UUID: proto should be in form of key=value",
+		},
+		{
+			tag: " uuid.UUID proto=string type=uuid",
+			err: "github.com/google/uuid.UUID: unknown key type",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.tag, func(t *testing.T) {
+			m, err := parseTypeMapping(test.tag, file)
+			if test.err != "" {
+				assert.EqualError(t, err, test.err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, test.mapping, m)
+		})
+	}
+}
+
+func TestRegisterTypeMappings(t *testing.T) {
+	template.ResetTypeMappings()
+	defer template.ResetTypeMappings()
+	file := &types.File{Imports: []*types.Import{{Package: "github.com/google/uuid"}}}
+	iface := &types.Interface{Base: types.Base{Docs: []string{
+		"// @microgen grpc",
+		"// @type-mapping uuid.UUID proto=string to-string=.String from-string=uuid.Parse",
+		"// @type-mapping uuid.NullUUID proto=unknown.Message",
+		"// @type-mapping uuid.Time to-string=.String",
+		"// @type-mapping uuid.Domain proto",
+	}}}
+	assert.EqualError(t, RegisterTypeMappings(iface, file), `many errors:
+@type-mapping: github.com/google/uuid.NullUUID: go type of protobuf field is unknown for unknown.Message
+@type-mapping: github.com/google/uuid.Time: both string converters should be provided
+@type-mapping: github.com/google/uuid.Domain: proto should be in form of key=value`)
+	uuid := types.TImport{Import: &types.Import{Package: "github.com/google/uuid"}, Next: types.TName{TypeName: "UUID"}}
+	assert.True(t, template.HasStringConverters(uuid))
+}
@@ -2,39 +2,66 @@ package generator
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
 	"strings"
 
 	mstrings "github.com/devimteam/microgen/generator/strings"
 	"github.com/devimteam/microgen/generator/template"
+	lg "github.com/devimteam/microgen/logger"
+	"github.com/vetcher/go-astra"
 	"github.com/vetcher/go-astra/types"
 )
 
-func ValidateInterface(iface *types.Interface) error {
+// ValidateInterface checks methods of interface against tags of generation. Named types of arguments
+// are resolved in package of source file and in packages, imported by it.
+func ValidateInterface(iface *types.Interface, sourceFile string) error {
+	info := &template.GenerationInfo{SourceFilePath: sourceFile, Iface: iface}
 	var errs []error
 	if len(iface.Methods) == 0 {
 		errs = append(errs, fmt.Errorf("%s does not have any methods", iface.Name))
 	}
+	if _, err := template.ParseGRPCErrors(iface.Docs); err != nil {
+		errs = append(errs, fmt.Errorf("%s: %v", iface.Name, err))
+	}
+	if _, err := template.ParseGRPCMetadata(iface.Docs); err != nil {
+		errs = append(errs, fmt.Errorf("%s: %v", iface.Name, err))
+	}
+	if _, err := template.ParseHTTPErrors(iface.Docs); err != nil {
+		errs = append(errs, fmt.Errorf("%s: %v", iface.Name, err))
+	}
+	errs = append(errs, validateGRPCDirect(iface)...)
 	for _, m := range iface.Methods {
-		errs = append(errs, validateFunction(m)...)
+		errs = append(errs, validateFunction(info, m)...)
+		errs = append(errs, validateChannels(iface, m)...)
+		errs = append(errs, validateFunctionParams(iface, m)...)
+		errs = append(errs, validateProtoMessages(iface, m)...)
+		errs = append(errs, validateHttpFiles(info, m)...)
+		if _, err := template.ParseGRPCErrors(m.Docs); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %v", m.Name, err))
+		}
+		if _, err := template.ParseGRPCMetadata(m.Docs); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %v", m.Name, err))
+		}
+		if _, err := template.ParseHTTPErrors(m.Docs); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %v", m.Name, err))
+		}
 	}
 	return composeErrors(errs...)
 }
 
 // Rules:
-// * First argument is context.Context.
-// * Last result is error.
 // * All params have names.
-func validateFunction(fn *types.Function) (errs []error) {
+// * Raw structs and functions are not wrapped by pointers, slices or maps.
+// * Arguments, placed to path, query or headers of http request, are strings, booleans, numbers, named types of them, time.Time
+// or have string converters. Pointers to them and slices of them are placed only to query and headers.
+// Methods without context.Context as first argument or error as last result are allowed,
+// transport adapts them (see template.isAdaptedMethod).
+func validateFunction(info *template.GenerationInfo, fn *types.Function) (errs []error) {
 	// don't validate when `@microgen -` provided
 	if mstrings.ContainTag(mstrings.FetchTags(fn.Docs, TagMark+MicrogenMainTag), "-") {
 		return
 	}
-	if !template.IsContextFirst(fn.Args) {
-		errs = append(errs, fmt.Errorf("%s: first argument should be of type context.Context", fn.Name))
-	}
-	if !template.IsErrorLast(fn.Results) {
-		errs = append(errs, fmt.Errorf("%s: last result should be of type error", fn.Name))
-	}
 	for _, param := range append(fn.Args, fn.Results...) {
 		if param.Name == "" {
 			errs = append(errs, fmt.Errorf("%s: unnamed parameter of type %s", fn.Name, param.Type.String()))
@@ -42,34 +69,168 @@ func validateFunction(fn *types.Function) (errs []error) {
 		if iface := types.TypeInterface(param.Type); iface != nil && !iface.(types.TInterface).Interface.IsEmpty() {
 			errs = append(errs, fmt.Errorf("%s: non empty interface %s is not allowed, delcare it outside", fn.Name, param.String()))
 		}
-		if strct := types.TypeStruct(param.Type); strct != nil {
-			errs = append(errs, fmt.Errorf("%s: raw struct %s is not allowed, declare it outside", fn.Name, param.Name))
+		// Inline struct and function types are promoted to named types in transport package,
+		// but only when they are not wrapped by pointer, slice or map.
+		if strct := types.TypeStruct(param.Type); strct != nil && !template.IsInlineType(param.Type) {
+			errs = append(errs, fmt.Errorf("%s: raw struct %s is allowed only as type of parameter, declare it outside", fn.Name, param.Name))
 		}
-		if f := types.TypeFunction(param.Type); f != nil {
-			errs = append(errs, fmt.Errorf("%s: raw function %s is not allowed, declare it outside", fn.Name, param.Name))
+		if isFunctionType(param.Type) && !template.IsInlineType(param.Type) {
+			errs = append(errs, fmt.Errorf("%s: raw function %s is allowed only as type of parameter, declare it outside", fn.Name, param.Name))
 		}
 	}
-	if template.FetchHttpMethodTag(fn.Docs) == "GET" && !isArgumentsAllowSmartPath(fn) {
-		errs = append(errs, fmt.Errorf("%s: can't use GET method with provided arguments", fn.Name))
+	for _, v := range template.MethodPathVars(fn) {
+		if v.Arg == nil {
+			errs = append(errs, fmt.Errorf("%s: path variable {%s} does not match any argument", fn.Name, v.Name))
+		}
+	}
+	params, err := template.ParseHttpParams(fn)
+	if err != nil {
+		errs = append(errs, fmt.Errorf("%s: %v", fn.Name, err))
+	}
+	for _, p := range params {
+		if !template.CanPlaceHttpParam(info, p.Arg.Type, p.Place) {
+			errs = append(errs, fmt.Errorf("%s: argument %s of type %s can't be placed to %s of http request", fn.Name, p.Arg.Name, p.Arg.Type.String(), p.Place))
+		}
 	}
 	return
 }
 
-func isArgumentsAllowSmartPath(fn *types.Function) bool {
-	for _, arg := range template.RemoveContextIfFirst(fn.Args) {
-		if !canInsertToPath(&arg) {
-			return false
+// Channels are transferred by grpc streams.
+// Rules:
+// * Channels are directional, arguments are receive-only.
+// * Method has at most one stream of requests: receive-only argument or send-only result,
+// and at most one stream of responses: receive-only result.
+// * Method with channel result does not have other results, except error and channels,
+// because stream is returned before it is finished.
+// * Channels are not transferred by http and json-rpc.
+func validateChannels(iface *types.Interface, fn *types.Function) (errs []error) {
+	if mstrings.ContainTag(mstrings.FetchTags(fn.Docs, TagMark+MicrogenMainTag), "-") {
+		return
+	}
+	var requests, responses, chanResults, plainResults int
+	for _, arg := range fn.Args {
+		ch, ok := arg.Type.(types.TChan)
+		switch {
+		case !ok:
+			continue
+		case ch.Direction != types.ChanDirRecv:
+			errs = append(errs, fmt.Errorf("%s: channel argument %s should be receive-only", fn.Name, arg.Name))
+		}
+		requests++
+	}
+	results := fn.Results
+	if template.IsErrorLast(results) {
+		results = results[:len(results)-1]
+	}
+	for _, res := range results {
+		ch, ok := res.Type.(types.TChan)
+		if !ok {
+			plainResults++
+			continue
+		}
+		chanResults++
+		switch ch.Direction {
+		case types.ChanDirSend:
+			requests++
+		case types.ChanDirRecv:
+			responses++
+		default:
+			errs = append(errs, fmt.Errorf("%s: channel result %s should be directional", fn.Name, res.Name))
+		}
+	}
+	if requests+responses == 0 {
+		return errs
+	}
+	if requests > 1 || responses > 1 {
+		errs = append(errs, fmt.Errorf("%s: only one stream of requests and one stream of responses are allowed", fn.Name))
+	}
+	if chanResults > 0 && plainResults > 0 {
+		errs = append(errs, fmt.Errorf("%s: results are not allowed with channel results", fn.Name))
+	}
+	tags := mstrings.FetchTags(iface.Docs, TagMark+MicrogenMainTag)
+	for _, tag := range []string{HttpTag, HttpServerTag, HttpClientTag, JSONRPCTag, JSONRPCServerTag, JSONRPCClientTag} {
+		if mstrings.ContainTag(tags, tag) {
+			errs = append(errs, fmt.Errorf("%s: channels are transferred only by grpc, but %s transport is generated", fn.Name, tag))
+			break
+		}
+	}
+	return errs
+}
+
+// Functions can not be encoded to json or protobuf, so inline function types are allowed
+// only when interface is not transferred by grpc, http or json-rpc.
+func validateFunctionParams(iface *types.Interface, fn *types.Function) (errs []error) {
+	if mstrings.ContainTag(mstrings.FetchTags(fn.Docs, TagMark+MicrogenMainTag), "-") {
+		return
+	}
+	tags := mstrings.FetchTags(iface.Docs, TagMark+MicrogenMainTag)
+	for _, param := range append(fn.Args, fn.Results...) {
+		if !isFunctionType(param.Type) {
+			continue
+		}
+		for _, tag := range []string{GrpcTag, GrpcServerTag, GrpcClientTag, HttpTag, HttpServerTag, HttpClientTag, JSONRPCTag, JSONRPCServerTag, JSONRPCClientTag} {
+			if mstrings.ContainTag(tags, tag) {
+				errs = append(errs, fmt.Errorf("%s: function %s can not be transferred by %s transport", fn.Name, param.Name, tag))
+				break
+			}
 		}
 	}
-	return true
+	return errs
 }
 
-var insertableToUrlTypes = []string{"string", "int", "int32", "int64", "uint", "uint32", "uint64"}
+// Returns true for function type, which may be wrapped by pointers or slices.
+// Inline function types are parsed as pointers to types.Function.
+func isFunctionType(t types.Type) bool {
+	switch f := t.(type) {
+	case types.Function, *types.Function:
+		return true
+	case types.LinearType:
+		return isFunctionType(f.NextType())
+	}
+	return false
+}
 
-// We can make url variable from string, int, int32, int64, uint, uint32, uint64
-func canInsertToPath(p *types.Variable) bool {
-	name := types.TypeName(p.Type)
-	return name != nil && mstrings.IsInStringSlice(*name, insertableToUrlTypes)
+// ValidateProtobuf checks interface against go package, compiled from .proto file, which is provided by @protobuf tag.
+// Validation is skipped, when grpc transport is not generated or package can not be found in GOPATH,
+// e.g. when it will be compiled later from generated service.proto.
+func ValidateProtobuf(iface *types.Interface, sourceFile string) error {
+	tags := mstrings.FetchTags(iface.Docs, TagMark+MicrogenMainTag)
+	if !mstrings.ContainTag(tags, GrpcTag) && !mstrings.ContainTag(tags, GrpcServerTag) && !mstrings.ContainTag(tags, GrpcClientTag) {
+		return nil
+	}
+	pbImport := mstrings.FetchMetaInfo(TagMark+ProtobufTag, iface.Docs)
+	if pbImport == "" {
+		return nil
+	}
+	pbDir := ""
+	for _, path := range filepath.SplitList(os.Getenv("GOPATH")) {
+		dir := filepath.Join(path, "src", pbImport)
+		if fi, err := os.Stat(dir); err == nil && fi.IsDir() {
+			pbDir = dir
+			break
+		}
+	}
+	if pbDir == "" {
+		lg.Logger.Logln(1, "Warning: protobuf package", pbImport, "not found, validation is skipped")
+		return nil
+	}
+	pb, err := parsePackageDir(pbDir)
+	if err != nil {
+		return fmt.Errorf("protobuf package %s: %v", pbImport, err)
+	}
+	source, err := parsePackageDir(filepath.Dir(sourceFile))
+	if err != nil {
+		return err
+	}
+	return composeErrors(template.ValidateProtobufPackage(iface, pb, source)...)
+}
+
+func parsePackageDir(dir string) (*types.File, error) {
+	files, err := astra.ParsePackage(dir, astra.AllowAnyImportAliases)
+	if err != nil {
+		return nil, err
+	}
+	return astra.MergeFiles(files)
 }
 
 func composeErrors(errs ...error) error {
@@ -89,3 +250,117 @@ func composeErrors(errs ...error) error {
 	}
 	return nil
 }
+
+// Messages of proto.Message type are transferred as google.protobuf.Any,
+// which can not be decoded from json, so they are not allowed with http and json-rpc transports.
+func validateProtoMessages(iface *types.Interface, fn *types.Function) (errs []error) {
+	if mstrings.ContainTag(mstrings.FetchTags(fn.Docs, TagMark+MicrogenMainTag), "-") {
+		return
+	}
+	tags := mstrings.FetchTags(iface.Docs, TagMark+MicrogenMainTag)
+	for _, param := range append(fn.Args, fn.Results...) {
+		if !template.ContainsProtoMessage(param.Type) {
+			continue
+		}
+		for _, tag := range []string{HttpTag, HttpServerTag, HttpClientTag, JSONRPCTag, JSONRPCServerTag, JSONRPCClientTag} {
+			if mstrings.ContainTag(tags, tag) {
+				errs = append(errs, fmt.Errorf("%s: %s is transferred only by grpc, but %s transport is generated", fn.Name, param.Name, tag))
+				break
+			}
+		}
+	}
+	return errs
+}
+
+// Files are streamed only by http.
+// Rules:
+// * Arguments of io.Reader type are placed by @http-file tag.
+// * Body arguments, which are sent in multipart form with files, may be placed to query.
+// * Method has at most one io.ReadCloser result, which is streamed as body of response,
+// other results are only string with content type of body and error.
+// * Readers are not transferred by grpc and json-rpc.
+func validateHttpFiles(info *template.GenerationInfo, fn *types.Function) (errs []error) {
+	if mstrings.ContainTag(mstrings.FetchTags(fn.Docs, TagMark+MicrogenMainTag), "-") {
+		return
+	}
+	// Errors of parsing are reported by validateFunction.
+	params, err := template.ParseHttpParams(fn)
+	if err != nil {
+		return
+	}
+	hasFiles := false
+	for _, p := range params {
+		if p.Place == template.HttpParamFile {
+			hasFiles = true
+		}
+	}
+	var readers []string
+	for _, p := range params {
+		if template.IsReaderType(p.Arg.Type) {
+			readers = append(readers, p.Arg.Name)
+		}
+		switch {
+		case template.IsReaderType(p.Arg.Type) && p.Place != template.HttpParamFile:
+			errs = append(errs, fmt.Errorf("%s: argument %s of type io.Reader should be sent as file by @%s", fn.Name, p.Arg.Name, template.HttpFileTag))
+		case p.Place == template.HttpParamBody && hasFiles && !template.CanPlaceHttpParam(info, p.Arg.Type, template.HttpParamQuery):
+			errs = append(errs, fmt.Errorf("%s: argument %s of type %s can't be sent in multipart form with files", fn.Name, p.Arg.Name, p.Arg.Type.String()))
+		}
+	}
+	results := fn.Results
+	if template.IsErrorLast(results) {
+		results = results[:len(results)-1]
+	}
+	var bodies, contentTypes, others int
+	for _, res := range results {
+		switch {
+		case template.IsReadCloserType(res.Type):
+			bodies++
+			readers = append(readers, res.Name)
+		case res.Type.String() == "string":
+			contentTypes++
+		default:
+			others++
+		}
+	}
+	if bodies > 1 || bodies == 1 && (contentTypes > 1 || others > 0) {
+		errs = append(errs, fmt.Errorf("%s: method with io.ReadCloser result may return only string content type and error", fn.Name))
+	}
+	tags := mstrings.FetchTags(info.Iface.Docs, TagMark+MicrogenMainTag)
+	for _, name := range readers {
+		for _, tag := range []string{GrpcTag, GrpcServerTag, GrpcClientTag, JSONRPCTag, JSONRPCServerTag, JSONRPCClientTag} {
+			if mstrings.ContainTag(tags, tag) {
+				errs = append(errs, fmt.Errorf("%s: %s is streamed only by http, but %s transport is generated", fn.Name, name, tag))
+				break
+			}
+		}
+	}
+	return errs
+}
+
+// Endpoints of direct grpc transport read and write protobuf messages, so
+// other transports can not be generated and streams, which use exchanges, are not allowed.
+func validateGRPCDirect(iface *types.Interface) (errs []error) {
+	if !template.IsGRPCDirect(iface) {
+		return nil
+	}
+	tags := mstrings.FetchTags(iface.Docs, TagMark+MicrogenMainTag)
+	if !mstrings.ContainTag(tags, GrpcTag) && !mstrings.ContainTag(tags, GrpcServerTag) && !mstrings.ContainTag(tags, GrpcClientTag) {
+		errs = append(errs, fmt.Errorf("%s: %s requires grpc transport", iface.Name, GrpcDirectTag))
+	}
+	for _, tag := range []string{HttpTag, HttpServerTag, HttpClientTag, JSONRPCTag, JSONRPCServerTag, JSONRPCClientTag} {
+		if mstrings.ContainTag(tags, tag) {
+			errs = append(errs, fmt.Errorf("%s: %s can not be used with %s transport", iface.Name, GrpcDirectTag, tag))
+		}
+	}
+	for _, fn := range iface.Methods {
+		if mstrings.ContainTag(mstrings.FetchTags(fn.Docs, TagMark+MicrogenMainTag), "-") {
+			continue
+		}
+		for _, param := range append(fn.Args, fn.Results...) {
+			if _, ok := param.Type.(types.TChan); ok {
+				errs = append(errs, fmt.Errorf("%s: channel %s is not allowed with %s", fn.Name, param.Name, GrpcDirectTag))
+			}
+		}
+	}
+	return errs
+}